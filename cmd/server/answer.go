@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/afumu/openlink/internal/question"
+	"github.com/afumu/openlink/internal/security"
+)
+
+// runAnswer 通过本机运行的 HTTP 服务查看和回答 question 工具提出的问题：
+//
+//	openlink answer                         列出等待回答的问题
+//	openlink answer <id> "使用 PostgreSQL"  回答问题
+//	openlink answer <id> 1 3                多选题给出多个选项（原文或序号）
+func runAnswer(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("answer", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: openlink answer [-port 端口] [<问题 id> <回答> ...]")
+		fs.PrintDefaults()
+	}
+	port := fs.Int("port", 39527, "openlink 服务端口")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) == 1 {
+		fs.Usage()
+		return 2
+	}
+
+	settings, err := security.LoadSettings()
+	if err != nil {
+		fmt.Fprintf(stderr, "读取 settings.json 失败: %v\n", err)
+		return 1
+	}
+	if settings.Token == "" {
+		fmt.Fprintln(stderr, "settings.json 中没有 token，请先启动 openlink 服务")
+		return 1
+	}
+	client := &answerClient{base: fmt.Sprintf("http://127.0.0.1:%d", *port), token: settings.Token}
+
+	if len(positional) == 0 {
+		var body struct {
+			Questions []question.Question `json:"questions"`
+		}
+		if err := client.do(http.MethodGet, "/questions", nil, &body); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		printQuestions(stdout, body.Questions)
+		return 0
+	}
+
+	// 只有一个回答时按字符串提交（文本、单选、确认），多个时按数组提交（多选）
+	var value interface{} = positional[1]
+	if len(positional) > 2 {
+		value = positional[1:]
+	}
+	var body struct {
+		Answer question.Answer `json:"answer"`
+	}
+	if err := client.do(http.MethodPost, "/questions/"+positional[0]+"/answer", map[string]interface{}{"answer": value}, &body); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "已回答: %s\n", body.Answer.String())
+	return 0
+}
+
+func printQuestions(w io.Writer, questions []question.Question) {
+	if len(questions) == 0 {
+		fmt.Fprintln(w, "没有等待回答的问题")
+		return
+	}
+	for i, q := range questions {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s  [%s] %s\n", q.ID, q.Type, q.Question)
+		for j, opt := range q.Options {
+			fmt.Fprintf(w, "    %d. %s\n", j+1, opt)
+		}
+		if len(q.Default) > 0 {
+			fmt.Fprintf(w, "    默认: %s\n", strings.Join(q.Default, ", "))
+		}
+		fmt.Fprintf(w, "    剩余 %d 秒\n", int(time.Until(q.ExpiresAt).Seconds()))
+	}
+}
+
+// answerClient 使用 settings.json 中的 token 调用本机 openlink 服务
+type answerClient struct {
+	base  string
+	token string
+}
+
+func (c *answerClient) do(method, path string, in, out interface{}) error {
+	var reqBody io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.base+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return fmt.Errorf("连接 openlink 服务失败（是否已启动？）: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			return fmt.Errorf("%s: %s", resp.Status, e.Error)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.Unmarshal(data, out)
+}
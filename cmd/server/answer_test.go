@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afumu/openlink/internal/question"
+)
+
+// questionServer 模拟 openlink 服务的 /questions 接口
+func questionServer(t *testing.T, broker *question.Broker) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/questions", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"questions": broker.Pending()})
+	})
+	mux.HandleFunc("/questions/", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Answer interface{} `json:"answer"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/questions/"), "/answer")
+		ans, err := broker.Answer(id, req.Answer)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"answer": ans})
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return u.Port()
+}
+
+func TestRunAnswer(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".openlink"), 0700)
+	os.WriteFile(filepath.Join(home, ".openlink", "settings.json"), []byte(`{"token":"tok"}`), 0600)
+
+	broker := question.NewBroker()
+	port := questionServer(t, broker)
+	ansCh := make(chan question.Answer, 1)
+	go func() {
+		ans, _ := broker.Ask(context.Background(), question.Question{Question: "Which db?", Options: []string{"MySQL", "PostgreSQL"}}, time.Minute)
+		ansCh <- ans
+	}()
+	var pending []question.Question
+	for i := 0; i < 200 && len(pending) == 0; i++ {
+		time.Sleep(5 * time.Millisecond)
+		pending = broker.Pending()
+	}
+	if len(pending) != 1 {
+		t.Fatal("question was not registered")
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runAnswer([]string{"-port", port}, &stdout, &stderr); code != 0 {
+		t.Fatalf("list: code=%d stderr=%s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), pending[0].ID) || !strings.Contains(stdout.String(), "2. PostgreSQL") {
+		t.Errorf("list output = %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := runAnswer([]string{"-port", port, pending[0].ID, "Oracle"}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "not one of the options") {
+		t.Errorf("invalid answer: code=%d stderr=%s", code, stderr.String())
+	}
+	if code := runAnswer([]string{"-port", port, pending[0].ID, "2"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("answer: code=%d stderr=%s", code, stderr.String())
+	}
+	if ans := <-ansCh; ans.String() != "PostgreSQL" {
+		t.Errorf("answer = %q", ans.String())
+	}
+}
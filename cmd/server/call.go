@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/afumu/openlink/internal/executor"
+	"github.com/afumu/openlink/internal/mcp"
+	"github.com/afumu/openlink/internal/security"
+	"github.com/afumu/openlink/internal/types"
+)
+
+// runCall 在进程内直接调用一个工具，不经过 HTTP 服务，便于调试和编写脚本：
+//
+//	openlink call read_file path=main.go limit=20
+//	openlink call edit --json '{"path":"a.go","old_string":"x","new_string":"y"}'
+//	openlink call --list
+func runCall(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cwd, _ := os.Getwd()
+	fs := flag.NewFlagSet("call", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: openlink call <工具> [key=value ...] [--json JSON] [--raw] [-dir 工作目录]")
+		fmt.Fprintln(stderr, "      openlink call --list")
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", cwd, "工作目录")
+	timeout := fs.Int("timeout", 60, "超时(秒)")
+	jsonArgs := fs.String("json", "", "以 JSON 对象提供参数，为 - 时从标准输入读取")
+	raw := fs.Bool("raw", false, "不追加身份提醒")
+	list := fs.Bool("list", false, "以 JSON 输出全部工具及参数 schema")
+	session := fs.String("session", "", "会话名（影响 todo、skill 等按会话保存的状态）")
+	verbose := fs.Bool("v", false, "输出执行日志")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if !*list && len(positional) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+	settings, err := security.LoadSettings()
+	if err != nil {
+		fmt.Fprintf(stderr, "读取 settings.json 失败: %v\n", err)
+		return 1
+	}
+	exec := executor.New(&types.Config{
+		RootDir:         *dir,
+		Timeout:         *timeout,
+		Search:          settings.Search,
+		MCPServers:      settings.MCPServers,
+		DisableReminder: *raw,
+		Unattended:      true,
+		Reinject:        settings.Reinject,
+		Output:          settings.Output,
+		Redact:          settings.Redact,
+		Paths:           settings.Paths,
+		Token:           settings.Token,
+	})
+	defer exec.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// MCP server 在后台启动，等首次握手结束后再列出或调用，"<server>.<tool>" 才已注册
+	if err := exec.WaitMCP(ctx); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *list {
+		return printTools(exec, stdout, stderr)
+	}
+
+	name := positional[0]
+	toolArgs, err := parseCallArgs(positional[1:], *jsonArgs, stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	resp := exec.Execute(ctx, &types.ToolRequest{Name: name, Args: toolArgs, Session: *session})
+	if resp.Status == "error" {
+		fmt.Fprintln(stderr, resp.Output)
+		return 1
+	}
+	fmt.Fprintln(stdout, resp.Output)
+	return 0
+}
+
+// parseCallArgs 合并 --json 对象和 key=value 参数，后者优先；key=value 的值保持为字符串，
+// 与浏览器扩展传入的参数一致
+func parseCallArgs(pairs []string, jsonArgs string, stdin io.Reader) (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+	if jsonArgs == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, err
+		}
+		jsonArgs = string(data)
+	}
+	if strings.TrimSpace(jsonArgs) != "" {
+		if err := json.Unmarshal([]byte(jsonArgs), &args); err != nil {
+			return nil, fmt.Errorf("invalid --json: %w", err)
+		}
+	}
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid argument %q: expected key=value", pair)
+		}
+		args[key] = value
+	}
+	return args, nil
+}
+
+func printTools(exec *executor.Executor, stdout, stderr io.Writer) int {
+	infos := exec.ListTools()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	tools := make([]mcp.Tool, 0, len(infos))
+	for _, info := range infos {
+		tools = append(tools, mcp.Tool{Name: info.Name, Description: info.Description, InputSchema: mcp.InputSchema(info.Parameters)})
+	}
+	data, err := json.MarshalIndent(tools, "", "  ")
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, string(data))
+	return 0
+}
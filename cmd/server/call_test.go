@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func callTool(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := runCall(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunCall(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "hello.txt"), []byte("hello call\n"), 0644)
+
+	t.Run("key=value args", func(t *testing.T) {
+		code, out, _ := callTool(t, "", "read_file", "path=hello.txt", "-dir", root, "--raw")
+		if code != 0 || !strings.Contains(out, "hello call") || strings.Contains(out, "[系统提示]") {
+			t.Errorf("code=%d out=%q", code, out)
+		}
+	})
+
+	t.Run("reminder without --raw", func(t *testing.T) {
+		_, out, _ := callTool(t, "", "read_file", "path=hello.txt", "-dir", root)
+		if !strings.Contains(out, "[系统提示]") {
+			t.Errorf("expected reminder, got %q", out)
+		}
+	})
+
+	t.Run("json args from stdin", func(t *testing.T) {
+		code, _, errOut := callTool(t, `{"path":"out.txt","content":"a=b"}`, "write_file", "--json", "-", "-dir", root, "--raw")
+		if data, _ := os.ReadFile(filepath.Join(root, "out.txt")); code != 0 || string(data) != "a=b" {
+			t.Errorf("code=%d stderr=%q file=%q", code, errOut, data)
+		}
+	})
+
+	t.Run("tool error exits 1", func(t *testing.T) {
+		code, _, errOut := callTool(t, "", "read_file", "path=missing.txt", "-dir", root, "--raw")
+		if code != 1 || errOut == "" {
+			t.Errorf("code=%d stderr=%q", code, errOut)
+		}
+	})
+
+	t.Run("malformed argument", func(t *testing.T) {
+		if code, _, _ := callTool(t, "", "read_file", "hello.txt", "-dir", root); code != 2 {
+			t.Errorf("code=%d", code)
+		}
+	})
+
+	t.Run("list prints schemas", func(t *testing.T) {
+		code, out, _ := callTool(t, "", "--list", "-dir", root)
+		var tools []map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &tools); code != 0 || err != nil {
+			t.Fatalf("code=%d err=%v out=%q", code, err, out)
+		}
+		found := false
+		for _, tool := range tools {
+			if tool["name"] == "read_file" && tool["inputSchema"].(map[string]interface{})["type"] == "object" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("read_file not listed: %s", out)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/afumu/openlink/internal/agent"
+	"github.com/afumu/openlink/internal/security"
+	"github.com/afumu/openlink/internal/storage"
+	"github.com/afumu/openlink/internal/tool"
+	"github.com/afumu/openlink/internal/types"
+)
+
+const day = 24 * time.Hour
+
+// pruneInterval 是服务运行期间定期清理的间隔
+const pruneInterval = time.Hour
+
+// stores 返回 ~/.openlink 下需要清理的数据目录，默认策略可被 settings.json 的 retention 覆盖
+func stores(settings *types.Settings) []storage.Store {
+	home, _ := os.UserHomeDir()
+	defaults := []storage.Store{
+		{Name: "tool-output", Dir: tool.OutputDir(), MaxAge: 7 * day, MaxBytes: 100 << 20},
+		{Name: "runs", Dir: agent.TranscriptDir(), MaxAge: 30 * day, MaxBytes: 100 << 20},
+		{Name: "todos", Dir: filepath.Join(home, ".openlink", "todos"), MaxAge: 90 * day},
+		{Name: "checkpoints", Dir: filepath.Join(home, ".openlink", "checkpoints"), MaxAge: 30 * day, MaxBytes: 200 << 20},
+	}
+	return storage.WithPolicy(defaults, settings.Retention)
+}
+
+// runGC 按保留策略立即清理 ~/.openlink 下的截断输出、运行记录、待办事项和检查点
+func runGC(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gc", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: openlink gc [--dry-run]")
+		fs.PrintDefaults()
+	}
+	dryRun := fs.Bool("dry-run", false, "只显示将要删除的内容，不实际删除")
+	if _, err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+
+	settings, err := security.LoadSettings()
+	if err != nil {
+		fmt.Fprintf(stderr, "读取 settings.json 失败: %v\n", err)
+		return 1
+	}
+	code := 0
+	now := time.Now()
+	for _, s := range stores(settings) {
+		r, err := s.Prune(now, *dryRun)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", s.Name, err)
+			code = 1
+		}
+		verb := "删除"
+		if *dryRun {
+			verb = "将删除"
+		}
+		fmt.Fprintf(stdout, "%-12s %s %d 个文件（%s），剩余 %d 个文件（%s）", r.Name, verb, r.Removed, storage.FormatSize(r.Freed), r.Files, storage.FormatSize(r.Bytes))
+		if r.Fixed > 0 {
+			fmt.Fprintf(stdout, "，%s收紧 %d 个文件权限", strings.TrimSuffix(verb, "删除"), r.Fixed)
+		}
+		fmt.Fprintln(stdout)
+	}
+	return code
+}
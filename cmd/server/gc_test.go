@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunGC(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".openlink", "tool-output")
+	os.MkdirAll(dir, 0755)
+	old := filepath.Join(dir, "old")
+	os.WriteFile(old, []byte("secret"), 0644)
+	mod := time.Now().Add(-30 * 24 * time.Hour)
+	os.Chtimes(old, mod, mod)
+	os.WriteFile(filepath.Join(home, ".openlink", "settings.json"), []byte(`{"retention":{"tool-output":{"max_age_days":60}}}`), 0600)
+
+	var stdout, stderr bytes.Buffer
+	if code := runGC(nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("code=%d stderr=%s", code, stderr.String())
+	}
+	if _, err := os.Stat(old); err != nil {
+		t.Fatal("file within the configured max age should be kept")
+	}
+
+	os.WriteFile(filepath.Join(home, ".openlink", "settings.json"), []byte(`{}`), 0600)
+	stdout.Reset()
+	if code := runGC([]string{"--dry-run"}, &stdout, &stderr); code != 0 || !strings.Contains(stdout.String(), "tool-output  将删除 1 个文件") {
+		t.Fatalf("dry run: %s", stdout.String())
+	}
+	if _, err := os.Stat(old); err != nil {
+		t.Fatal("dry run must not delete")
+	}
+	stdout.Reset()
+	runGC(nil, &stdout, &stderr)
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expected expired output removed, out=%s", stdout.String())
+	}
+}
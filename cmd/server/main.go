@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -8,11 +9,32 @@ import (
 
 	"github.com/afumu/openlink/internal/security"
 	"github.com/afumu/openlink/internal/server"
+	"github.com/afumu/openlink/internal/storage"
 	"github.com/afumu/openlink/internal/types"
 	"github.com/afumu/openlink/prompts"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "skill":
+			os.Exit(runSkill(os.Args[2:]))
+		case "mcp":
+			os.Exit(runMCP(os.Args[2:]))
+		case "run":
+			os.Exit(runRun(os.Args[2:]))
+		case "call":
+			os.Exit(runCall(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		case "gc":
+			os.Exit(runGC(os.Args[2:], os.Stdout, os.Stderr))
+		case "answer":
+			os.Exit(runAnswer(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+	runServer()
+}
+
+func runServer() {
 	cwd, err := os.Getwd()
 	if err != nil {
 		log.Fatal(err)
@@ -20,6 +42,8 @@ func main() {
 	dir := flag.String("dir", cwd, "工作目录")
 	port := flag.Int("port", 39527, "端口")
 	timeout := flag.Int("timeout", 60, "超时(秒)")
+	searchURL := flag.String("search-url", "", "web_search 搜索后端地址（覆盖 settings.json）")
+	searchBackend := flag.String("search-backend", "", "web_search 搜索后端类型: searxng 或 json")
 	flag.Parse()
 
 	token, err := security.LoadOrCreateToken()
@@ -27,20 +51,60 @@ func main() {
 		log.Fatal(err)
 	}
 
+	settings, err := security.LoadSettings()
+	if err != nil {
+		log.Fatalf("读取 settings.json 失败: %v", err)
+	}
+
 	config := &types.Config{
-		RootDir:       *dir,
-		Port:          *port,
-		Timeout:       *timeout,
-		Token:         token,
-		DefaultPrompt: prompts.DefaultPrompt,
+		RootDir:    *dir,
+		Port:       *port,
+		Timeout:    *timeout,
+		Token:      token,
+		Prompts:    prompts.Templates,
+		Search:     settings.Search,
+		MCPServers: settings.MCPServers,
+		Reinject:   settings.Reinject,
+		Output:     settings.Output,
+		Redact:     settings.Redact,
+		Paths:      settings.Paths,
+	}
+	if *searchURL != "" {
+		var search types.SearchConfig
+		if settings.Search != nil {
+			search = *settings.Search
+		}
+		search.URL = *searchURL
+		if *searchBackend != "" {
+			search.Backend = *searchBackend
+		}
+		config.Search = &search
 	}
 
 	fmt.Printf("\n认证 URL: http://127.0.0.1:%d/auth?token=%s\n", *port, token)
 	fmt.Printf("请在浏览器扩展中输入此 URL\n\n")
 
+	go storage.Run(context.Background(), stores(settings), pruneInterval)
+
 	srv := server.New(config)
 
 	if err := srv.Run(); err != nil {
 		log.Fatalf("服务器运行出错: %v", err)
 	}
 }
+
+// parseArgs 解析子命令参数，允许选项出现在位置参数之后（如 install ./x --global）
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
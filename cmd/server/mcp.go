@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/afumu/openlink/internal/executor"
+	"github.com/afumu/openlink/internal/mcp"
+	"github.com/afumu/openlink/internal/security"
+	"github.com/afumu/openlink/internal/storage"
+	"github.com/afumu/openlink/internal/types"
+)
+
+const version = "1.0"
+
+// runMCP 以 MCP server 模式运行：通过 stdin/stdout 暴露与 HTTP 接口相同的工具集。
+// stdout 专用于协议消息，日志写到 stderr。
+func runMCP(args []string) int {
+	cwd, _ := os.Getwd()
+	fs := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: openlink mcp [-dir 工作目录] [-timeout 秒]")
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", cwd, "工作目录")
+	timeout := fs.Int("timeout", 60, "超时(秒)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+
+	log.SetOutput(os.Stderr)
+	settings, err := security.LoadSettings()
+	if err != nil {
+		log.Printf("读取 settings.json 失败: %v", err)
+		return 1
+	}
+	config := &types.Config{
+		RootDir:         *dir,
+		Timeout:         *timeout,
+		Search:          settings.Search,
+		MCPServers:      settings.MCPServers,
+		Output:          settings.Output,
+		Redact:          settings.Redact,
+		Paths:           settings.Paths,
+		Token:           settings.Token,
+		DisableReminder: true,
+		Unattended:      true,
+	}
+	exec := executor.New(config)
+	defer exec.Close()
+	// 与 HTTP 服务一样监听 skills 目录，常驻进程中新增或修改的 skill 及其工具无需重启即可生效
+	exec.Skills().Watch()
+	defer exec.Skills().Close()
+	go storage.Run(context.Background(), stores(settings), pruneInterval)
+	// 服务端不发送 tools/list_changed 通知，先等下游 MCP server 完成握手，客户端首次列出的工具才完整
+	exec.WaitMCP(context.Background())
+
+	if err := mcp.NewServer(exec, version).Serve(os.Stdin, os.Stdout); err != nil {
+		log.Printf("[MCP] %v", err)
+		return 1
+	}
+	return 0
+}
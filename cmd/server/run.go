@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/afumu/openlink/internal/agent"
+	"github.com/afumu/openlink/internal/executor"
+	"github.com/afumu/openlink/internal/security"
+	"github.com/afumu/openlink/internal/types"
+)
+
+// runRun 无界面运行一个任务：由 OpenAI 兼容的模型接口驱动工具调用，结束后输出最终回答和文件变更摘要。
+func runRun(args []string) int {
+	cwd, _ := os.Getwd()
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: openlink run --model-url URL --task 任务 [--model 模型] [--api-key KEY] [-dir 工作目录]")
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", cwd, "工作目录")
+	timeout := fs.Int("timeout", 60, "单个工具调用超时(秒)")
+	modelURL := fs.String("model-url", "", "OpenAI 兼容接口地址，如 http://localhost:11434/v1")
+	model := fs.String("model", "", "模型名称")
+	apiKey := fs.String("api-key", "", "API Key（默认读取环境变量 OPENAI_API_KEY）")
+	task := fs.String("task", "", "任务描述，为 - 时从标准输入读取")
+	maxSteps := fs.Int("max-steps", agent.DefaultMaxSteps, "最多模型轮次")
+	transcript := fs.String("transcript", "", "运行记录保存路径（默认 ~/.openlink/runs/<时间>.json）")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if *task == "" && len(positional) > 0 {
+		*task = strings.Join(positional, " ")
+	}
+	if *task == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "读取任务失败: %v\n", err)
+			return 1
+		}
+		*task = string(data)
+	}
+	if *modelURL == "" || strings.TrimSpace(*task) == "" {
+		fs.Usage()
+		return 2
+	}
+	if *apiKey == "" {
+		*apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	root, err := filepath.Abs(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	settings, err := security.LoadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取 settings.json 失败: %v\n", err)
+		return 1
+	}
+	exec := executor.New(&types.Config{
+		RootDir:         root,
+		Timeout:         *timeout,
+		Search:          settings.Search,
+		MCPServers:      settings.MCPServers,
+		Output:          settings.Output,
+		Redact:          settings.Redact,
+		Paths:           settings.Paths,
+		Token:           settings.Token,
+		DisableReminder: true,
+		Unattended:      true,
+	})
+	defer exec.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := exec.WaitMCP(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "等待 MCP server 启动失败: %v\n", err)
+		return 1
+	}
+	runner := &agent.Runner{
+		Client:   agent.NewChatClient(*modelURL, *apiKey, *model),
+		Executor: exec,
+		RootDir:  root,
+		MaxSteps: *maxSteps,
+		Log:      os.Stderr,
+	}
+	tr, runErr := runner.Run(ctx, *task)
+	if tr == nil {
+		fmt.Fprintf(os.Stderr, "运行失败: %v\n", runErr)
+		return 1
+	}
+
+	if *transcript == "" {
+		*transcript = filepath.Join(agent.TranscriptDir(), time.Now().Format("20060102-150405")+".json")
+	}
+	if err := tr.Save(*transcript); err != nil {
+		fmt.Fprintf(os.Stderr, "保存运行记录失败: %v\n", err)
+	}
+
+	if tr.Final != "" {
+		fmt.Println(tr.Final)
+		fmt.Println()
+	}
+	fmt.Printf("工具调用: %d 次\n", len(tr.Steps))
+	fmt.Println(tr.Changes.String())
+	fmt.Printf("运行记录: %s\n", *transcript)
+	if runErr != nil {
+		fmt.Fprintf(os.Stderr, "运行失败: %v\n", runErr)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/afumu/openlink/internal/skill"
+)
+
+const skillUsage = `用法: openlink skill <命令> [选项]
+
+命令:
+  list                         列出全部 skills，显示来源目录及同名覆盖关系
+  install <目录|zip> [--global|--project] [--force]
+                               安装 skill（默认安装到 <工作目录>/.openlink/skills）
+  validate [名称|目录...]       检查 frontmatter 和引用的文件，省略参数时检查全部 skills
+  remove <名称>                删除当前生效的同名 skill
+
+通用选项:
+  -dir string                  工作目录（默认当前目录）
+`
+
+func runSkill(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, skillUsage)
+		return 2
+	}
+	cwd, _ := os.Getwd()
+	fs := flag.NewFlagSet("skill "+args[0], flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, skillUsage) }
+	dir := fs.String("dir", cwd, "工作目录")
+	global := fs.Bool("global", false, "安装到 ~/.openlink/skills")
+	project := fs.Bool("project", false, "安装到 <工作目录>/.openlink/skills")
+	force := fs.Bool("force", false, "覆盖已存在的 skill")
+	rest, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		return skillList(os.Stdout, *dir)
+	case "install":
+		if len(rest) != 1 || (*global && *project) {
+			fmt.Fprint(os.Stderr, skillUsage)
+			return 2
+		}
+		dest := skill.ProjectDir(*dir)
+		if *global {
+			dest = skill.GlobalDir()
+		}
+		return skillInstall(os.Stdout, *dir, rest[0], dest, *force)
+	case "validate":
+		return skillValidate(os.Stdout, *dir, rest)
+	case "remove":
+		if len(rest) != 1 {
+			fmt.Fprint(os.Stderr, skillUsage)
+			return 2
+		}
+		removed, err := skill.Remove(*dir, rest[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "删除失败: %v\n", err)
+			return 1
+		}
+		fmt.Printf("已删除 %s\n", removed)
+		if info, ok := skill.Get(*dir, rest[0]); ok {
+			fmt.Printf("注意: 同名 skill 仍存在于 %s，现在生效的是该副本\n", info.Dir)
+		}
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "未知命令: %s\n\n%s", args[0], skillUsage)
+	return 2
+}
+
+func skillList(w io.Writer, rootDir string) int {
+	entries := skill.LoadAll(rootDir)
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "没有找到 skills。搜索目录:")
+		for _, d := range skill.SkillDirs(rootDir) {
+			fmt.Fprintf(w, "  %s\n", d)
+		}
+		return 0
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tVERSION\tSTATUS\tDIR")
+	for _, e := range entries {
+		status := "ok"
+		switch {
+		case e.ShadowedBy != "":
+			status = "shadowed"
+		case e.Broken():
+			status = "invalid"
+		}
+		version := e.Version
+		if version == "" {
+			version = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Name, version, status, e.Dir)
+	}
+	tw.Flush()
+
+	header := false
+	for _, e := range entries {
+		if e.ShadowedBy == "" {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(w, "\n同名覆盖:")
+			header = true
+		}
+		fmt.Fprintf(w, "  %s\n    被 %s 覆盖\n", e.Location, e.ShadowedBy)
+	}
+	return 0
+}
+
+func skillInstall(w io.Writer, rootDir, src, dest string, force bool) int {
+	info, err := skill.Install(src, dest, force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "安装失败: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(w, "已安装 %s 到 %s\n", info.Name, info.Dir)
+	printDiagnostics(w, skill.Validate(info))
+	if active, ok := skill.Get(rootDir, info.Name); ok && filepath.Clean(active.Dir) != filepath.Clean(info.Dir) {
+		fmt.Fprintf(w, "注意: %s 中的同名 skill 优先级更高，新安装的副本不会生效\n", active.Dir)
+	}
+	return 0
+}
+
+func skillValidate(w io.Writer, rootDir string, targets []string) int {
+	var infos []skill.Info
+	if len(targets) == 0 {
+		for _, e := range skill.LoadAll(rootDir) {
+			infos = append(infos, e.Info)
+		}
+	}
+	failed := false
+	for _, target := range targets {
+		if fi, err := os.Stat(target); err == nil && fi.IsDir() {
+			info, err := skill.Inspect(target)
+			if err != nil {
+				fmt.Fprintf(w, "✗ %s: %v\n", target, err)
+				failed = true
+				continue
+			}
+			infos = append(infos, info)
+			continue
+		}
+		info, ok := skill.Get(rootDir, target)
+		if !ok {
+			fmt.Fprintf(w, "✗ %s: skill not found\n", target)
+			failed = true
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	for _, info := range infos {
+		diags := skill.Validate(info)
+		mark := "✓"
+		for _, d := range diags {
+			if d.Severity == skill.SeverityError {
+				mark = "✗"
+				failed = true
+			}
+		}
+		fmt.Fprintf(w, "%s %s (%s)\n", mark, info.Name, info.Location)
+		printDiagnostics(w, diags)
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func printDiagnostics(w io.Writer, diags []skill.Diagnostic) {
+	for _, d := range diags {
+		fmt.Fprintf(w, "    %s: %s\n", strings.ToUpper(d.Severity[:1])+d.Severity[1:], d.Message)
+	}
+}
@@ -138,16 +138,53 @@ function markExecuted(key: string): void {
   } catch {}
 }
 
-async function executeToolCallRaw(toolCall: any): Promise<string> {
+async function executeToolCallRaw(toolCall: any): Promise<{ text: string; diffs?: any[] }> {
   const { authToken, apiUrl } = await chrome.storage.local.get(['authToken', 'apiUrl']);
-  if (!apiUrl) return '请先在插件中配置 API 地址';
+  if (!apiUrl) return { text: '请先在插件中配置 API 地址' };
   const headers: any = { 'Content-Type': 'application/json' };
   if (authToken) headers['Authorization'] = `Bearer ${authToken}`;
-  const response = await bgFetch(`${apiUrl}/exec`, { method: 'POST', headers, body: JSON.stringify(toolCall) });
-  if (response.status === 401) return '认证失败，请在插件中重新输入 Token';
-  if (!response.ok) return `[OpenLink 错误] HTTP ${response.status}`;
+  const response = await bgFetch(`${apiUrl}/exec`, { method: 'POST', headers, body: JSON.stringify({ ...toolCall, session: getConversationId(), platform: getPromptPlatform() }) });
+  if (response.status === 401) return { text: '认证失败，请在插件中重新输入 Token' };
+  if (!response.ok) return { text: `[OpenLink 错误] HTTP ${response.status}` };
   const result = JSON.parse(response.body);
-  return result.output || result.error || '[OpenLink] 空响应';
+  return { text: result.output || result.error || '[OpenLink] 空响应', diffs: result.diffs || (result.diff ? [result.diff] : []) };
+}
+
+// renderDiff 把 write_file/edit/multi_edit 返回的结构化差异渲染为带行号的差异卡片
+function renderDiff(diff: any): HTMLElement {
+  const box = document.createElement('div');
+  box.style.cssText = 'margin-top:10px;background:#181825;border-radius:6px;max-height:300px;overflow:auto;font-family:monospace;font-size:12px';
+  const title = document.createElement('div');
+  title.style.cssText = 'padding:6px 8px;border-bottom:1px solid #313244;color:#cdd6f4';
+  title.textContent = `${diff.created ? '新建' : '修改'} ${diff.path}  +${diff.added} -${diff.removed}`;
+  box.appendChild(title);
+  const colors: Record<string, string> = { '+': 'background:#1e3a2a;color:#a6e3a1', '-': 'background:#3a1e24;color:#f38ba8', ' ': 'color:#a6adc8' };
+  for (const hunk of diff.hunks || []) {
+    const head = document.createElement('div');
+    head.style.cssText = 'padding:2px 8px;color:#89b4fa';
+    head.textContent = `@@ -${hunk.oldStart},${hunk.oldLines} +${hunk.newStart},${hunk.newLines} @@`;
+    box.appendChild(head);
+    for (const line of hunk.lines || []) {
+      const row = document.createElement('div');
+      row.style.cssText = `padding:0 8px;white-space:pre;${colors[line.op] || ''}`;
+      const num = line.op === '-' ? line.old : line.new;
+      row.textContent = `${String(num ?? '').padStart(4)} ${line.op} ${line.text}`;
+      box.appendChild(row);
+    }
+  }
+  if (diff.truncated) {
+    const more = document.createElement('div');
+    more.style.cssText = 'padding:2px 8px;color:#888';
+    more.textContent = '… 差异过长，已截断';
+    box.appendChild(more);
+  }
+  for (const note of diff.notes || []) {
+    const row = document.createElement('div');
+    row.style.cssText = 'padding:2px 8px;color:#888';
+    row.textContent = `\\ ${note}`;
+    box.appendChild(row);
+  }
+  return box;
 }
 
 function renderToolCard(data: any, _full: string, sourceEl: Element, key: string, processed: Set<string>) {
@@ -200,7 +237,7 @@ function renderToolCard(data: any, _full: string, sourceEl: Element, key: string
     execBtn.textContent = '执行中...';
     markExecuted(key);
     try {
-      const text = await executeToolCallRaw(data);
+      const { text, diffs } = await executeToolCallRaw(data);
       const resultBox = document.createElement('div');
       resultBox.style.cssText = 'margin-top:10px;background:#181825;border-radius:6px;padding:8px;max-height:200px;overflow-y:auto;font-family:monospace;font-size:12px;color:#cdd6f4;white-space:pre-wrap';
       resultBox.textContent = text;
@@ -208,7 +245,9 @@ function renderToolCard(data: any, _full: string, sourceEl: Element, key: string
       insertBtn.textContent = '插入到对话';
       insertBtn.style.cssText = 'margin-top:6px;padding:4px 12px;background:#313244;color:#89b4fa;border:1px solid #89b4fa;border-radius:6px;cursor:pointer;font-size:12px';
       insertBtn.onclick = () => fillAndSend(text, true);
-      card.appendChild(resultBox);
+      const changed = (diffs || []).filter((d: any) => d?.hunks?.length || d?.notes?.length);
+      if (changed.length) changed.forEach((d: any) => card.appendChild(renderDiff(d)));
+      else card.appendChild(resultBox);
       card.appendChild(insertBtn);
       execBtn.textContent = '✅ 已执行';
     } catch {
@@ -326,12 +365,24 @@ async function bgFetch(url: string, options?: any): Promise<{ ok: boolean; statu
   return chrome.runtime.sendMessage({ type: 'FETCH', url, options });
 }
 
+// 初始化提示词按平台选择模板（不同界面对 XML、Markdown 的处理不同）
+function getPromptPlatform(): string {
+  const h = location.hostname;
+  if (h.includes('gemini.google.com')) return 'gemini';
+  if (h.includes('qwen.ai')) return 'qwen';
+  if (h.includes('chatgpt.com')) return 'chatgpt';
+  if (h.includes('claude.ai')) return 'claude';
+  if (h.includes('deepseek.com')) return 'deepseek';
+  return '';
+}
+
 async function sendInitPrompt() {
   const { authToken, apiUrl } = await chrome.storage.local.get(['authToken', 'apiUrl']);
   if (!apiUrl) { alert('请先在插件中配置 API 地址'); return; }
   const headers: any = { 'Content-Type': 'application/json' };
   if (authToken) headers['Authorization'] = `Bearer ${authToken}`;
-  const resp = await bgFetch(`${apiUrl}/prompt`, { headers });
+  const params = new URLSearchParams({ platform: getPromptPlatform(), session: getConversationId() });
+  const resp = await bgFetch(`${apiUrl}/prompt?${params}`, { headers });
   if (!resp.ok) { alert('获取初始化提示词失败'); return; }
   fillAndSend(resp.body, true);
 }
@@ -380,7 +431,7 @@ async function executeToolCall(toolCall: any) {
     const response = await bgFetch(`${apiUrl}/exec`, {
       method: 'POST',
       headers,
-      body: JSON.stringify(toolCall)
+      body: JSON.stringify({ ...toolCall, session: getConversationId(), platform: getPromptPlatform() })
     });
 
     if (response.status === 401) { fillAndSend('认证失败，请在插件中重新输入 Token', false); return; }
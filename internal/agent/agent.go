@@ -0,0 +1,225 @@
+// Package agent 在命令行中以无界面方式驱动 Executor：把工具以 function calling 形式交给
+// OpenAI 兼容的模型接口，执行模型返回的工具调用，直到模型给出最终回答。
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/afumu/openlink/internal/mcp"
+	"github.com/afumu/openlink/internal/tool"
+	"github.com/afumu/openlink/internal/types"
+)
+
+const DefaultMaxSteps = 50
+
+// Session 是无界面运行使用的会话名
+const Session = "run"
+
+// Executor 是 Runner 所需的执行器能力，由 executor.Executor 实现
+type Executor interface {
+	ListTools() []tool.ToolInfo
+	Execute(ctx context.Context, req *types.ToolRequest) *types.ToolResponse
+}
+
+// Step 记录一次工具调用
+type Step struct {
+	Tool     string                 `json:"tool"`
+	Args     map[string]interface{} `json:"args"`
+	Status   string                 `json:"status"`
+	Output   string                 `json:"output"`
+	Duration time.Duration          `json:"duration_ns"`
+}
+
+// Transcript 是一次运行的完整记录
+type Transcript struct {
+	Task      string    `json:"task"`
+	Model     string    `json:"model"`
+	RootDir   string    `json:"root_dir"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Messages  []Message `json:"messages"`
+	Steps     []Step    `json:"steps"`
+	Final     string    `json:"final"`
+	Changes   Changes   `json:"changes"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type Runner struct {
+	Client   *ChatClient
+	Executor Executor
+	RootDir  string
+	MaxSteps int
+	Log      io.Writer // 进度输出，可为 nil
+}
+
+// excludedTools 在无人值守时不可用：question 会阻塞等待用户回答
+var excludedTools = map[string]bool{"question": true}
+
+// funcNameRe 是 OpenAI function 名称允许的字符
+var funcNameRe = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// Run 执行任务直到模型不再调用工具或达到步数上限
+func (r *Runner) Run(ctx context.Context, task string) (*Transcript, error) {
+	maxSteps := r.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	tr := &Transcript{Task: task, Model: r.Client.Model, RootDir: r.RootDir, StartedAt: time.Now()}
+	before, err := Scan(r.RootDir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		tr.EndedAt = time.Now()
+		if after, err := Scan(r.RootDir); err == nil {
+			tr.Changes = Diff(before, after)
+		}
+	}()
+
+	tr.Messages = []Message{
+		{Role: "system", Content: systemPrompt(r.RootDir)},
+		{Role: "user", Content: task},
+	}
+
+	for turn := 0; ; turn++ {
+		// 每轮重新读取工具列表：MCP server 可能在运行中完成握手或重启，skill 工具也可能随 skill 增删变化
+		defs, names := r.toolDefs()
+		reply, err := r.Client.complete(ctx, tr.Messages, defs)
+		if err != nil {
+			tr.Error = err.Error()
+			return tr, err
+		}
+		reply.Role = "assistant"
+		tr.Messages = append(tr.Messages, reply)
+		if len(reply.ToolCalls) == 0 {
+			tr.Final = reply.Content
+			return tr, nil
+		}
+		if turn >= maxSteps {
+			err := fmt.Errorf("stopped after %d steps without a final answer", maxSteps)
+			tr.Error = err.Error()
+			return tr, err
+		}
+		for _, call := range reply.ToolCalls {
+			step := r.runCall(ctx, call, names)
+			tr.Steps = append(tr.Steps, step)
+			tr.Messages = append(tr.Messages, Message{Role: "tool", ToolCallID: call.ID, Content: step.Output})
+		}
+	}
+}
+
+func (r *Runner) runCall(ctx context.Context, call ToolCall, names map[string]string) Step {
+	name := call.Function.Name
+	if real, ok := names[name]; ok {
+		name = real
+	}
+	step := Step{Tool: name, Args: map[string]interface{}{}}
+	if args := strings.TrimSpace(call.Function.Arguments); args != "" {
+		if err := json.Unmarshal([]byte(args), &step.Args); err != nil {
+			step.Status = "error"
+			step.Output = fmt.Sprintf("invalid tool arguments JSON: %v", err)
+			r.logf("✗ %s: %s\n", name, step.Output)
+			return step
+		}
+	}
+	r.logf("→ %s %s\n", name, summarizeArgs(step.Args))
+	start := time.Now()
+	resp := r.Executor.Execute(ctx, &types.ToolRequest{Name: name, Args: step.Args, Session: Session})
+	step.Duration = time.Since(start)
+	step.Status = resp.Status
+	step.Output = resp.Output
+	if step.Output == "" {
+		step.Output = resp.Error
+	}
+	if resp.Status == "error" {
+		r.logf("✗ %s: %s\n", name, firstLine(step.Output))
+	}
+	return step
+}
+
+// toolDefs 把工具转换为 function 定义；名称中 OpenAI 不允许的字符（如 skill 工具的 "."）替换为 "__"，
+// 返回的 map 用于把模型返回的名称映射回工具名
+func (r *Runner) toolDefs() ([]toolDef, map[string]string) {
+	infos := r.Executor.ListTools()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	var defs []toolDef
+	names := map[string]string{}
+	for _, info := range infos {
+		if excludedTools[info.Name] {
+			continue
+		}
+		fname := funcNameRe.ReplaceAllString(strings.ReplaceAll(info.Name, ".", "__"), "_")
+		if len(fname) > 64 {
+			fname = fname[:64]
+		}
+		names[fname] = info.Name
+		defs = append(defs, toolDef{Type: "function", Function: functionDef{
+			Name:        fname,
+			Description: info.Description,
+			Parameters:  mcp.InputSchema(info.Parameters),
+		}})
+	}
+	return defs, names
+}
+
+func (r *Runner) logf(format string, args ...interface{}) {
+	if r.Log != nil {
+		fmt.Fprintf(r.Log, format, args...)
+	}
+}
+
+func systemPrompt(rootDir string) string {
+	return fmt.Sprintf(`你是 openlink，一个在本地工作区中自主完成任务的编程助手。
+工作目录: %s
+当前时间: %s
+
+- 通过提供的工具读取、搜索和修改文件，执行命令；文件路径相对于工作目录
+- 没有用户可以回答问题，遇到不确定的地方请自行做出合理判断
+- 任务完成后，不再调用工具，直接给出简短的总结`, rootDir, time.Now().Format("2006-01-02 15:04"))
+}
+
+func summarizeArgs(args map[string]interface{}) string {
+	data, _ := json.Marshal(args)
+	s := string(data)
+	if len(s) > 120 {
+		s = s[:120] + "…"
+	}
+	return s
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// TranscriptDir 返回默认的运行记录目录 ~/.openlink/runs
+func TranscriptDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "openlink-runs")
+	}
+	return filepath.Join(home, ".openlink", "runs")
+}
+
+// Save 把运行记录以 JSON 写入 path，自动创建父目录
+func (t *Transcript) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,208 @@
+package agent_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/afumu/openlink/internal/agent"
+	"github.com/afumu/openlink/internal/executor"
+	"github.com/afumu/openlink/internal/tool"
+	"github.com/afumu/openlink/internal/types"
+)
+
+// fakeModel 按顺序返回预设的 assistant 消息，并记录收到的请求
+type fakeModel struct {
+	mu       sync.Mutex
+	replies  []agent.Message
+	requests []map[string]interface{}
+}
+
+func (f *fakeModel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+		http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+		return
+	}
+	var req map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&req)
+	f.requests = append(f.requests, req)
+	reply := agent.Message{Role: "assistant", Content: "done"}
+	if len(f.replies) > 0 {
+		reply, f.replies = f.replies[0], f.replies[1:]
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"choices": []interface{}{map[string]interface{}{"message": reply, "finish_reason": "stop"}},
+	})
+}
+
+func toolCall(id, name string, args map[string]interface{}) agent.ToolCall {
+	data, _ := json.Marshal(args)
+	return agent.ToolCall{ID: id, Type: "function", Function: agent.FunctionCall{Name: name, Arguments: string(data)}}
+}
+
+func newRunner(t *testing.T, root string, model *fakeModel) *agent.Runner {
+	t.Helper()
+	srv := httptest.NewServer(model)
+	t.Cleanup(srv.Close)
+	t.Setenv("HOME", t.TempDir())
+	exec := executor.New(&types.Config{RootDir: root, Timeout: 10, DisableReminder: true})
+	t.Cleanup(func() { exec.Close() })
+	return &agent.Runner{
+		Client:   agent.NewChatClient(srv.URL+"/v1/", "sk-test", "fake"),
+		Executor: exec,
+		RootDir:  root,
+	}
+}
+
+func TestRun(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "old.txt"), []byte("old"), 0644)
+	model := &fakeModel{replies: []agent.Message{
+		{Role: "assistant", ToolCalls: []agent.ToolCall{
+			toolCall("call_1", "write_file", map[string]interface{}{"path": "hello.txt", "content": "hello run"}),
+			toolCall("call_2", "read_file", map[string]interface{}{"path": "missing.txt"}),
+		}},
+		{Role: "assistant", Content: "已创建 hello.txt"},
+	}}
+	runner := newRunner(t, root, model)
+
+	tr, err := runner.Run(context.Background(), "create hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Final != "已创建 hello.txt" {
+		t.Errorf("final = %q", tr.Final)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "hello.txt")); string(data) != "hello run" {
+		t.Errorf("hello.txt = %q", data)
+	}
+	if len(tr.Changes.Added) != 1 || tr.Changes.Added[0] != "hello.txt" || len(tr.Changes.Modified)+len(tr.Changes.Deleted) != 0 {
+		t.Errorf("changes = %+v", tr.Changes)
+	}
+	if len(tr.Steps) != 2 || tr.Steps[0].Status != "success" || tr.Steps[1].Status != "error" {
+		t.Errorf("steps = %+v", tr.Steps)
+	}
+
+	// 第二次请求应携带两个工具结果，且工具定义中不包含 question
+	if len(model.requests) != 2 {
+		t.Fatalf("got %d requests", len(model.requests))
+	}
+	msgs := model.requests[1]["messages"].([]interface{})
+	var toolMsgs int
+	for _, m := range msgs {
+		if m.(map[string]interface{})["role"] == "tool" {
+			toolMsgs++
+		}
+	}
+	if toolMsgs != 2 {
+		t.Errorf("expected 2 tool messages, got %d", toolMsgs)
+	}
+	for _, d := range model.requests[0]["tools"].([]interface{}) {
+		if name := d.(map[string]interface{})["function"].(map[string]interface{})["name"]; name == "question" {
+			t.Error("question tool should not be offered")
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "runs", "t.json")
+	if err := tr.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	var saved agent.Transcript
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Task != "create hello.txt" || len(saved.Messages) != 6 {
+		t.Errorf("saved transcript = %s", data)
+	}
+}
+
+// lateTools 模拟运行中才完成握手的 MCP server：第一次之后的 ListTools 多出一个工具
+type lateTools struct {
+	agent.Executor
+	calls int
+}
+
+func (l *lateTools) ListTools() []tool.ToolInfo {
+	l.calls++
+	infos := l.Executor.ListTools()
+	if l.calls > 1 {
+		infos = append(infos, tool.ToolInfo{Name: "db.query", Description: "query the database"})
+	}
+	return infos
+}
+
+func TestRunRefreshesTools(t *testing.T) {
+	root := t.TempDir()
+	model := &fakeModel{replies: []agent.Message{
+		{Role: "assistant", ToolCalls: []agent.ToolCall{toolCall("call_1", "list_dir", map[string]interface{}{"path": "."})}},
+		{Role: "assistant", Content: "done"},
+	}}
+	runner := newRunner(t, root, model)
+	runner.Executor = &lateTools{Executor: runner.Executor}
+	if _, err := runner.Run(context.Background(), "look around"); err != nil {
+		t.Fatal(err)
+	}
+	offered := func(req map[string]interface{}) bool {
+		for _, d := range req["tools"].([]interface{}) {
+			if d.(map[string]interface{})["function"].(map[string]interface{})["name"] == "db__query" {
+				return true
+			}
+		}
+		return false
+	}
+	if len(model.requests) != 2 || offered(model.requests[0]) || !offered(model.requests[1]) {
+		t.Error("tools registered during the run should be offered on the next turn")
+	}
+}
+
+func TestRunMaxSteps(t *testing.T) {
+	root := t.TempDir()
+	var replies []agent.Message
+	for i := 0; i < 5; i++ {
+		replies = append(replies, agent.Message{Role: "assistant", ToolCalls: []agent.ToolCall{
+			toolCall("c", "list_dir", map[string]interface{}{"path": "."}),
+		}})
+	}
+	runner := newRunner(t, root, &fakeModel{replies: replies})
+	runner.MaxSteps = 2
+
+	tr, err := runner.Run(context.Background(), "loop")
+	if err == nil || !strings.Contains(err.Error(), "2 steps") {
+		t.Fatalf("expected max steps error, got %v", err)
+	}
+	if len(tr.Steps) != 2 || tr.Error == "" {
+		t.Errorf("steps = %d, error = %q", len(tr.Steps), tr.Error)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "keep.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(root, "edit.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(root, "gone.txt"), []byte("a"), 0644)
+	os.MkdirAll(filepath.Join(root, ".git"), 0755)
+	before, err := agent.Scan(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(root, "edit.txt"), []byte("abc"), 0644)
+	os.Remove(filepath.Join(root, "gone.txt"))
+	os.MkdirAll(filepath.Join(root, "sub"), 0755)
+	os.WriteFile(filepath.Join(root, "sub", "new.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(root, ".git", "index"), []byte("a"), 0644)
+	after, _ := agent.Scan(root)
+
+	c := agent.Diff(before, after)
+	if strings.Join(c.Added, ",") != "sub/new.txt" || strings.Join(c.Modified, ",") != "edit.txt" || strings.Join(c.Deleted, ",") != "gone.txt" {
+		t.Errorf("changes = %+v", c)
+	}
+	if !strings.Contains(c.String(), "A sub/new.txt") {
+		t.Errorf("summary = %q", c.String())
+	}
+}
@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// Snapshot 记录工作区中每个文件的大小和修改时间（跳过 .git 目录）
+type Snapshot map[string]fileState
+
+// skipDirs 是不参与变更统计的目录
+var skipDirs = map[string]bool{".git": true, ".hg": true, ".svn": true}
+
+func Scan(rootDir string) (Snapshot, error) {
+	snap := Snapshot{}
+	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == rootDir {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != rootDir && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(rootDir, path)
+		snap[filepath.ToSlash(rel)] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return snap, err
+}
+
+// Changes 是两次快照之间的文件变化
+type Changes struct {
+	Added    []string `json:"added,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+	Deleted  []string `json:"deleted,omitempty"`
+}
+
+func Diff(before, after Snapshot) Changes {
+	var c Changes
+	for path, a := range after {
+		b, ok := before[path]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, path)
+		case a.size != b.size || !a.modTime.Equal(b.modTime):
+			c.Modified = append(c.Modified, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			c.Deleted = append(c.Deleted, path)
+		}
+	}
+	sort.Strings(c.Added)
+	sort.Strings(c.Modified)
+	sort.Strings(c.Deleted)
+	return c
+}
+
+func (c Changes) Empty() bool {
+	return len(c.Added)+len(c.Modified)+len(c.Deleted) == 0
+}
+
+// String 渲染变更摘要，如 "A new.go\nM main.go\nD old.go"
+func (c Changes) String() string {
+	if c.Empty() {
+		return "没有文件变更"
+	}
+	var sb strings.Builder
+	for _, p := range c.Added {
+		fmt.Fprintf(&sb, "A %s\n", p)
+	}
+	for _, p := range c.Modified {
+		fmt.Fprintf(&sb, "M %s\n", p)
+	}
+	for _, p := range c.Deleted {
+		fmt.Fprintf(&sb, "D %s\n", p)
+	}
+	fmt.Fprintf(&sb, "共 %d 个新增，%d 个修改，%d 个删除", len(c.Added), len(c.Modified), len(c.Deleted))
+	return sb.String()
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 以下类型对应 OpenAI chat completions 接口中用到的字段
+
+type Message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+}
+
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type toolDef struct {
+	Type     string      `json:"type"`
+	Function functionDef `json:"function"`
+}
+
+type functionDef struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type chatRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Tools    []toolDef `json:"tools,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message      Message `json:"message"`
+		FinishReason string  `json:"finish_reason"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// ChatClient 调用 OpenAI 兼容的 /chat/completions 接口
+type ChatClient struct {
+	BaseURL string // 如 http://localhost:8080/v1
+	APIKey  string
+	Model   string
+	HTTP    *http.Client
+}
+
+func NewChatClient(baseURL, apiKey, model string) *ChatClient {
+	return &ChatClient{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		Model:   model,
+		HTTP:    &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+func (c *ChatClient) complete(ctx context.Context, messages []Message, tools []toolDef) (Message, error) {
+	body, err := json.Marshal(chatRequest{Model: c.Model, Messages: messages, Tools: tools})
+	if err != nil {
+		return Message{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return Message{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return Message{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return Message{}, err
+	}
+	var out chatResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return Message{}, fmt.Errorf("model returned HTTP %d with invalid JSON: %.200s", resp.StatusCode, data)
+	}
+	if out.Error != nil {
+		return Message{}, fmt.Errorf("model error (HTTP %d): %s", resp.StatusCode, out.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Message{}, fmt.Errorf("model returned HTTP %d: %.200s", resp.StatusCode, data)
+	}
+	if len(out.Choices) == 0 {
+		return Message{}, fmt.Errorf("model returned no choices")
+	}
+	return out.Choices[0].Message, nil
+}
@@ -0,0 +1,332 @@
+// Package checkpoint 在工具修改文件前保存文件原来的内容，用于撤销 write_file、edit 等工具的修改。
+// 不依赖 git，工作目录不是 git 仓库时同样可用。
+package checkpoint
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/afumu/openlink/internal/security"
+	"github.com/afumu/openlink/internal/types"
+)
+
+// ErrNotFound 表示会话中没有该检查点
+var ErrNotFound = errors.New("checkpoint not found")
+
+// Checkpoint 记录一次修改前的文件状态
+type Checkpoint struct {
+	ID       string    `json:"id"`
+	Tool     string    `json:"tool"`
+	Path     string    `json:"path"`
+	Created  bool      `json:"created"` // 修改前文件不存在，恢复时删除
+	Mode     uint32    `json:"mode,omitempty"`
+	Size     int64     `json:"size"`
+	Time     time.Time `json:"time"`
+	Restored bool      `json:"restored,omitempty"`
+	// Group 是同一次工具调用（如 multi_edit）修改多个文件时共用的标识，撤销时整组一起撤销
+	Group string `json:"group,omitempty"`
+}
+
+// Change 是一次恢复操作对单个文件的处理结果
+type Change struct {
+	Checkpoint
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// Store 按会话保存检查点：<dir>/<session>.json 是检查点列表，<dir>/<session>/<id> 是修改前的文件内容
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir 返回 ~/.openlink/checkpoints/<工作区哈希>
+func DefaultDir(rootDir string) string {
+	home, _ := os.UserHomeDir()
+	abs, err := filepath.Abs(rootDir)
+	if err != nil {
+		abs = rootDir
+	}
+	sum := sha1.Sum([]byte(abs))
+	return filepath.Join(home, ".openlink", "checkpoints", hex.EncodeToString(sum[:])[:12])
+}
+
+// Save 在 tool 修改 abs 之前调用，记录文件修改前的内容；resolver 用于在允许的目录内读取文件
+func (s *Store) Save(session, tool, abs string, resolver *security.Resolver) (*Checkpoint, error) {
+	cps, err := s.SaveGroup(session, tool, []string{abs}, resolver)
+	if err != nil {
+		return nil, err
+	}
+	return &cps[0], nil
+}
+
+// SaveGroup 在 tool 一次修改多个文件之前调用，为每个文件记录一个检查点；多个文件时检查点共用一个 Group
+func (s *Store) SaveGroup(session, tool string, paths []string, resolver *security.Resolver) ([]Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list, err := s.load(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.blobDir(session), 0700); err != nil {
+		return nil, err
+	}
+	var saved []Checkpoint
+	for _, abs := range paths {
+		cp, err := s.snapshot(session, nextID(list), tool, abs, resolver)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) > 1 {
+			if len(saved) == 0 {
+				cp.Group = cp.ID
+			} else {
+				cp.Group = saved[0].Group
+			}
+		}
+		list = append(list, cp)
+		saved = append(saved, cp)
+	}
+	if err := s.save(session, list); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// snapshot 把 abs 的当前内容保存为检查点 id 的快照
+func (s *Store) snapshot(session, id, tool, abs string, resolver *security.Resolver) (Checkpoint, error) {
+	cp := Checkpoint{ID: id, Tool: tool, Path: abs, Time: time.Now()}
+	var content []byte
+	f, err := resolver.Open(abs)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		cp.Created = true
+		return cp, nil
+	case err != nil:
+		return cp, err
+	}
+	info, err := f.Stat()
+	if err == nil {
+		cp.Mode = uint32(info.Mode().Perm())
+		content, err = io.ReadAll(f)
+	}
+	f.Close()
+	if err != nil {
+		return cp, err
+	}
+	cp.Size = int64(len(content))
+	return cp, os.WriteFile(s.blobPath(session, cp.ID), content, 0600)
+}
+
+// Discard 删除尚未生效的检查点，用于工具保存检查点后修改失败并已自行恢复文件的情况
+func (s *Store) Discard(session string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list, err := s.load(session)
+	if err != nil {
+		return err
+	}
+	drop := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		drop[id] = true
+	}
+	kept := list[:0]
+	for _, cp := range list {
+		if !drop[cp.ID] {
+			kept = append(kept, cp)
+			continue
+		}
+		if err := os.Remove(s.blobPath(session, cp.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return s.save(session, kept)
+}
+
+// List 返回会话的全部检查点，最新的在前
+func (s *Store) List(session string) ([]Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list, err := s.load(session)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Checkpoint, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		out = append(out, list[i])
+	}
+	return out, nil
+}
+
+// Restore 把会话中 id 及之后的全部修改按从新到旧的顺序撤销，文件回到检查点 id 之前的状态
+func (s *Store) Restore(session, id string, resolver *security.Resolver) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list, err := s.load(session)
+	if err != nil {
+		return nil, err
+	}
+	start := -1
+	for i, cp := range list {
+		if cp.ID == id {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	// 检查点属于一组时整组一起恢复
+	if group := list[start].Group; group != "" {
+		for start > 0 && list[start-1].Group == group {
+			start--
+		}
+	}
+	return s.restore(session, list, start, resolver)
+}
+
+// Undo 撤销会话中最近 n 次尚未撤销的修改；同一组的检查点算作一次修改
+func (s *Store) Undo(session string, n int, resolver *security.Resolver) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list, err := s.load(session)
+	if err != nil {
+		return nil, err
+	}
+	start := len(list)
+	current := ""
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i].Restored {
+			continue
+		}
+		key := list[i].Group
+		if key == "" || key != current {
+			if n == 0 {
+				break
+			}
+			n--
+			current = key
+		}
+		start = i
+	}
+	if start == len(list) {
+		return nil, nil
+	}
+	return s.restore(session, list, start, resolver)
+}
+
+// restore 从最新的检查点开始逐个恢复到 list[start]，跳过已撤销的检查点。
+// 某个文件恢复失败时停止，已恢复的检查点仍被标记，保证再次撤销不会重复处理。
+func (s *Store) restore(session string, list []Checkpoint, start int, resolver *security.Resolver) ([]Change, error) {
+	var changes []Change
+	var restoreErr error
+	for i := len(list) - 1; i >= start; i-- {
+		cp := &list[i]
+		if cp.Restored {
+			continue
+		}
+		change, err := s.restoreOne(session, *cp, resolver)
+		if err != nil {
+			restoreErr = fmt.Errorf("restore checkpoint %s (%s): %w", cp.ID, resolver.Display(cp.Path), err)
+			break
+		}
+		cp.Restored = true
+		change.Restored = true
+		changes = append(changes, change)
+	}
+	if err := s.save(session, list); err != nil && restoreErr == nil {
+		restoreErr = err
+	}
+	return changes, restoreErr
+}
+
+func (s *Store) restoreOne(session string, cp Checkpoint, resolver *security.Resolver) (Change, error) {
+	change := Change{Checkpoint: cp}
+	abs, err := resolver.Resolve(cp.Path, security.Write)
+	if err != nil {
+		return change, err
+	}
+	if cp.Created {
+		err := resolver.Remove(abs)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return change, err
+		}
+		change.Deleted = true
+		return change, nil
+	}
+	content, err := os.ReadFile(s.blobPath(session, cp.ID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return change, errors.New("snapshot has been pruned")
+		}
+		return change, err
+	}
+	mode := os.FileMode(cp.Mode)
+	if mode == 0 {
+		mode = 0644
+	}
+	if err := resolver.MkdirAll(filepath.Dir(abs), 0755); err != nil {
+		return change, err
+	}
+	return change, resolver.WriteFile(abs, content, mode)
+}
+
+func nextID(list []Checkpoint) string {
+	max := 0
+	for _, cp := range list {
+		if n, err := strconv.Atoi(cp.ID); err == nil && n > max {
+			max = n
+		}
+	}
+	return strconv.Itoa(max + 1)
+}
+
+func (s *Store) indexPath(session string) string {
+	return filepath.Join(s.dir, types.NormalizeSession(session)+".json")
+}
+
+func (s *Store) blobDir(session string) string {
+	return filepath.Join(s.dir, types.NormalizeSession(session))
+}
+
+func (s *Store) blobPath(session, id string) string {
+	return filepath.Join(s.blobDir(session), id)
+}
+
+func (s *Store) load(session string) ([]Checkpoint, error) {
+	data, err := os.ReadFile(s.indexPath(session))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []Checkpoint
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("corrupt checkpoint index: %w", err)
+	}
+	return list, nil
+}
+
+func (s *Store) save(session string, list []Checkpoint) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.indexPath(session), data, 0600)
+}
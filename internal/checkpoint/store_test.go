@@ -0,0 +1,143 @@
+package checkpoint
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/afumu/openlink/internal/security"
+	"github.com/afumu/openlink/internal/types"
+)
+
+func TestStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	r := security.NewResolver(&types.Config{RootDir: root})
+	s := NewStore(t.TempDir())
+	a := filepath.Join(r.Workspace(), "a.txt")
+	b := filepath.Join(r.Workspace(), "dir", "b.txt")
+	os.WriteFile(a, []byte("v1"), 0640)
+
+	// 模拟工具：先保存检查点再修改
+	modify := func(path, content string) {
+		t.Helper()
+		if _, err := s.Save("s1", "write_file", path, r); err != nil {
+			t.Fatal(err)
+		}
+		r.MkdirAll(filepath.Dir(path), 0755)
+		if err := r.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	modify(a, "v2")
+	modify(b, "new")
+	modify(a, "v3")
+
+	list, err := s.List("s1")
+	if err != nil || len(list) != 3 || list[0].ID != "3" || !list[1].Created || list[2].Size != 2 {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+	if other, _ := s.List("s2"); len(other) != 0 {
+		t.Errorf("sessions should be separate, got %+v", other)
+	}
+
+	t.Run("undo reverts the latest change", func(t *testing.T) {
+		changes, err := s.Undo("s1", 1, r)
+		if err != nil || len(changes) != 1 || changes[0].ID != "3" {
+			t.Fatalf("changes = %+v, %v", changes, err)
+		}
+		if data, _ := os.ReadFile(a); string(data) != "v2" {
+			t.Errorf("a.txt = %q", data)
+		}
+	})
+
+	t.Run("restore rewinds to before the checkpoint and deletes created files", func(t *testing.T) {
+		changes, err := s.Restore("s1", "1", r)
+		if err != nil || len(changes) != 2 || !changes[0].Deleted || changes[1].ID != "1" {
+			t.Fatalf("changes = %+v, %v", changes, err)
+		}
+		if _, err := os.Stat(b); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("created file should be deleted, got %v", err)
+		}
+		info, _ := os.Stat(a)
+		if data, _ := os.ReadFile(a); string(data) != "v1" || info.Mode().Perm() != 0640 {
+			t.Errorf("a.txt = %q %v", data, info.Mode())
+		}
+		if changes, _ := s.Undo("s1", 5, r); len(changes) != 0 {
+			t.Errorf("everything was already undone, got %+v", changes)
+		}
+	})
+
+	t.Run("unknown checkpoint", func(t *testing.T) {
+		if _, err := s.Restore("s1", "42", r); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("pruned snapshot", func(t *testing.T) {
+		modify(a, "v4")
+		list, _ := s.List("s1")
+		os.Remove(filepath.Join(s.dir, "s1", list[0].ID))
+		if _, err := s.Undo("s1", 1, r); err == nil {
+			t.Error("expected an error for a pruned snapshot")
+		}
+	})
+}
+
+func TestStoreGroup(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	r := security.NewResolver(&types.Config{RootDir: t.TempDir()})
+	s := NewStore(t.TempDir())
+	a := filepath.Join(r.Workspace(), "a.txt")
+	b := filepath.Join(r.Workspace(), "b.txt")
+	c := filepath.Join(r.Workspace(), "c.txt")
+	os.WriteFile(a, []byte("a1"), 0644)
+	os.WriteFile(b, []byte("b1"), 0644)
+	os.WriteFile(c, []byte("c1"), 0644)
+	read := func(path string) string {
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+
+	s.Save("s1", "edit", c, r)
+	os.WriteFile(c, []byte("c2"), 0644)
+	cps, err := s.SaveGroup("s1", "multi_edit", []string{a, b}, r)
+	if err != nil || len(cps) != 2 || cps[0].Group == "" || cps[0].Group != cps[1].Group {
+		t.Fatalf("group = %+v, %v", cps, err)
+	}
+	os.WriteFile(a, []byte("a2"), 0644)
+	os.WriteFile(b, []byte("b2"), 0644)
+
+	changes, err := s.Undo("s1", 1, r)
+	if err != nil || len(changes) != 2 || read(a) != "a1" || read(b) != "b1" || read(c) != "c2" {
+		t.Fatalf("undo one group: %+v, %v, %s %s %s", changes, err, read(a), read(b), read(c))
+	}
+	if changes, err := s.Undo("s1", 1, r); err != nil || len(changes) != 1 || read(c) != "c1" {
+		t.Fatalf("undo next change: %+v, %v", changes, err)
+	}
+
+	t.Run("restoring a group member restores the whole group", func(t *testing.T) {
+		cps, _ := s.SaveGroup("s2", "multi_edit", []string{a, b}, r)
+		os.WriteFile(a, []byte("a3"), 0644)
+		os.WriteFile(b, []byte("b3"), 0644)
+		if _, err := s.Restore("s2", cps[1].ID, r); err != nil || read(a) != "a1" || read(b) != "b1" {
+			t.Fatalf("restore: %v, %s %s", err, read(a), read(b))
+		}
+	})
+
+	t.Run("discarded checkpoints are not undone", func(t *testing.T) {
+		s.Save("s3", "edit", c, r)
+		os.WriteFile(c, []byte("c4"), 0644)
+		cps, _ := s.SaveGroup("s3", "multi_edit", []string{a, b}, r)
+		if err := s.Discard("s3", []string{cps[0].ID, cps[1].ID}); err != nil {
+			t.Fatal(err)
+		}
+		if list, _ := s.List("s3"); len(list) != 1 {
+			t.Fatalf("list = %+v", list)
+		}
+		if changes, err := s.Undo("s3", 1, r); err != nil || len(changes) != 1 || changes[0].Tool != "edit" || read(c) != "c1" {
+			t.Fatalf("undo: %+v, %v", changes, err)
+		}
+	})
+}
@@ -0,0 +1,326 @@
+// Package diff 计算文件修改前后的行级差异，生成带行号的统一差异格式文本和供扩展渲染的结构化 hunk。
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+// Context 是每个 hunk 前后保留的上下文行数
+const Context = 3
+
+// maxEdits 限制 Myers 算法的编辑距离，差异过大时把中间部分整体视为删除后新增，避免占用过多内存
+const maxEdits = 2000
+
+// op 是编辑脚本中的一步：kind 为 ' '、'-' 或 '+'，a、b 为该步之前在修改前后文本中的行下标
+type op struct {
+	kind byte
+	a, b int
+}
+
+// Compute 比较 before 和 after，返回 path 的差异；各行内容相同时 Hunks 为空，换行符或末尾换行的变化记录在 Notes 中
+func Compute(path, before, after string) *types.FileDiff {
+	a, b := splitLines(before), splitLines(after)
+	ops := edits(a, b)
+	d := &types.FileDiff{Path: path}
+	for _, o := range ops {
+		switch o.kind {
+		case '-':
+			d.Removed++
+		case '+':
+			d.Added++
+		}
+	}
+	d.Hunks = hunks(ops, a, b)
+	d.Notes = notes(before, after)
+	return d
+}
+
+// notes 描述 splitLines 忽略的差异：文件末尾换行和换行符风格
+func notes(before, after string) []string {
+	var out []string
+	if before != "" && after != "" {
+		if b, a := eolStyle(before), eolStyle(after); b != a && b != "" && a != "" {
+			out = append(out, fmt.Sprintf("换行符由 %s 改为 %s", b, a))
+		}
+	}
+	endsB := before == "" || strings.HasSuffix(before, "\n")
+	endsA := after == "" || strings.HasSuffix(after, "\n")
+	switch {
+	case endsB && !endsA:
+		out = append(out, "已删除文件末尾的换行")
+	case !endsB && endsA:
+		out = append(out, "已在文件末尾添加换行")
+	}
+	return out
+}
+
+// eolStyle 返回文本使用的换行符：LF、CRLF、mixed，没有换行时为空
+func eolStyle(s string) string {
+	lf := strings.Count(s, "\n")
+	crlf := strings.Count(s, "\r\n")
+	switch {
+	case lf == 0:
+		return ""
+	case crlf == 0:
+		return "LF"
+	case crlf == lf:
+		return "CRLF"
+	}
+	return "mixed"
+}
+
+// splitLines 按行拆分文本并去掉行尾的 \r；以换行结尾时不产生最后的空行
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
+}
+
+// edits 返回把 a 变为 b 的最短编辑脚本（Myers 算法），先去掉相同的开头和结尾
+func edits(a, b []string) []op {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	var ops []op
+	for i := 0; i < pre; i++ {
+		ops = append(ops, op{' ', i, i})
+	}
+	for _, o := range myers(a[pre:len(a)-suf], b[pre:len(b)-suf]) {
+		ops = append(ops, op{o.kind, o.a + pre, o.b + pre})
+	}
+	for i := suf; i > 0; i-- {
+		ops = append(ops, op{' ', len(a) - i, len(b) - i})
+	}
+	return ops
+}
+
+func myers(a, b []string) []op {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(n, m)
+	}
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+	off := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d] 保存第 d 步开始前 k ∈ [-d, d] 的最远 x
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+	return replaceAll(n, m)
+}
+
+func backtrack(trace [][]int, n, m int) []op {
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d] }
+		k := x - y
+		var pk int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			pk = k + 1
+		} else {
+			pk = k - 1
+		}
+		px := at(pk)
+		py := px - pk
+		for x > px && y > py {
+			x--
+			y--
+			ops = append(ops, op{' ', x, y})
+		}
+		if pk == k+1 {
+			ops = append(ops, op{'+', px, py})
+		} else {
+			ops = append(ops, op{'-', px, py})
+		}
+		x, y = px, py
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, op{' ', x, y})
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// replaceAll 把 a 的 n 行全部删除后新增 b 的 m 行
+func replaceAll(n, m int) []op {
+	ops := make([]op, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, op{'-', i, 0})
+	}
+	for j := 0; j < m; j++ {
+		ops = append(ops, op{'+', n, j})
+	}
+	return ops
+}
+
+// hunks 把编辑脚本按 Context 行上下文分组，相邻改动之间的相同行不超过 2*Context 时合并为一个 hunk
+func hunks(ops []op, a, b []string) []types.DiffHunk {
+	out := []types.DiffHunk{}
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := max(i-Context, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			j := end
+			for j < len(ops) && ops[j].kind == ' ' {
+				j++
+			}
+			if j == len(ops) || j-end > 2*Context {
+				break
+			}
+			end = j
+		}
+		stop := min(end+Context, len(ops))
+		out = append(out, hunk(ops[start:stop], a, b))
+		i = stop
+	}
+	return out
+}
+
+func hunk(ops []op, a, b []string) types.DiffHunk {
+	h := types.DiffHunk{OldStart: ops[0].a + 1, NewStart: ops[0].b + 1}
+	for _, o := range ops {
+		switch o.kind {
+		case ' ':
+			h.Lines = append(h.Lines, types.DiffLine{Op: " ", Old: o.a + 1, New: o.b + 1, Text: a[o.a]})
+		case '-':
+			h.Lines = append(h.Lines, types.DiffLine{Op: "-", Old: o.a + 1, Text: a[o.a]})
+		case '+':
+			h.Lines = append(h.Lines, types.DiffLine{Op: "+", New: o.b + 1, Text: b[o.b]})
+		}
+	}
+	countLines(&h)
+	// 统一差异格式中行数为 0 时起始行号指向前一行
+	if h.OldLines == 0 {
+		h.OldStart--
+	}
+	if h.NewLines == 0 {
+		h.NewStart--
+	}
+	return h
+}
+
+// countLines 按 hunk 中的行重新计算行数
+func countLines(h *types.DiffHunk) {
+	h.OldLines, h.NewLines = 0, 0
+	for _, l := range h.Lines {
+		if l.Op != "+" {
+			h.OldLines++
+		}
+		if l.Op != "-" {
+			h.NewLines++
+		}
+	}
+}
+
+// Cap 把差异限制在 maxLines 行、maxBytes 字节以内，超出部分丢弃并标记 Truncated
+func Cap(d *types.FileDiff, maxLines, maxBytes int) {
+	lines, bytes := 0, 0
+	for i := range d.Hunks {
+		h := &d.Hunks[i]
+		for j, l := range h.Lines {
+			lines++
+			bytes += len(l.Text) + 1
+			if lines > maxLines || bytes > maxBytes {
+				d.Truncated = true
+				if j == 0 {
+					d.Hunks = d.Hunks[:i]
+					return
+				}
+				h.Lines = h.Lines[:j]
+				countLines(h)
+				d.Hunks = d.Hunks[:i+1]
+				return
+			}
+		}
+	}
+}
+
+// Unified 把差异渲染为统一差异格式，每行前加上行号（删除行为修改前的行号，其余为修改后的行号）
+func Unified(d *types.FileDiff) string {
+	var sb strings.Builder
+	if d.Created {
+		sb.WriteString("--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&sb, "--- a/%s\n", d.Path)
+	}
+	fmt.Fprintf(&sb, "+++ b/%s\n", d.Path)
+	width := 1
+	for _, h := range d.Hunks {
+		if w := len(strconv.Itoa(max(h.OldStart+h.OldLines, h.NewStart+h.NewLines))); w > width {
+			width = w
+		}
+	}
+	for _, h := range d.Hunks {
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", span(h.OldStart, h.OldLines), span(h.NewStart, h.NewLines))
+		for _, l := range h.Lines {
+			n := l.New
+			if l.Op == "-" {
+				n = l.Old
+			}
+			fmt.Fprintf(&sb, "%s%*d  %s\n", l.Op, width, n, l.Text)
+		}
+	}
+	if d.Truncated {
+		sb.WriteString("... 差异过长，已截断\n")
+	}
+	for _, n := range d.Notes {
+		fmt.Fprintf(&sb, "\\ %s\n", n)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func span(start, n int) string {
+	if n == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
@@ -0,0 +1,129 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func numbered(n int) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "line%d\n", i)
+	}
+	return sb.String()
+}
+
+func TestUnified(t *testing.T) {
+	before := numbered(10)
+	after := strings.Replace(before, "line5\n", "line5 changed\nextra\n", 1)
+	d := Compute("a.txt", before, after)
+	if d.Added != 2 || d.Removed != 1 || len(d.Hunks) != 1 {
+		t.Fatalf("got %+v", d)
+	}
+	want := `--- a/a.txt
++++ b/a.txt
+@@ -2,7 +2,8 @@
+  2  line2
+  3  line3
+  4  line4
+- 5  line5
++ 5  line5 changed
++ 6  extra
+  7  line6
+  8  line7
+  9  line8`
+	if got := Unified(d); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	t.Run("identical", func(t *testing.T) {
+		if d := Compute("a", "x\ny\n", "x\ny\n"); len(d.Hunks) != 0 || d.Hunks == nil || d.Notes != nil {
+			t.Errorf("got %+v", d)
+		}
+	})
+
+	t.Run("line ending and final newline changes are noted", func(t *testing.T) {
+		cases := []struct{ before, after, want string }{
+			{"x\ny\n", "x\r\ny\r\n", "换行符由 LF 改为 CRLF"},
+			{"x\r\ny\r\n", "x\ny\n", "换行符由 CRLF 改为 LF"},
+			{"x\ny\n", "x\ny", "已删除文件末尾的换行"},
+			{"x\ny", "x\ny\n", "已在文件末尾添加换行"},
+		}
+		for _, c := range cases {
+			d := Compute("a", c.before, c.after)
+			if len(d.Hunks) != 0 || len(d.Notes) != 1 || d.Notes[0] != c.want {
+				t.Errorf("%q -> %q: got %+v", c.before, c.after, d)
+			}
+			if !strings.HasSuffix(Unified(d), "\n\\ "+c.want) {
+				t.Errorf("unified: %q", Unified(d))
+			}
+		}
+	})
+
+	t.Run("created file", func(t *testing.T) {
+		d := Compute("new.go", "", "package a\n\nfunc A() {}\n")
+		d.Created = true
+		h := d.Hunks[0]
+		if d.Added != 3 || h.OldStart != 0 || h.OldLines != 0 || h.NewStart != 1 || h.NewLines != 3 {
+			t.Errorf("got %+v", d)
+		}
+		if !strings.HasPrefix(Unified(d), "--- /dev/null\n+++ b/new.go\n@@ -0,0 +1,3 @@\n") {
+			t.Errorf("got %q", Unified(d))
+		}
+	})
+
+	t.Run("distant changes get separate hunks", func(t *testing.T) {
+		before := numbered(30)
+		after := strings.Replace(strings.Replace(before, "line2\n", "two\n", 1), "line28\n", "", 1)
+		d := Compute("a", before, after)
+		if len(d.Hunks) != 2 || d.Hunks[1].OldStart != 25 || d.Hunks[1].OldLines != 6 || d.Hunks[1].NewLines != 5 {
+			t.Fatalf("got %+v", d.Hunks)
+		}
+	})
+
+	t.Run("nearby changes are merged", func(t *testing.T) {
+		before := numbered(20)
+		after := strings.Replace(strings.Replace(before, "line5\n", "five\n", 1), "line11\n", "eleven\n", 1)
+		if d := Compute("a", before, after); len(d.Hunks) != 1 {
+			t.Errorf("got %d hunks", len(d.Hunks))
+		}
+	})
+
+	t.Run("minimal edit script", func(t *testing.T) {
+		d := Compute("a", "a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n")
+		if d.Added+d.Removed != 5 {
+			t.Errorf("expected edit distance 5, got +%d -%d", d.Added, d.Removed)
+		}
+		// 按差异重放修改前的内容应得到修改后的内容
+		var rebuilt []string
+		for _, h := range d.Hunks {
+			for _, l := range h.Lines {
+				if l.Op != "-" {
+					rebuilt = append(rebuilt, l.Text)
+				}
+			}
+		}
+		if strings.Join(rebuilt, "\n") != "c\nb\na\nb\na\nc" {
+			t.Errorf("got %q", rebuilt)
+		}
+	})
+}
+
+func TestCap(t *testing.T) {
+	d := Compute("big", "", numbered(100))
+	Cap(d, 10, 1<<20)
+	if !d.Truncated || len(d.Hunks) != 1 || len(d.Hunks[0].Lines) != 10 || d.Hunks[0].NewLines != 10 || d.Added != 100 {
+		t.Fatalf("got %+v", d)
+	}
+	if !strings.HasSuffix(Unified(d), "差异过长，已截断") {
+		t.Errorf("missing truncation marker: %q", Unified(d))
+	}
+	d = Compute("big", "", numbered(100))
+	Cap(d, 1000, 20)
+	if !d.Truncated || len(d.Hunks[0].Lines) != 3 {
+		t.Errorf("byte cap: got %d lines", len(d.Hunks[0].Lines))
+	}
+}
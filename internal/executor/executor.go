@@ -4,25 +4,66 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
 	"strings"
-	"sync/atomic"
+	"sync"
+	"time"
 
+	"github.com/afumu/openlink/internal/checkpoint"
+	"github.com/afumu/openlink/internal/mcp"
+	"github.com/afumu/openlink/internal/prompt"
+	"github.com/afumu/openlink/internal/question"
+	"github.com/afumu/openlink/internal/redact"
+	"github.com/afumu/openlink/internal/skill"
+	"github.com/afumu/openlink/internal/todo"
 	"github.com/afumu/openlink/internal/tool"
 	"github.com/afumu/openlink/internal/types"
 )
 
 type Executor struct {
-	config    *types.Config
-	registry  *tool.Registry
-	callCount atomic.Int64
+	config      *types.Config
+	registry    *tool.Registry
+	questions   *question.Broker
+	todos       *todo.Store
+	checkpoints *checkpoint.Store
+	skills      *skill.Index
+	active      *skill.Activations
+	redactor    *redact.Redactor
+	// instructions 缓存 AGENTS.md 等项目指令文件，修改后下次渲染提示词时重新读取
+	instructions *prompt.Instructions
+
+	skillMu    sync.Mutex
+	skillTools map[string]string // 已注册的 skill 工具名 -> 定义指纹
+
+	promptMu sync.Mutex
+	prompts  map[string]*sessionPrompt // 会话 -> 提示词注入状态
+
+	mcpMu      sync.Mutex
+	mcpClients []*mcp.Client
+	mcpTools   map[string][]string // MCP server 名 -> 已注册的工具名
+	mcpReady   sync.WaitGroup      // 每个 MCP server 首次启动（握手成功或失败）后 Done
 }
 
 func New(config *types.Config) *Executor {
 	e := &Executor{
-		config:   config,
-		registry: tool.NewRegistry(),
+		config:       config,
+		registry:     tool.NewRegistry(),
+		todos:        todo.NewStore(todo.DefaultDir(config.RootDir)),
+		checkpoints:  checkpoint.NewStore(checkpoint.DefaultDir(config.RootDir)),
+		skills:       skill.NewIndex(config.RootDir),
+		active:       skill.NewActivations(),
+		instructions: prompt.NewInstructions(config.RootDir),
+		skillTools:   make(map[string]string),
+		mcpTools:     make(map[string][]string),
+		prompts:      make(map[string]*sessionPrompt),
+	}
+	redactor, err := redact.New(config.Redact, config.Token)
+	if err != nil {
+		log.Printf("[Executor] 脱敏配置无效，仅使用内置检测: %v\n", err)
+		redactor, _ = redact.New(nil, config.Token)
+	}
+	e.redactor = redactor
+	if !config.Unattended {
+		e.questions = question.NewBroker()
 	}
 	e.registry.Register(tool.NewExecCmdTool(config))
 	e.registry.Register(tool.NewListDirTool(config))
@@ -31,15 +72,106 @@ func New(config *types.Config) *Executor {
 	e.registry.Register(tool.NewGlobTool(config))
 	e.registry.Register(tool.NewGrepTool(config))
 	e.registry.Register(tool.NewEditTool(config))
+	e.registry.Register(tool.NewMultiEditTool(config))
 	e.registry.Register(tool.NewWebFetchTool())
-	e.registry.Register(tool.NewQuestionTool())
-	e.registry.Register(tool.NewSkillTool(config))
-	e.registry.Register(tool.NewTodoWriteTool(config))
+	e.registry.Register(tool.NewWebSearchTool(config))
+	e.registry.Register(tool.NewQuestionTool(e.questions))
+	e.registry.Register(tool.NewSkillTool(e.skills, e.active))
+	e.registry.Register(tool.NewTodoWriteTool(e.todos))
+	e.registry.Register(tool.NewTodoReadTool(e.todos))
+	e.registry.Register(tool.NewOutputReadTool())
+	e.registry.Register(tool.NewUndoTool(e.checkpoints))
+	e.registerPlugins()
+	e.startMCPServers()
 	return e
 }
 
+// Close 停止 MCP server 子进程
+func (e *Executor) Close() error {
+	e.mcpMu.Lock()
+	clients := e.mcpClients
+	e.mcpClients = nil
+	e.mcpMu.Unlock()
+	for _, c := range clients {
+		c.Close()
+	}
+	return nil
+}
+
+// startMCPServers 在后台启动配置的 MCP server；握手完成后其工具以 "<server>.<tool>" 挂载，
+// 进程崩溃时卸载，重启成功后重新挂载
+func (e *Executor) startMCPServers() {
+	for name, cfg := range e.config.MCPServers {
+		if cfg.Disabled {
+			continue
+		}
+		var client *mcp.Client
+		client = mcp.NewClient(name, cfg, func(_ string, defs []mcp.Tool) {
+			e.mountMCPTools(client, defs)
+		})
+		e.mcpMu.Lock()
+		e.mcpClients = append(e.mcpClients, client)
+		e.mcpMu.Unlock()
+		e.mcpReady.Add(1)
+		go func() {
+			defer e.mcpReady.Done()
+			client.Start()
+		}()
+	}
+}
+
+// WaitMCP 等待所有 MCP server 完成首次启动：握手成功时其工具已挂载，失败时转入后台重试。
+// 单次握手受超时限制，call/run/mcp 等一次性命令在列出或调用工具前调用，避免 "<server>.<tool>" 尚未注册
+func (e *Executor) WaitMCP(ctx context.Context) error {
+	ready := make(chan struct{})
+	go func() {
+		e.mcpReady.Wait()
+		close(ready)
+	}()
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Executor) mountMCPTools(client *mcp.Client, defs []mcp.Tool) {
+	e.mcpMu.Lock()
+	defer e.mcpMu.Unlock()
+	for _, name := range e.mcpTools[client.Name()] {
+		e.registry.Unregister(name)
+	}
+	var names []string
+	for _, def := range defs {
+		t := mcp.NewRemoteTool(client, def)
+		if err := e.registry.Register(t); err != nil {
+			log.Printf("[Executor] 注册 MCP 工具 %s 失败: %v\n", t.Name(), err)
+			continue
+		}
+		names = append(names, t.Name())
+	}
+	e.mcpTools[client.Name()] = names
+}
+
+// registerPlugins 注册 .openlink/tools/ 下清单声明的外部工具，与内置工具同名的插件会被忽略
+func (e *Executor) registerPlugins() {
+	specs, errs := tool.LoadPlugins(e.config.RootDir)
+	for _, err := range errs {
+		log.Printf("[Executor] 忽略插件清单: %v\n", err)
+	}
+	for _, spec := range specs {
+		if err := e.registry.Register(tool.NewProcessTool(spec)); err != nil {
+			log.Printf("[Executor] 注册插件 %s 失败: %v\n", spec.Name, err)
+			continue
+		}
+		log.Printf("[Executor] 注册插件: %s\n", spec.Name)
+	}
+}
+
 func (e *Executor) Execute(ctx context.Context, req *types.ToolRequest) *types.ToolResponse {
 	log.Printf("[Executor] 执行工具: %s\n", req.Name)
+	e.syncSkillTools()
 
 	t, exists := e.registry.Get(req.Name)
 	if !exists {
@@ -61,36 +193,171 @@ func (e *Executor) Execute(ctx context.Context, req *types.ToolRequest) *types.T
 		return &types.ToolResponse{Status: "error", Output: msg, Error: msg}
 	}
 
+	session := types.NormalizeSession(req.Session)
+	if active, ok := e.active.Active(session); ok && !active.Allows(t.Name(), req.Args) {
+		msg := fmt.Sprintf("tool %s is not allowed while skill %s is active (allowed-tools: %s); call skill with release=true to deactivate it",
+			t.Name(), active.Name, strings.Join(active.AllowedTools, ", "))
+		log.Printf("[Executor] 拒绝工具 %s: skill %s 未允许\n", t.Name(), active.Name)
+		return &types.ToolResponse{Status: "error", Output: msg, Error: msg}
+	}
+
+	// 同一次调用中截断前后各脱敏一次，命中次数合并后统一告知模型
+	found := map[string]int{}
+	redactFn := func(s string) string {
+		out, hits := e.redactor.Redact(s)
+		for kind, n := range hits {
+			found[kind] += n
+		}
+		return out
+	}
 	result := t.Execute(&tool.Context{
-		Args:   req.Args,
-		Config: e.config,
+		Ctx:         ctx,
+		Args:        req.Args,
+		Config:      e.config,
+		Session:     session,
+		Redact:      redactFn,
+		Checkpoints: e.checkpoints,
 	})
 
 	resp := &types.ToolResponse{
 		Status:     result.Status,
-		Output:     result.Output,
-		Error:      result.Error,
+		Output:     redactFn(result.Output),
+		Error:      redactFn(result.Error),
 		StopStream: result.StopStream,
+		Diff:       redactDiff(result.Diff, e.Redact),
+	}
+	for _, d := range result.Diffs {
+		resp.Diffs = append(resp.Diffs, redactDiff(d, e.Redact))
 	}
 	if result.Status == "error" && result.Output == "" {
-		resp.Output = result.Error
+		resp.Output = resp.Error
+	}
+	if len(found) > 0 {
+		log.Printf("[Executor] 工具 %s 的输出已脱敏: %s\n", t.Name(), redact.Summary(found))
+		resp.Output += redactNotice(req.Lang, found)
 	}
 
-	// Fix 4: append identity reminder; re-inject full prompt every 20 calls
-	n := e.callCount.Add(1)
-	const reinjectEvery = 20
-	const reminder = "\n\n[系统提示] 请记住你是 openlink，严格遵循工具调用规范，不要忘记自己的身份和指令。"
-	if n%reinjectEvery == 0 {
-		if data, err := os.ReadFile(filepath.Join(e.config.RootDir, "init_prompt.txt")); err == nil {
-			resp.Output += "\n\n[系统重新注入提示词]\n" + string(data)
-		}
-	} else {
-		resp.Output += reminder
+	if e.config.DisableReminder {
+		return resp
 	}
 
+	e.remind(req, resp)
 	return resp
 }
 
+// Redact 脱敏任意文本（如日志中的工具参数），不告知模型
+func (e *Executor) Redact(s string) string {
+	out, _ := e.redactor.Redact(s)
+	return out
+}
+
+// redactDiff 脱敏差异中每一行的内容，差异和输出文本一样会发往聊天界面。
+// 内置工具生成差异时已逐行脱敏并计入命中次数，这里用不计数的 Redact 兜底
+func redactDiff(d *types.FileDiff, redactFn func(string) string) *types.FileDiff {
+	if d == nil {
+		return nil
+	}
+	out := *d
+	out.Hunks = make([]types.DiffHunk, len(d.Hunks))
+	for i, h := range d.Hunks {
+		h.Lines = append([]types.DiffLine(nil), h.Lines...)
+		for j := range h.Lines {
+			h.Lines[j].Text = redactFn(h.Lines[j].Text)
+		}
+		out.Hunks[i] = h
+	}
+	return &out
+}
+
+func redactNotice(lang string, found map[string]int) string {
+	if lang == "en" {
+		return fmt.Sprintf("\n\n[openlink] Sensitive values in this output were replaced with [REDACTED:...] placeholders (%s). The placeholders are not the real values: do not write them to files or use them in edit old_string.", redact.Summary(found))
+	}
+	return fmt.Sprintf("\n\n[openlink] 输出中的敏感信息已替换为 [REDACTED:...] 占位符（%s）。占位符不是真实内容，不要把它写入文件或用作 edit 的 old_string。", redact.Summary(found))
+}
+
 func (e *Executor) ListTools() []tool.ToolInfo {
+	e.syncSkillTools()
 	return e.registry.List()
 }
+
+// syncSkillTools 让 Registry 中的 skill 工具与当前 skills 保持一致：
+// 新出现或定义变化的工具重新注册，skill 消失后其工具随之注销
+func (e *Executor) syncSkillTools() {
+	want := map[string]tool.ProcessSpec{}
+	for _, info := range skill.Usable(e.skills.List()) {
+		for _, spec := range info.Tools {
+			name := info.QualifiedName(spec)
+			want[name] = tool.ProcessSpec{
+				Name:        name,
+				Description: spec.Description,
+				Parameters:  spec.Parameters,
+				Command:     spec.Command,
+				Argv:        spec.Argv,
+				Dir:         info.Dir,
+				Timeout:     time.Duration(spec.Timeout) * time.Second,
+			}
+		}
+	}
+
+	e.skillMu.Lock()
+	defer e.skillMu.Unlock()
+	for name := range e.skillTools {
+		if _, ok := want[name]; !ok {
+			e.registry.Unregister(name)
+			delete(e.skillTools, name)
+			log.Printf("[Executor] 注销 skill 工具: %s\n", name)
+		}
+	}
+	for name, spec := range want {
+		fp := fmt.Sprintf("%+v", spec)
+		if prev, ok := e.skillTools[name]; ok {
+			if prev == fp {
+				continue
+			}
+			e.registry.Unregister(name)
+		}
+		if err := e.registry.Register(tool.NewProcessTool(spec)); err != nil {
+			log.Printf("[Executor] 注册 skill 工具 %s 失败: %v\n", name, err)
+			continue
+		}
+		e.skillTools[name] = fp
+		log.Printf("[Executor] 注册 skill 工具: %s\n", name)
+	}
+}
+
+// Skills 返回缓存的 skill 索引
+func (e *Executor) Skills() *skill.Index {
+	return e.skills
+}
+
+// Todos 返回按会话保存的待办列表
+func (e *Executor) Todos() *todo.Store {
+	return e.todos
+}
+
+// Checkpoints 返回按会话保存的文件修改检查点
+func (e *Executor) Checkpoints() *checkpoint.Store {
+	return e.checkpoints
+}
+
+// Questions 返回 question 工具挂起的提问，供 HTTP 接口回答；Config.Unattended 时为 nil
+func (e *Executor) Questions() *question.Broker {
+	return e.questions
+}
+
+// Prompt 按平台和语言选择模板并渲染初始化提示词，模板中可使用当前工具和 skills
+func (e *Executor) Prompt(platform, lang string) (string, error) {
+	opts := prompt.Options{RootDir: e.config.RootDir, Platform: platform, Lang: lang, Builtin: e.config.Prompts}
+	source, src, err := prompt.Find(opts)
+	if err != nil {
+		return "", err
+	}
+	data := prompt.NewData(opts, e.ListTools(), e.skills.List())
+	data.Instructions = e.instructions.Load()
+	text, err := prompt.Render(src, data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", source, err)
+	}
+	return text, nil
+}
@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/afumu/openlink/internal/mcp"
+	"github.com/afumu/openlink/internal/types"
+)
+
+// 测试进程以 OPENLINK_MCP_STUB=1 重新执行自身时充当一个握手较慢的 MCP server
+func TestMain(m *testing.M) {
+	if os.Getenv("OPENLINK_MCP_STUB") == "1" {
+		runStubServer()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func runStubServer() {
+	scanner := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &req) != nil || len(req.ID) == 0 {
+			continue
+		}
+		var result interface{}
+		switch req.Method {
+		case "initialize":
+			time.Sleep(300 * time.Millisecond)
+			result = map[string]interface{}{
+				"protocolVersion": mcp.ProtocolVersion,
+				"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+				"serverInfo":      map[string]string{"name": "stub", "version": "0.1"},
+			}
+		case "tools/list":
+			result = map[string]interface{}{"tools": []interface{}{
+				map[string]interface{}{"name": "ping", "inputSchema": map[string]interface{}{"type": "object"}},
+			}}
+		default:
+			result = map[string]interface{}{"content": []interface{}{map[string]string{"type": "text", "text": "pong"}}}
+		}
+		out.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}
+}
+
+func TestWaitMCP(t *testing.T) {
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig(t)
+	cfg.MCPServers = map[string]types.MCPServerConfig{
+		"stub":    {Command: self, Env: map[string]string{"OPENLINK_MCP_STUB": "1"}},
+		"missing": {Command: filepath.Join(t.TempDir(), "no-such-server")},
+	}
+	e := New(cfg)
+	defer e.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.WaitMCP(ctx); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, info := range e.ListTools() {
+		found = found || info.Name == "stub.ping"
+	}
+	if !found {
+		t.Fatal("stub.ping should be registered once WaitMCP returns")
+	}
+	resp := e.Execute(ctx, &types.ToolRequest{Name: "stub.ping", Args: map[string]interface{}{}})
+	if resp.Status != "success" {
+		t.Errorf("got %s: %s", resp.Status, resp.Output)
+	}
+}
@@ -0,0 +1,231 @@
+package executor
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+// 重新注入策略
+const (
+	ReinjectNever  = "never"
+	ReinjectCalls  = "calls"
+	ReinjectTokens = "tokens"
+)
+
+const (
+	defaultReinjectEvery = 20
+	defaultTokenBudget   = 30000
+)
+
+const (
+	reminderZh = "\n\n[系统提示] 请记住你是 openlink，严格遵循工具调用规范，不要忘记自己的身份和指令。"
+	reminderEn = "\n\n[System reminder] Remember that you are openlink. Strictly follow the tool call format and do not forget your identity and instructions."
+)
+
+// sessionPrompt 记录会话使用的平台、语言，以及自上次注入提示词以来的调用次数和估算输出 token
+type sessionPrompt struct {
+	platform string
+	lang     string
+	policy   *types.ReinjectPolicy // 会话级覆盖
+	calls    int
+	tokens   int
+	pending  bool // 已请求在下一次工具输出中重新注入
+}
+
+// ReinjectStatus 是会话当前的注入策略和计数
+type ReinjectStatus struct {
+	Session  string               `json:"session"`
+	Platform string               `json:"platform,omitempty"`
+	Lang     string               `json:"lang,omitempty"`
+	Policy   types.ReinjectPolicy `json:"policy"`
+	Calls    int                  `json:"calls"`
+	Tokens   int                  `json:"tokens"`
+	Pending  bool                 `json:"pending"`
+}
+
+// sessionLocked 返回会话状态，调用方需持有 promptMu
+func (e *Executor) sessionLocked(session string) *sessionPrompt {
+	st, ok := e.prompts[session]
+	if !ok {
+		st = &sessionPrompt{}
+		e.prompts[session] = st
+	}
+	return st
+}
+
+// policyLocked 依次合并默认值、全局配置、平台配置和会话配置
+func (e *Executor) policyLocked(st *sessionPrompt) types.ReinjectPolicy {
+	reminder := true
+	p := types.ReinjectPolicy{Mode: ReinjectCalls, Every: defaultReinjectEvery, TokenBudget: defaultTokenBudget, Reminder: &reminder}
+	if cfg := e.config.Reinject; cfg != nil {
+		p = mergePolicy(p, cfg.ReinjectPolicy)
+		if platform, ok := cfg.Platforms[st.platform]; ok && st.platform != "" {
+			p = mergePolicy(p, platform)
+		}
+	}
+	if st.policy != nil {
+		p = mergePolicy(p, *st.policy)
+	}
+	return p
+}
+
+func mergePolicy(base, over types.ReinjectPolicy) types.ReinjectPolicy {
+	if over.Mode != "" {
+		base.Mode = over.Mode
+	}
+	if over.Every > 0 {
+		base.Every = over.Every
+	}
+	if over.TokenBudget > 0 {
+		base.TokenBudget = over.TokenBudget
+	}
+	if over.Reminder != nil {
+		base.Reminder = over.Reminder
+	}
+	return base
+}
+
+// ValidatePolicy 检查策略字段取值
+func ValidatePolicy(p types.ReinjectPolicy) error {
+	switch p.Mode {
+	case "", ReinjectNever, ReinjectCalls, ReinjectTokens:
+	default:
+		return fmt.Errorf("invalid reinject mode %q: expected never, calls or tokens", p.Mode)
+	}
+	if p.Every < 0 || p.TokenBudget < 0 {
+		return fmt.Errorf("every and token_budget must not be negative")
+	}
+	return nil
+}
+
+// BindSession 记录会话的平台和语言；injected 为 true 表示刚刚发送过完整提示词，计数清零
+func (e *Executor) BindSession(session, platform, lang string, injected bool) {
+	e.promptMu.Lock()
+	defer e.promptMu.Unlock()
+	st := e.sessionLocked(types.NormalizeSession(session))
+	if platform != "" {
+		st.platform = strings.ToLower(platform)
+	}
+	if lang != "" {
+		st.lang = strings.ToLower(lang)
+	}
+	if injected {
+		st.calls, st.tokens, st.pending = 0, 0, false
+	}
+}
+
+// SetReinjectPolicy 设置会话级策略，nil 表示恢复为全局和平台配置
+func (e *Executor) SetReinjectPolicy(session string, policy *types.ReinjectPolicy) error {
+	if policy != nil {
+		if err := ValidatePolicy(*policy); err != nil {
+			return err
+		}
+	}
+	e.promptMu.Lock()
+	defer e.promptMu.Unlock()
+	e.sessionLocked(types.NormalizeSession(session)).policy = policy
+	return nil
+}
+
+// RequestReinject 渲染会话对应的提示词，并让下一次工具输出附带完整提示词
+func (e *Executor) RequestReinject(session string) (string, error) {
+	session = types.NormalizeSession(session)
+	e.promptMu.Lock()
+	st := e.sessionLocked(session)
+	platform, lang := st.platform, st.lang
+	e.promptMu.Unlock()
+
+	text, err := e.Prompt(platform, lang)
+	if err != nil {
+		return "", err
+	}
+	e.promptMu.Lock()
+	st.pending = true
+	e.promptMu.Unlock()
+	return text, nil
+}
+
+func (e *Executor) ReinjectStatus(session string) ReinjectStatus {
+	session = types.NormalizeSession(session)
+	e.promptMu.Lock()
+	defer e.promptMu.Unlock()
+	st := e.sessionLocked(session)
+	return ReinjectStatus{
+		Session:  session,
+		Platform: st.platform,
+		Lang:     st.lang,
+		Policy:   e.policyLocked(st),
+		Calls:    st.calls,
+		Tokens:   st.tokens,
+		Pending:  st.pending,
+	}
+}
+
+// remind 按会话策略在工具输出末尾追加身份提醒，或在达到阈值时重新注入 /prompt 渲染的完整提示词
+func (e *Executor) remind(req *types.ToolRequest, resp *types.ToolResponse) {
+	session := types.NormalizeSession(req.Session)
+	e.promptMu.Lock()
+	st := e.sessionLocked(session)
+	if req.Platform != "" {
+		st.platform = strings.ToLower(req.Platform)
+	}
+	if req.Lang != "" {
+		st.lang = strings.ToLower(req.Lang)
+	}
+	policy := e.policyLocked(st)
+	st.calls++
+	st.tokens += estimateTokens(resp.Output)
+	inject := st.pending
+	switch policy.Mode {
+	case ReinjectCalls:
+		inject = inject || st.calls >= policy.Every
+	case ReinjectTokens:
+		inject = inject || st.tokens >= policy.TokenBudget
+	}
+	if inject {
+		// 无论渲染是否成功都重新计数，避免模板缺失时每次调用都重试
+		st.calls, st.tokens, st.pending = 0, 0, false
+	}
+	platform, lang := st.platform, st.lang
+	e.promptMu.Unlock()
+
+	if inject {
+		text, err := e.Prompt(platform, lang)
+		if err == nil {
+			header := "\n\n[系统重新注入提示词]\n"
+			if lang == "en" {
+				header = "\n\n[System prompt re-injected]\n"
+			}
+			resp.Output += header + text
+			log.Printf("[Executor] 会话 %s 重新注入提示词 (%s)\n", session, policy.Mode)
+			return
+		}
+		log.Printf("[Executor] 会话 %s 重新注入提示词失败: %v\n", session, err)
+	}
+	if policy.Reminder == nil || *policy.Reminder {
+		if lang == "en" {
+			resp.Output += reminderEn
+		} else {
+			resp.Output += reminderZh
+		}
+	}
+}
+
+// estimateTokens 粗略估算 token 数：ASCII 约 4 字节一个 token，其他字符（如中文）约一字一个 token
+func estimateTokens(s string) int {
+	ascii, other := 0, 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+		i += size
+	}
+	return (ascii+3)/4 + other
+}
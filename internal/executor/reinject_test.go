@@ -0,0 +1,110 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+func TestReinject(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	newExecutor := func(t *testing.T, settings *types.ReinjectSettings) *Executor {
+		t.Helper()
+		cfg := testConfig(t)
+		cfg.Reinject = settings
+		os.WriteFile(filepath.Join(cfg.RootDir, "init_prompt.txt"), []byte("PROMPT platform={{.Platform}} lang={{.Lang}}"), 0644)
+		os.WriteFile(filepath.Join(cfg.RootDir, "big.txt"), []byte(strings.Repeat("x", 400)), 0644)
+		return New(cfg)
+	}
+	call := func(e *Executor, req types.ToolRequest) string {
+		if req.Name == "" {
+			req.Name = "read_file"
+			req.Args = map[string]interface{}{"path": "big.txt"}
+		}
+		return e.Execute(context.Background(), &req).Output
+	}
+	off := false
+
+	t.Run("default re-injects the rendered prompt every 20 calls", func(t *testing.T) {
+		e := newExecutor(t, nil)
+		for i := 1; i <= 20; i++ {
+			out := call(e, types.ToolRequest{Session: "s1", Platform: "gemini"})
+			injected := strings.Contains(out, "PROMPT platform=gemini lang=")
+			if injected != (i == 20) {
+				t.Fatalf("call %d: injected=%v", i, injected)
+			}
+			if !injected && !strings.Contains(out, "[系统提示]") {
+				t.Fatalf("call %d: missing reminder", i)
+			}
+		}
+		if st := e.ReinjectStatus("s1"); st.Calls != 0 || st.Platform != "gemini" {
+			t.Errorf("status = %+v", st)
+		}
+	})
+
+	t.Run("token budget and per-platform override", func(t *testing.T) {
+		e := newExecutor(t, &types.ReinjectSettings{
+			ReinjectPolicy: types.ReinjectPolicy{Mode: ReinjectNever},
+			Platforms:      map[string]types.ReinjectPolicy{"qwen": {Mode: ReinjectTokens, TokenBudget: 250}},
+		})
+		// 全局 never：不会注入
+		for i := 0; i < 3; i++ {
+			if out := call(e, types.ToolRequest{Session: "a"}); strings.Contains(out, "PROMPT") {
+				t.Fatal("never mode should not inject")
+			}
+		}
+		// qwen：每次约 100+ token，第三次超过预算
+		var injectedAt []int
+		for i := 1; i <= 6; i++ {
+			if strings.Contains(call(e, types.ToolRequest{Session: "b", Platform: "qwen", Lang: "en"}), "PROMPT platform=qwen lang=en") {
+				injectedAt = append(injectedAt, i)
+			}
+		}
+		if len(injectedAt) != 2 || injectedAt[0] != 3 || injectedAt[1] != 6 {
+			t.Errorf("injected at %v", injectedAt)
+		}
+	})
+
+	t.Run("session override and explicit request", func(t *testing.T) {
+		e := newExecutor(t, nil)
+		if err := e.SetReinjectPolicy("s", &types.ReinjectPolicy{Mode: "sometimes"}); err == nil {
+			t.Error("expected invalid mode error")
+		}
+		if err := e.SetReinjectPolicy("s", &types.ReinjectPolicy{Mode: ReinjectNever, Reminder: &off}); err != nil {
+			t.Fatal(err)
+		}
+		e.BindSession("s", "claude", "", true)
+		out := call(e, types.ToolRequest{Session: "s"})
+		if strings.Contains(out, "[系统提示]") || strings.Contains(out, "PROMPT") {
+			t.Errorf("expected no reminder, got %q", out[len(out)-40:])
+		}
+
+		text, err := e.RequestReinject("s")
+		if err != nil || text != "PROMPT platform=claude lang=zh" {
+			t.Fatalf("got %q, %v", text, err)
+		}
+		if !strings.Contains(call(e, types.ToolRequest{Session: "s"}), "PROMPT platform=claude") {
+			t.Error("pending re-injection should be delivered on the next call")
+		}
+		if strings.Contains(call(e, types.ToolRequest{Session: "s"}), "PROMPT") {
+			t.Error("re-injection should happen once")
+		}
+		// 其他会话不受影响
+		if !strings.Contains(call(e, types.ToolRequest{Session: "other"}), "[系统提示]") {
+			t.Error("other sessions keep the default reminder")
+		}
+	})
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := estimateTokens(strings.Repeat("a", 400)); got != 100 {
+		t.Errorf("got %d", got)
+	}
+	if got := estimateTokens("你好世界"); got != 4 {
+		t.Errorf("got %d", got)
+	}
+}
@@ -0,0 +1,355 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+var ErrNotRunning = errors.New("mcp server is not running")
+
+const (
+	initTimeout    = 30 * time.Second
+	minBackoff     = 500 * time.Millisecond
+	maxBackoff     = 30 * time.Second
+	stableDuration = time.Minute // 运行超过该时长后崩溃，重启退避时间重置
+)
+
+// Client 管理一个通过 stdio 连接的 MCP server 进程：完成 initialize 握手、获取工具列表，
+// 进程退出后按指数退避自动重启。每次（重新）连接成功或工具列表变化时调用 onTools。
+type Client struct {
+	name    string
+	cfg     types.MCPServerConfig
+	onTools func(name string, tools []Tool)
+
+	mu      sync.Mutex
+	conn    *conn
+	cmd     *exec.Cmd
+	pending map[string]chan *message
+	nextID  int64
+	tools   []Tool
+	running bool
+	closed  bool
+	done    chan struct{}
+	eof     chan struct{} // 当前进程的 stdout 读取结束时关闭
+}
+
+func NewClient(name string, cfg types.MCPServerConfig, onTools func(name string, tools []Tool)) *Client {
+	return &Client{name: name, cfg: cfg, onTools: onTools, done: make(chan struct{})}
+}
+
+func (c *Client) Name() string { return c.name }
+
+// Tools 返回最近一次 tools/list 的结果
+func (c *Client) Tools() []Tool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Tool(nil), c.tools...)
+}
+
+// Start 启动 server 并完成握手；之后在后台监控进程，崩溃时自动重启。
+// 首次启动失败同样会进入重试循环，返回的错误仅用于提示。
+func (c *Client) Start() error {
+	err := c.connect()
+	go c.supervise(err)
+	return err
+}
+
+// Close 停止 server 且不再重启
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	cmd := c.cmd
+	c.mu.Unlock()
+	if cmd != nil && cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+	return nil
+}
+
+// CallTool 调用远端工具
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*CallToolResult, error) {
+	var result CallToolResult
+	if err := c.request(ctx, "tools/call", callToolParams{Name: name, Arguments: args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) connect() error {
+	cmd := exec.Command(c.cfg.Command, c.cfg.Args...)
+	cmd.Dir = c.cfg.Cwd
+	cmd.Env = os.Environ()
+	for k, v := range c.cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start mcp server %s: %w", c.name, err)
+	}
+
+	cn := newConn(stdout, stdin)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		cmd.Process.Kill()
+		cmd.Wait()
+		return ErrNotRunning
+	}
+	eof := make(chan struct{})
+	c.cmd, c.conn, c.running, c.eof = cmd, cn, true, eof
+	c.pending = make(map[string]chan *message)
+	c.mu.Unlock()
+
+	go c.logStderr(stderr)
+	go func() {
+		c.readLoop(cn)
+		close(eof)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), initTimeout)
+	defer cancel()
+	if err := c.handshake(ctx); err != nil {
+		cmd.Process.Kill()
+		<-eof
+		cmd.Wait()
+		return fmt.Errorf("mcp server %s: %w", c.name, err)
+	}
+	return nil
+}
+
+func (c *Client) handshake(ctx context.Context) error {
+	var init initializeResult
+	err := c.request(ctx, "initialize", initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo:      Implementation{Name: "openlink", Version: "1.0"},
+	}, &init)
+	if err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	if err := c.notify("notifications/initialized", nil); err != nil {
+		return err
+	}
+	log.Printf("[MCP] %s 已连接: %s %s (协议 %s)", c.name, init.ServerInfo.Name, init.ServerInfo.Version, init.ProtocolVersion)
+	return c.refreshTools(ctx)
+}
+
+func (c *Client) refreshTools(ctx context.Context) error {
+	var tools []Tool
+	cursor := ""
+	for {
+		var page listToolsResult
+		if err := c.request(ctx, "tools/list", listToolsParams{Cursor: cursor}, &page); err != nil {
+			return fmt.Errorf("tools/list: %w", err)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	c.mu.Lock()
+	c.tools = tools
+	c.mu.Unlock()
+	log.Printf("[MCP] %s 提供 %d 个工具", c.name, len(tools))
+	if c.onTools != nil {
+		c.onTools(c.name, tools)
+	}
+	return nil
+}
+
+// supervise 等待进程退出并重启，直到 Close
+func (c *Client) supervise(startErr error) {
+	backoff := minBackoff
+	for {
+		started := time.Now()
+		if startErr == nil {
+			c.mu.Lock()
+			cmd, eof := c.cmd, c.eof
+			c.mu.Unlock()
+			<-eof
+			err := cmd.Wait()
+			c.markDown()
+			select {
+			case <-c.done:
+				return
+			default:
+			}
+			log.Printf("[MCP] %s 已退出（%v），%s 后重启", c.name, err, backoff)
+			if c.onTools != nil {
+				c.onTools(c.name, nil)
+			}
+		} else {
+			c.markDown()
+			log.Printf("[MCP] %s 启动失败: %v，%s 后重试", c.name, startErr, backoff)
+		}
+		if time.Since(started) > stableDuration {
+			backoff = minBackoff
+		}
+		select {
+		case <-c.done:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		startErr = c.connect()
+	}
+}
+
+// markDown 标记进程已退出，并让所有等待中的请求失败
+func (c *Client) markDown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running = false
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+func (c *Client) readLoop(cn *conn) {
+	for {
+		msg, err := cn.read()
+		if err != nil {
+			var rpcErr *RPCError
+			if errors.As(err, &rpcErr) {
+				log.Printf("[MCP] %s 输出了无法解析的消息: %v", c.name, err)
+				continue
+			}
+			return
+		}
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			c.handleServerRequest(cn, msg)
+		case msg.Method != "":
+			if msg.Method == "notifications/tools/list_changed" {
+				go func() {
+					ctx, cancel := context.WithTimeout(context.Background(), initTimeout)
+					defer cancel()
+					if err := c.refreshTools(ctx); err != nil {
+						log.Printf("[MCP] %s 刷新工具列表失败: %v", c.name, err)
+					}
+				}()
+			}
+		default:
+			c.mu.Lock()
+			ch, ok := c.pending[string(msg.ID)]
+			delete(c.pending, string(msg.ID))
+			c.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+		}
+	}
+}
+
+// handleServerRequest 响应 server 发起的请求：只支持 ping，其余返回 method not found
+func (c *Client) handleServerRequest(cn *conn, msg *message) {
+	if msg.Method == "ping" {
+		cn.write(&message{ID: msg.ID, Result: json.RawMessage(`{}`)})
+		return
+	}
+	cn.write(&message{ID: msg.ID, Error: &RPCError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}})
+}
+
+func (c *Client) request(ctx context.Context, method string, params interface{}, out interface{}) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	if !c.running || c.conn == nil {
+		c.mu.Unlock()
+		return ErrNotRunning
+	}
+	c.nextID++
+	id := strconv.FormatInt(c.nextID, 10)
+	ch := make(chan *message, 1)
+	c.pending[id] = ch
+	cn := c.conn
+	c.mu.Unlock()
+
+	if err := cn.write(&message{ID: json.RawMessage(id), Method: method, Params: raw}); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return ErrNotRunning
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if out != nil && len(resp.Result) > 0 {
+			return json.Unmarshal(resp.Result, out)
+		}
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		if method != "initialize" {
+			c.notify("notifications/cancelled", map[string]interface{}{"requestId": json.RawMessage(id), "reason": "timeout"})
+		}
+		return ctx.Err()
+	}
+}
+
+func (c *Client) notify(method string, params interface{}) error {
+	c.mu.Lock()
+	cn := c.conn
+	c.mu.Unlock()
+	if cn == nil {
+		return ErrNotRunning
+	}
+	var raw json.RawMessage
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		raw = data
+	}
+	return cn.write(&message{Method: method, Params: raw})
+}
+
+func (c *Client) logStderr(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		log.Printf("[MCP] %s: %s", c.name, scanner.Text())
+	}
+}
@@ -0,0 +1,210 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/afumu/openlink/internal/tool"
+	"github.com/afumu/openlink/internal/types"
+)
+
+// 测试进程以 OPENLINK_MCP_STUB=1 重新执行自身时充当一个最小的 MCP server
+func TestMain(m *testing.M) {
+	if os.Getenv("OPENLINK_MCP_STUB") == "1" {
+		runStubServer()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func runStubServer() {
+	scanner := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+	reply := func(id json.RawMessage, result interface{}) {
+		out.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result})
+	}
+	for scanner.Scan() {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &req) != nil || len(req.ID) == 0 {
+			continue
+		}
+		switch req.Method {
+		case "initialize":
+			reply(req.ID, map[string]interface{}{
+				"protocolVersion": ProtocolVersion,
+				"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+				"serverInfo":      map[string]string{"name": "stub", "version": "0.1"},
+			})
+		case "tools/list":
+			var p struct{ Cursor string }
+			json.Unmarshal(req.Params, &p)
+			schema := map[string]interface{}{"type": "object", "required": []string{"text"}}
+			if p.Cursor == "" {
+				reply(req.ID, map[string]interface{}{
+					"tools":      []interface{}{map[string]interface{}{"name": "echo", "description": "echo text", "inputSchema": schema}},
+					"nextCursor": "page2",
+				})
+			} else {
+				reply(req.ID, map[string]interface{}{"tools": []interface{}{
+					map[string]interface{}{"name": "fail", "inputSchema": map[string]interface{}{"type": "object"}},
+					map[string]interface{}{"name": "crash", "inputSchema": map[string]interface{}{"type": "object"}},
+					map[string]interface{}{"name": "image", "inputSchema": map[string]interface{}{"type": "object"}},
+				}})
+			}
+		case "tools/call":
+			var p struct {
+				Name      string
+				Arguments map[string]interface{}
+			}
+			json.Unmarshal(req.Params, &p)
+			switch p.Name {
+			case "echo":
+				reply(req.ID, map[string]interface{}{"content": []interface{}{
+					map[string]string{"type": "text", "text": fmt.Sprint("echo: ", p.Arguments["text"])},
+				}})
+			case "fail":
+				reply(req.ID, map[string]interface{}{"isError": true, "content": []interface{}{
+					map[string]string{"type": "text", "text": "something broke"},
+				}})
+			case "image":
+				reply(req.ID, map[string]interface{}{"content": []interface{}{
+					map[string]string{"type": "image", "data": "aGVsbG8=", "mimeType": "image/png"},
+				}})
+			case "crash":
+				os.Exit(3)
+			}
+		default:
+			out.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32601, "message": "not found"}})
+		}
+	}
+}
+
+type toolsRecorder struct {
+	mu    sync.Mutex
+	calls [][]Tool
+}
+
+func (r *toolsRecorder) record(_ string, tools []Tool) {
+	r.mu.Lock()
+	r.calls = append(r.calls, tools)
+	r.mu.Unlock()
+}
+
+func (r *toolsRecorder) count(nonEmpty bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, c := range r.calls {
+		if (len(c) > 0) == nonEmpty {
+			n++
+		}
+	}
+	return n
+}
+
+func stubConfig(t *testing.T) types.MCPServerConfig {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.MCPServerConfig{Command: exe, Env: map[string]string{"OPENLINK_MCP_STUB": "1"}}
+}
+
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestClient(t *testing.T) {
+	rec := &toolsRecorder{}
+	client := NewClient("stub", stubConfig(t), rec.record)
+	if err := client.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	tools := client.Tools()
+	if len(tools) != 4 || rec.count(true) != 1 {
+		t.Fatalf("expected 4 tools across both pages, got %+v", tools)
+	}
+	byName := map[string]*RemoteTool{}
+	for _, def := range tools {
+		rt := NewRemoteTool(client, def)
+		byName[rt.Name()] = rt
+	}
+	cfg := &types.Config{Timeout: 10}
+	call := func(name string, args map[string]interface{}) *tool.Result {
+		return byName[name].Execute(&tool.Context{Args: args, Config: cfg})
+	}
+
+	t.Run("tools are prefixed with server name", func(t *testing.T) {
+		if byName["stub.echo"] == nil {
+			t.Fatalf("got %v", byName)
+		}
+		if err := byName["stub.echo"].Validate(map[string]interface{}{}); err == nil {
+			t.Error("expected required argument error")
+		}
+	})
+
+	t.Run("text result", func(t *testing.T) {
+		res := call("stub.echo", map[string]interface{}{"text": "hi"})
+		if res.Status != "success" || res.Output != "echo: hi" {
+			t.Errorf("got %s %q %s", res.Status, res.Output, res.Error)
+		}
+	})
+
+	t.Run("isError maps to error status", func(t *testing.T) {
+		res := call("stub.fail", nil)
+		if res.Status != "error" || res.Error != "something broke" {
+			t.Errorf("got %s %q", res.Status, res.Error)
+		}
+	})
+
+	t.Run("image content is described", func(t *testing.T) {
+		res := call("stub.image", nil)
+		if res.Status != "success" || !strings.Contains(res.Output, "image/png") {
+			t.Errorf("got %s %q", res.Status, res.Output)
+		}
+	})
+
+	t.Run("restarts after crash", func(t *testing.T) {
+		res := call("stub.crash", nil)
+		if res.Status != "error" {
+			t.Errorf("expected error when server crashes, got %s", res.Status)
+		}
+		waitUntil(t, func() bool { return rec.count(false) >= 1 && rec.count(true) >= 2 })
+		res = call("stub.echo", map[string]interface{}{"text": "again"})
+		if res.Status != "success" || res.Output != "echo: again" {
+			t.Errorf("got %s %q %s", res.Status, res.Output, res.Error)
+		}
+	})
+}
+
+func TestClientStartFailure(t *testing.T) {
+	client := NewClient("missing", types.MCPServerConfig{Command: "/nonexistent/mcp-server"}, nil)
+	defer client.Close()
+	if err := client.Start(); err == nil {
+		t.Error("expected start error")
+	}
+	if _, err := client.CallTool(context.Background(), "x", nil); err != ErrNotRunning {
+		t.Errorf("expected ErrNotRunning, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/afumu/openlink/internal/tool"
+	"github.com/afumu/openlink/internal/types"
+)
+
+// Session 是通过 MCP 调用工具时使用的会话名
+const Session = "mcp"
+
+// Executor 是 Server 所需的执行器能力，由 executor.Executor 实现
+type Executor interface {
+	ListTools() []tool.ToolInfo
+	Execute(ctx context.Context, req *types.ToolRequest) *types.ToolResponse
+}
+
+// Server 通过 stdio 以 MCP 协议暴露 Executor 中的工具，调用经过与 HTTP /exec 相同的沙箱和策略
+type Server struct {
+	exec Executor
+	info Implementation
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewServer(exec Executor, version string) *Server {
+	return &Server{
+		exec:     exec,
+		info:     Implementation{Name: "openlink", Version: version},
+		inflight: make(map[string]context.CancelFunc),
+	}
+}
+
+// Serve 处理请求直到 r 关闭。tools/call 并发执行，其余请求按顺序处理。
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	cn := newConn(r, w)
+	defer s.wg.Wait()
+	for {
+		msg, err := cn.read()
+		if err != nil {
+			var rpcErr *RPCError
+			if errors.As(err, &rpcErr) {
+				cn.write(&message{ID: json.RawMessage("null"), Error: rpcErr})
+				continue
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if msg.Method == "" {
+			// 客户端对我们请求的响应；本 server 不发起请求，忽略
+			continue
+		}
+		if len(msg.ID) == 0 {
+			s.handleNotification(msg)
+			continue
+		}
+		if msg.Method == "tools/call" {
+			ctx, cancel := context.WithCancel(context.Background())
+			s.mu.Lock()
+			s.inflight[string(msg.ID)] = cancel
+			s.mu.Unlock()
+			s.wg.Add(1)
+			go func(msg *message) {
+				defer s.wg.Done()
+				defer func() {
+					s.mu.Lock()
+					delete(s.inflight, string(msg.ID))
+					s.mu.Unlock()
+					cancel()
+				}()
+				cn.write(s.respond(msg, func() (interface{}, *RPCError) { return s.callTool(ctx, msg.Params) }))
+			}(msg)
+			continue
+		}
+		cn.write(s.handle(msg))
+	}
+}
+
+func (s *Server) handle(msg *message) *message {
+	switch msg.Method {
+	case "initialize":
+		return s.respond(msg, func() (interface{}, *RPCError) { return s.initialize(msg.Params) })
+	case "ping":
+		return &message{ID: msg.ID, Result: json.RawMessage(`{}`)}
+	case "tools/list":
+		return s.respond(msg, func() (interface{}, *RPCError) { return listToolsResult{Tools: s.tools()}, nil })
+	}
+	return &message{ID: msg.ID, Error: &RPCError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}}
+}
+
+func (s *Server) respond(msg *message, fn func() (interface{}, *RPCError)) *message {
+	result, rpcErr := fn()
+	if rpcErr != nil {
+		return &message{ID: msg.ID, Error: rpcErr}
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return &message{ID: msg.ID, Error: &RPCError{Code: codeInternalError, Message: err.Error()}}
+	}
+	return &message{ID: msg.ID, Result: data}
+}
+
+func (s *Server) handleNotification(msg *message) {
+	if msg.Method != "notifications/cancelled" {
+		return
+	}
+	var p struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if json.Unmarshal(msg.Params, &p) != nil {
+		return
+	}
+	s.mu.Lock()
+	cancel := s.inflight[string(p.RequestID)]
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, *RPCError) {
+	var p initializeParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &RPCError{Code: codeInvalidParams, Message: err.Error()}
+		}
+	}
+	log.Printf("[MCP] 客户端已连接: %s %s (协议 %s)", p.ClientInfo.Name, p.ClientInfo.Version, p.ProtocolVersion)
+	return initializeResult{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]interface{}{"tools": map[string]interface{}{"listChanged": false}},
+		ServerInfo:      s.info,
+		Instructions:    "openlink sandboxed workspace tools. File paths are relative to the workspace root.",
+	}, nil
+}
+
+func (s *Server) tools() []Tool {
+	infos := s.exec.ListTools()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	tools := make([]Tool, 0, len(infos))
+	for _, info := range infos {
+		tools = append(tools, Tool{Name: info.Name, Description: info.Description, InputSchema: InputSchema(info.Parameters)})
+	}
+	return tools
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interface{}, *RPCError) {
+	var p callToolParams
+	if err := json.Unmarshal(params, &p); err != nil || p.Name == "" {
+		msg := "tool name is required"
+		if err != nil {
+			msg = err.Error()
+		}
+		return nil, &RPCError{Code: codeInvalidParams, Message: msg}
+	}
+	args := p.Arguments
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	resp := s.exec.Execute(ctx, &types.ToolRequest{Name: p.Name, Args: args, Session: Session})
+	return toCallResult(resp), nil
+}
+
+// toCallResult 把 ToolResponse 映射为 MCP 结果：输出为文本内容，错误设置 isError，
+// 被截断的输出额外附带指向完整内容文件的 resource
+func toCallResult(resp *types.ToolResponse) CallToolResult {
+	text := resp.Output
+	if resp.Status == "error" && text == "" {
+		text = resp.Error
+	}
+	result := CallToolResult{
+		Content: []Content{{Type: "text", Text: text}},
+		IsError: resp.Status == "error",
+	}
+	if path, ok := tool.StoredOutputPath(text); ok {
+		result.Content = append(result.Content, Content{
+			Type:     "resource",
+			Resource: &ResourceContent{URI: "file://" + path, MimeType: "text/plain", Text: "完整输出保存在 " + path},
+		})
+	}
+	return result
+}
+
+// paramRe 解析内置工具的参数描述，如 "string (required) - file path to read"
+var paramRe = regexp.MustCompile(`^\s*(\w+)\s*(?:\(([^)]*)\))?\s*(?:-\s*)?(.*)$`)
+
+// InputSchema 把工具的 Parameters() 转换为 JSON Schema。已经是 JSON Schema 的（插件、MCP 工具）原样返回。
+func InputSchema(params interface{}) map[string]interface{} {
+	switch p := params.(type) {
+	case map[string]interface{}:
+		if _, ok := p["type"]; ok {
+			return p
+		}
+	case map[string]string:
+		props := map[string]interface{}{}
+		var required []string
+		for name, desc := range p {
+			prop := map[string]interface{}{"description": desc}
+			if m := paramRe.FindStringSubmatch(desc); m != nil {
+				prop["type"] = jsonType(m[1])
+				prop["description"] = strings.TrimSpace(m[3])
+				if strings.HasPrefix(strings.TrimSpace(m[2]), "required") {
+					required = append(required, name)
+				}
+			}
+			props[name] = prop
+		}
+		sort.Strings(required)
+		schema := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+
+func jsonType(t string) string {
+	switch strings.ToLower(t) {
+	case "bool", "boolean":
+		return "boolean"
+	case "int", "integer":
+		return "integer"
+	case "number", "float":
+		return "number"
+	case "array", "list":
+		return "array"
+	case "object", "map":
+		return "object"
+	}
+	return "string"
+}
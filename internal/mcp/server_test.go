@@ -0,0 +1,146 @@
+package mcp_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/afumu/openlink/internal/executor"
+	"github.com/afumu/openlink/internal/mcp"
+	"github.com/afumu/openlink/internal/types"
+)
+
+type rpcClient struct {
+	t   *testing.T
+	w   io.Writer
+	r   *bufio.Reader
+	seq int
+}
+
+func (c *rpcClient) call(method string, params interface{}) map[string]interface{} {
+	c.t.Helper()
+	c.seq++
+	req, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": c.seq, "method": method, "params": params})
+	c.w.Write(append(req, '\n'))
+	line, err := c.r.ReadBytes('\n')
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		c.t.Fatal(err)
+	}
+	if resp["id"].(float64) != float64(c.seq) {
+		c.t.Fatalf("unexpected id in %s", line)
+	}
+	return resp
+}
+
+func startServer(t *testing.T, root string) *rpcClient {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	exec := executor.New(&types.Config{RootDir: root, Timeout: 10, DisableReminder: true})
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- mcp.NewServer(exec, "test").Serve(inR, outW)
+		outW.Close()
+	}()
+	t.Cleanup(func() {
+		inW.Close()
+		<-done
+		exec.Close()
+	})
+	return &rpcClient{t: t, w: inW, r: bufio.NewReader(outR)}
+}
+
+func result(t *testing.T, resp map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	res, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected result, got %v", resp)
+	}
+	return res
+}
+
+func TestServer(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "hello.txt"), []byte("hello mcp"), 0644)
+	c := startServer(t, root)
+
+	init := result(t, c.call("initialize", map[string]interface{}{
+		"protocolVersion": mcp.ProtocolVersion,
+		"clientInfo":      map[string]string{"name": "test", "version": "1"},
+	}))
+	if init["serverInfo"].(map[string]interface{})["name"] != "openlink" {
+		t.Errorf("got %v", init)
+	}
+
+	t.Run("tools/list exposes JSON schemas", func(t *testing.T) {
+		tools := result(t, c.call("tools/list", nil))["tools"].([]interface{})
+		var readFile map[string]interface{}
+		for _, item := range tools {
+			if m := item.(map[string]interface{}); m["name"] == "read_file" {
+				readFile = m
+			}
+		}
+		if readFile == nil {
+			t.Fatal("read_file not listed")
+		}
+		schema := readFile["inputSchema"].(map[string]interface{})
+		props := schema["properties"].(map[string]interface{})
+		if schema["type"] != "object" || props["path"].(map[string]interface{})["type"] != "string" || props["limit"].(map[string]interface{})["type"] != "number" {
+			t.Errorf("unexpected schema %v", schema)
+		}
+		if req, _ := schema["required"].([]interface{}); len(req) != 1 || req[0] != "path" {
+			t.Errorf("required = %v", schema["required"])
+		}
+	})
+
+	t.Run("tools/call returns text content", func(t *testing.T) {
+		res := result(t, c.call("tools/call", map[string]interface{}{"name": "read_file", "arguments": map[string]interface{}{"path": "hello.txt"}}))
+		content := res["content"].([]interface{})[0].(map[string]interface{})
+		if res["isError"] == true || content["type"] != "text" || !strings.Contains(content["text"].(string), "hello mcp") {
+			t.Errorf("got %v", res)
+		}
+		if strings.Contains(content["text"].(string), "[系统提示]") {
+			t.Error("reminder should not be appended in MCP mode")
+		}
+	})
+
+	t.Run("sandbox errors map to isError", func(t *testing.T) {
+		res := result(t, c.call("tools/call", map[string]interface{}{"name": "read_file", "arguments": map[string]interface{}{"path": "../../etc/passwd"}}))
+		if res["isError"] != true {
+			t.Errorf("expected isError, got %v", res)
+		}
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		resp := c.call("resources/list", nil)
+		if resp["error"].(map[string]interface{})["code"].(float64) != -32601 {
+			t.Errorf("got %v", resp)
+		}
+	})
+
+	t.Run("missing tool name is invalid params", func(t *testing.T) {
+		resp := c.call("tools/call", map[string]interface{}{})
+		if resp["error"].(map[string]interface{})["code"].(float64) != -32602 {
+			t.Errorf("got %v", resp)
+		}
+	})
+}
+
+func TestInputSchemaPassthrough(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"q": map[string]interface{}{"type": "string"}}}
+	if got := mcp.InputSchema(schema); got["properties"] == nil {
+		t.Errorf("got %v", got)
+	}
+	if got := mcp.InputSchema(nil); got["type"] != "object" {
+		t.Errorf("got %v", got)
+	}
+}
@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/afumu/openlink/internal/tool"
+)
+
+// RemoteTool 把 MCP server 的一个工具包装成 tool.Tool，注册名为 "<server>.<tool>"
+type RemoteTool struct {
+	client *Client
+	def    Tool
+}
+
+func NewRemoteTool(client *Client, def Tool) *RemoteTool {
+	return &RemoteTool{client: client, def: def}
+}
+
+func (t *RemoteTool) Name() string { return t.client.Name() + "." + t.def.Name }
+
+func (t *RemoteTool) Description() string {
+	if t.def.Description == "" {
+		return fmt.Sprintf("Tool %s provided by MCP server %s", t.def.Name, t.client.Name())
+	}
+	return t.def.Description
+}
+
+func (t *RemoteTool) Parameters() interface{} { return t.def.InputSchema }
+
+func (t *RemoteTool) Validate(args map[string]interface{}) error {
+	required, _ := t.def.InputSchema["required"].([]interface{})
+	for _, r := range required {
+		name := fmt.Sprint(r)
+		if v, ok := args[name]; !ok || v == nil {
+			return fmt.Errorf("%s is required", name)
+		}
+	}
+	return nil
+}
+
+func (t *RemoteTool) Execute(ctx *tool.Context) *tool.Result {
+	result := &tool.Result{StartTime: time.Now()}
+	timeout := 60 * time.Second
+	if ctx.Config != nil && ctx.Config.Timeout > 0 {
+		timeout = time.Duration(ctx.Config.Timeout) * time.Second
+	}
+	callCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	res, err := t.client.CallTool(callCtx, t.def.Name, ctx.Args)
+	result.EndTime = time.Now()
+	if err != nil {
+		result.Status = "error"
+		result.Error = fmt.Sprintf("mcp %s: %v", t.Name(), err)
+		return result
+	}
+	text := tool.TruncateFor(ctx, t.Name(), contentText(res.Content))
+	if res.IsError {
+		result.Status = "error"
+		result.Error = text
+		if result.Error == "" {
+			result.Error = "tool reported an error"
+		}
+		return result
+	}
+	result.Status = "success"
+	result.Output = text
+	if result.Output == "" {
+		result.Output = "empty"
+	}
+	return result
+}
+
+// contentText 把 MCP 内容块转换为文本：文本原样拼接，二进制内容用占位说明代替
+func contentText(content []Content) string {
+	parts := make([]string, 0, len(content))
+	for _, c := range content {
+		switch c.Type {
+		case "text":
+			parts = append(parts, c.Text)
+		case "image", "audio":
+			parts = append(parts, fmt.Sprintf("[%s: %s, %d bytes base64]", c.Type, c.MimeType, len(c.Data)))
+		case "resource":
+			if c.Resource == nil {
+				continue
+			}
+			if c.Resource.Text != "" {
+				parts = append(parts, fmt.Sprintf("[resource: %s]\n%s", c.Resource.URI, c.Resource.Text))
+			} else {
+				parts = append(parts, fmt.Sprintf("[resource: %s, %s]", c.Resource.URI, c.Resource.MimeType))
+			}
+		default:
+			parts = append(parts, fmt.Sprintf("[unsupported content type %q]", c.Type))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
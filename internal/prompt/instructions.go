@@ -0,0 +1,196 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultInstructionBudget 是注入提示词的项目指令总字节数上限
+const DefaultInstructionBudget = 32 * 1024
+
+// instructionFiles 是每个目录中查找的指令文件，同一目录内按此顺序排列
+var instructionFiles = []string{"AGENTS.md", "CLAUDE.md"}
+
+// Instruction 是一份注入提示词的项目指令文件
+type Instruction struct {
+	Path      string // 绝对路径
+	Display   string // 相对工作目录或 ~ 的展示路径
+	Content   string
+	Size      int  // 原文件字节数
+	Truncated bool // 超出预算被截断
+}
+
+type cachedFile struct {
+	modTime time.Time
+	size    int64
+	content string
+}
+
+// Instructions 查找并缓存项目指令文件，文件修改时间或大小变化后重新读取
+type Instructions struct {
+	rootDir string
+	home    string
+	budget  int
+
+	mu    sync.Mutex
+	cache map[string]cachedFile
+}
+
+func NewInstructions(rootDir string) *Instructions {
+	home, _ := os.UserHomeDir()
+	if abs, err := filepath.Abs(rootDir); err == nil {
+		rootDir = abs
+	}
+	return &Instructions{rootDir: rootDir, home: home, budget: DefaultInstructionBudget, cache: map[string]cachedFile{}}
+}
+
+// Paths 返回当前存在的指令文件，从通用到具体排列：
+// ~/.openlink 下的用户级文件，然后从 git 根目录逐级到工作目录。指向同一文件的链接只保留一次。
+func (in *Instructions) Paths() []string {
+	type source struct{ dir, rules string }
+	var sources []source
+	if in.home != "" {
+		dir := filepath.Join(in.home, ".openlink")
+		sources = append(sources, source{dir, filepath.Join(dir, "rules")})
+	}
+	for _, dir := range projectDirs(in.rootDir) {
+		sources = append(sources, source{dir, filepath.Join(dir, ".openlink", "rules")})
+	}
+
+	var paths []string
+	var seen []os.FileInfo
+	add := func(path string) {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return
+		}
+		for _, s := range seen {
+			if os.SameFile(s, info) {
+				return
+			}
+		}
+		seen = append(seen, info)
+		paths = append(paths, path)
+	}
+	for _, src := range sources {
+		for _, name := range instructionFiles {
+			add(filepath.Join(src.dir, name))
+		}
+		rules, _ := filepath.Glob(filepath.Join(src.rules, "*.md"))
+		sort.Strings(rules)
+		for _, path := range rules {
+			add(path)
+		}
+	}
+	return paths
+}
+
+// projectDirs 返回从 git 根目录到 rootDir 的目录链；不在 git 仓库中时只有 rootDir
+func projectDirs(rootDir string) []string {
+	root, err := filepath.Abs(rootDir)
+	if err != nil {
+		return []string{rootDir}
+	}
+	chain := []string{root}
+	for dir := root; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// 没有找到 git 根目录
+			return []string{root}
+		}
+		dir = parent
+		chain = append(chain, dir)
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// Load 读取全部指令文件。总大小超出预算时优先保留更具体（离工作目录更近）的文件，
+// 跨越预算的文件截断，其余省略。
+func (in *Instructions) Load() []Instruction {
+	paths := in.Paths()
+	var list []Instruction
+	for _, path := range paths {
+		content, err := in.read(path)
+		if err != nil || strings.TrimSpace(content) == "" {
+			continue
+		}
+		list = append(list, Instruction{Path: path, Display: in.display(path), Content: strings.TrimRight(content, " \t\r\n"), Size: len(content)})
+	}
+
+	remaining := in.budget
+	kept := make([]bool, len(list))
+	for i := len(list) - 1; i >= 0 && remaining > 0; i-- {
+		kept[i] = true
+		if len(list[i].Content) > remaining {
+			list[i].Content = truncateUTF8(list[i].Content, remaining)
+			list[i].Truncated = true
+		}
+		remaining -= len(list[i].Content)
+	}
+	var out []Instruction
+	for i, item := range list {
+		if kept[i] {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func (in *Instructions) read(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if c, ok := in.cache[path]; ok && c.size == info.Size() && c.modTime.Equal(info.ModTime()) {
+		return c.content, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	in.cache[path] = cachedFile{modTime: info.ModTime(), size: info.Size(), content: string(data)}
+	return string(data), nil
+}
+
+func (in *Instructions) display(path string) string {
+	if rel, err := filepath.Rel(in.rootDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	if in.home != "" {
+		if rel, err := filepath.Rel(in.home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return "~/" + filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
+
+// truncateUTF8 截断到不超过 n 字节，尽量停在换行处，且不截断多字节字符
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	for i := 0; i < utf8.UTFMax && len(s) > 0; i++ {
+		if r, size := utf8.DecodeLastRuneInString(s); r != utf8.RuneError || size > 1 {
+			break
+		}
+		s = s[:len(s)-1]
+	}
+	if i := strings.LastIndexByte(s, '\n'); i > len(s)/2 {
+		s = s[:i+1]
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstructions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	repo := t.TempDir()
+	root := filepath.Join(repo, "services", "api")
+	os.MkdirAll(filepath.Join(repo, ".git"), 0755)
+	os.MkdirAll(filepath.Join(root, ".openlink", "rules"), 0755)
+	os.MkdirAll(filepath.Join(home, ".openlink", "rules"), 0755)
+
+	write := func(path, content string) {
+		t.Helper()
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(home, ".openlink", "AGENTS.md"), "user rules\n")
+	write(filepath.Join(home, ".openlink", "rules", "style.md"), "user style")
+	write(filepath.Join(repo, "AGENTS.md"), "repo rules")
+	write(filepath.Join(repo, "services", "CLAUDE.md"), "services rules")
+	write(filepath.Join(root, "AGENTS.md"), "api rules")
+	write(filepath.Join(root, ".openlink", "rules", "b.md"), "rule b")
+	write(filepath.Join(root, ".openlink", "rules", "a.md"), "rule a")
+	write(filepath.Join(root, ".openlink", "rules", "notes.txt"), "ignored")
+	os.Symlink(filepath.Join(root, "AGENTS.md"), filepath.Join(root, "CLAUDE.md"))
+	// git 根目录之外的文件不应被读取
+	write(filepath.Join(filepath.Dir(repo), "AGENTS.md"), "outside")
+
+	in := NewInstructions(root)
+	var got []string
+	for _, item := range in.Load() {
+		got = append(got, item.Display+"="+item.Content)
+	}
+	want := []string{
+		"~/.openlink/AGENTS.md=user rules",
+		"~/.openlink/rules/style.md=user style",
+		filepath.Join(repo, "AGENTS.md") + "=repo rules",
+		filepath.Join(repo, "services", "CLAUDE.md") + "=services rules",
+		"AGENTS.md=api rules",
+		".openlink/rules/a.md=rule a",
+		".openlink/rules/b.md=rule b",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	t.Run("re-read after change", func(t *testing.T) {
+		path := filepath.Join(root, "AGENTS.md")
+		write(path, "api rules v2")
+		os.Chtimes(path, time.Now().Add(time.Second), time.Now().Add(time.Second))
+		for _, item := range in.Load() {
+			if item.Display == "AGENTS.md" && item.Content != "api rules v2" {
+				t.Errorf("got %q", item.Content)
+			}
+		}
+	})
+
+	t.Run("budget keeps the most specific files", func(t *testing.T) {
+		in := NewInstructions(root)
+		in.budget = len("rule a") + len("rule b") + 3
+		items := in.Load()
+		if len(items) != 3 || items[0].Display != "AGENTS.md" || !items[0].Truncated || items[0].Content != "api" {
+			t.Fatalf("got %+v", items)
+		}
+		if items[0].Size != len("api rules v2") {
+			t.Errorf("size = %d", items[0].Size)
+		}
+	})
+}
+
+func TestProjectDirsWithoutGit(t *testing.T) {
+	root := t.TempDir()
+	if dirs := projectDirs(root); len(dirs) != 1 || dirs[0] != root {
+		t.Errorf("got %v", dirs)
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	if got := truncateUTF8("你好世界", 7); got != "你好" {
+		t.Errorf("got %q", got)
+	}
+	if got := truncateUTF8("line one\nline two\nline three", 20); got != "line one\nline two\n" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,254 @@
+// Package prompt 按平台和语言选择初始化提示词模板，并用 text/template 渲染工具、skills、工作区等变量。
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/afumu/openlink/internal/mcp"
+	"github.com/afumu/openlink/internal/skill"
+	"github.com/afumu/openlink/internal/tool"
+)
+
+// DefaultLang 是未指定 lang 时使用的语言
+const DefaultLang = "zh"
+
+var (
+	ErrNotFound = errors.New("init_prompt.txt not found")
+	ErrInvalid  = errors.New("invalid prompt option")
+)
+
+// nameRe 限制 platform/lang 只能是简单标识符，避免拼接出任意文件路径
+var nameRe = regexp.MustCompile(`^[a-z0-9-]*$`)
+
+// describedTools 是默认模板中已有详细中文说明和示例的工具，其余工具（插件、MCP、skill 工具等）由模板循环生成
+var describedTools = map[string]bool{
+	"exec_cmd": true, "list_dir": true, "read_file": true, "write_file": true, "glob": true, "grep": true,
+	"edit": true, "multi_edit": true, "web_fetch": true, "web_search": true, "question": true, "skill": true,
+	"todo_write": true, "todo_read": true,
+}
+
+// Param 是模板中可用的工具参数
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// Tool 是模板中可用的工具信息
+type Tool struct {
+	Name        string
+	Description string
+	Params      []Param
+	Described   bool // 默认模板已单独说明
+}
+
+// Data 是渲染模板时的变量
+type Data struct {
+	Platform     string
+	Lang         string
+	Workspace    string // 工作目录名
+	WorkspaceDir string
+	OS           string
+	Hostname     string
+	Date         string
+	Time         string
+	SystemInfo   string
+	Tools        []Tool
+	Skills       []skill.Info
+	Instructions []Instruction // AGENTS.md、CLAUDE.md、.openlink/rules 等指令文件，从通用到具体
+}
+
+// Options 描述一次渲染请求
+type Options struct {
+	RootDir  string
+	Platform string
+	Lang     string
+	Builtin  fs.FS // 内置模板，可为 nil
+}
+
+// Dirs 返回模板查找目录，按优先级从高到低。工作目录本身只用于兼容旧的 init_prompt.txt 覆盖方式。
+func Dirs(rootDir string) []string {
+	dirs := []string{
+		rootDir,
+		filepath.Join(rootDir, ".openlink", "prompts"),
+		filepath.Join(rootDir, "prompts"),
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".openlink", "prompts"))
+	}
+	return dirs
+}
+
+// Candidates 返回按优先级排列的模板文件名：
+// init_prompt.<platform>.<lang>.txt → init_prompt.<platform>.txt → init_prompt.<lang>.txt → init_prompt.txt
+func Candidates(platform, lang string) []string {
+	var names []string
+	if platform != "" {
+		names = append(names, "init_prompt."+platform+"."+lang+".txt", "init_prompt."+platform+".txt")
+	}
+	return append(names, "init_prompt."+lang+".txt", "init_prompt.txt")
+}
+
+// Find 按目录优先、文件名其次的顺序查找模板，返回来源路径和内容
+func Find(opts Options) (string, string, error) {
+	platform, lang, err := normalize(opts.Platform, opts.Lang)
+	if err != nil {
+		return "", "", err
+	}
+	names := Candidates(platform, lang)
+	for _, dir := range Dirs(opts.RootDir) {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if data, err := os.ReadFile(path); err == nil {
+				return path, string(data), nil
+			}
+		}
+	}
+	if opts.Builtin != nil {
+		for _, name := range names {
+			if data, err := fs.ReadFile(opts.Builtin, name); err == nil {
+				return "builtin:" + name, string(data), nil
+			}
+		}
+	}
+	return "", "", ErrNotFound
+}
+
+func normalize(platform, lang string) (string, string, error) {
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		lang = DefaultLang
+	}
+	if !nameRe.MatchString(platform) {
+		return "", "", fmt.Errorf("%w: platform %q", ErrInvalid, platform)
+	}
+	if !nameRe.MatchString(lang) {
+		return "", "", fmt.Errorf("%w: lang %q", ErrInvalid, lang)
+	}
+	return platform, lang, nil
+}
+
+// NewData 组装模板变量
+func NewData(opts Options, tools []tool.ToolInfo, skills []skill.Info) Data {
+	platform, lang, _ := normalize(opts.Platform, opts.Lang)
+	hostname, _ := os.Hostname()
+	now := time.Now()
+	d := Data{
+		Platform:     platform,
+		Lang:         lang,
+		Workspace:    filepath.Base(opts.RootDir),
+		WorkspaceDir: opts.RootDir,
+		OS:           runtime.GOOS + "/" + runtime.GOARCH,
+		Hostname:     hostname,
+		Date:         now.Format("2006-01-02"),
+		Time:         now.Format("2006-01-02 15:04:05"),
+		Skills:       skill.Usable(skills),
+	}
+	d.SystemInfo = fmt.Sprintf("- 操作系统: %s\n- 工作目录: %s\n- 主机名: %s\n- 当前时间: %s", d.OS, d.WorkspaceDir, d.Hostname, d.Time)
+	if lang == "en" {
+		d.SystemInfo = fmt.Sprintf("- OS: %s\n- Workspace: %s\n- Hostname: %s\n- Current time: %s", d.OS, d.WorkspaceDir, d.Hostname, d.Time)
+	}
+
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	for _, info := range tools {
+		d.Tools = append(d.Tools, Tool{
+			Name:        info.Name,
+			Description: info.Description,
+			Params:      params(info.Parameters),
+			Described:   describedTools[info.Name],
+		})
+	}
+	return d
+}
+
+// params 借助 JSON Schema 统一内置工具的描述字符串和插件/MCP 工具的 schema，必需参数在前
+func params(raw interface{}) []Param {
+	schema := mcp.InputSchema(raw)
+	props, _ := schema["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	switch req := schema["required"].(type) {
+	case []string:
+		for _, name := range req {
+			required[name] = true
+		}
+	case []interface{}:
+		for _, name := range req {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+	var out []Param
+	for name, v := range props {
+		p := Param{Name: name, Required: required[name]}
+		if prop, ok := v.(map[string]interface{}); ok {
+			p.Type, _ = prop["type"].(string)
+			p.Description, _ = prop["description"].(string)
+		}
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Required != out[j].Required {
+			return out[i].Required
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+}
+
+// Render 渲染模板。旧版模板中的 {{SYSTEM_INFO}} 仍然可用；不含任何模板动作的纯文本模板
+// 按旧行为在末尾追加 skills 列表和初始化回复。
+func Render(src string, data Data) (string, error) {
+	legacy := !strings.Contains(strings.ReplaceAll(src, "{{SYSTEM_INFO}}", ""), "{{")
+	src = strings.ReplaceAll(src, "{{SYSTEM_INFO}}", "{{.SystemInfo}}")
+	tmpl, err := template.New("prompt").Funcs(funcs).Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("parse prompt template: %w", err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("render prompt template: %w", err)
+	}
+	if legacy {
+		sb.WriteString(legacySuffix(data))
+	}
+	return sb.String(), nil
+}
+
+func legacySuffix(data Data) string {
+	var sb strings.Builder
+	if len(data.Instructions) > 0 {
+		sb.WriteString("\n\n## 项目指令\n")
+		for _, in := range data.Instructions {
+			sb.WriteString(fmt.Sprintf("\n### %s\n\n%s\n", in.Display, in.Content))
+		}
+	}
+	if len(data.Skills) > 0 {
+		sb.WriteString("\n\n## 当前可用 Skills\n\n")
+		for _, sk := range data.Skills {
+			sb.WriteString(fmt.Sprintf("- **%s**: %s\n", sk.Name, sk.Description))
+			if sk.WhenToUse != "" {
+				sb.WriteString(fmt.Sprintf("  - 使用时机: %s\n", sk.WhenToUse))
+			}
+		}
+	}
+	sb.WriteString("\n\n初始化回复：\n你好，我是 openlink，请问有什么可以帮你？")
+	return sb.String()
+}
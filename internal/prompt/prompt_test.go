@@ -0,0 +1,134 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/afumu/openlink/internal/skill"
+	"github.com/afumu/openlink/internal/tool"
+	"github.com/afumu/openlink/prompts"
+)
+
+func TestFind(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	builtin := fstest.MapFS{
+		"init_prompt.txt":    {Data: []byte("builtin zh")},
+		"init_prompt.en.txt": {Data: []byte("builtin en")},
+	}
+	find := func(platform, lang string) string {
+		t.Helper()
+		_, src, err := Find(Options{RootDir: root, Platform: platform, Lang: lang, Builtin: builtin})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return src
+	}
+
+	if got := find("gemini", ""); got != "builtin zh" {
+		t.Errorf("got %q", got)
+	}
+	if got := find("gemini", "en"); got != "builtin en" {
+		t.Errorf("got %q", got)
+	}
+
+	dir := filepath.Join(root, ".openlink", "prompts")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "init_prompt.gemini.txt"), []byte("project gemini"), 0644)
+	if got := find("Gemini", ""); got != "project gemini" {
+		t.Errorf("platform template should win, got %q", got)
+	}
+	if got := find("qwen", ""); got != "builtin zh" {
+		t.Errorf("other platforms fall back, got %q", got)
+	}
+
+	// 工作目录下的 init_prompt.txt 覆盖所有平台
+	os.WriteFile(filepath.Join(root, "init_prompt.txt"), []byte("legacy"), 0644)
+	if got := find("gemini", "en"); got != "legacy" {
+		t.Errorf("got %q", got)
+	}
+
+	if _, _, err := Find(Options{RootDir: root, Platform: "../etc"}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+	if _, _, err := Find(Options{RootDir: t.TempDir()}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tools := []tool.ToolInfo{
+		{Name: "read_file", Description: "Read file contents", Parameters: map[string]string{
+			"path":  "string (required) - file path to read",
+			"limit": "number (optional) - max lines",
+		}},
+		{Name: "deploy.run", Description: "Deploy", Parameters: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"env": map[string]interface{}{"type": "string"}},
+			"required":   []interface{}{"env"},
+		}},
+	}
+	skills := []skill.Info{{Name: "deploy", Description: "ship it"}}
+	data := NewData(Options{RootDir: "/work/demo", Platform: "claude", Lang: "en"}, tools, skills)
+
+	src := `{{.Workspace}} {{.Platform}} {{.Lang}}
+{{range .Tools}}{{.Name}}({{range .Params}}{{.Name}}:{{.Type}}{{if .Required}}!{{end}} {{end}}) described={{.Described}}
+{{end}}{{range .Skills}}skill {{.Name}}{{end}}`
+	got, err := Render(src, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "demo claude en\ndeploy.run(env:string! ) described=false\nread_file(path:string! limit:number ) described=true\nskill deploy"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	t.Run("legacy template keeps SYSTEM_INFO and suffix", func(t *testing.T) {
+		got, err := Render("env:\n{{SYSTEM_INFO}}", data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(got, "Workspace: /work/demo") || !strings.Contains(got, "**deploy**: ship it") || !strings.Contains(got, "初始化回复") {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("parse errors are reported", func(t *testing.T) {
+		if _, err := Render("{{range .Tools}}", data); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tools := []tool.ToolInfo{
+		{Name: "read_file", Description: "Read file contents", Parameters: map[string]string{"path": "string (required) - file path"}},
+		{Name: "hello", Description: "plugin tool", Parameters: map[string]interface{}{"type": "object"}},
+	}
+	for _, lang := range []string{"zh", "en"} {
+		for _, platform := range []string{"", "gemini", "qwen", "chatgpt", "claude", "deepseek"} {
+			opts := Options{RootDir: t.TempDir(), Platform: platform, Lang: lang, Builtin: prompts.Templates}
+			_, src, err := Find(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data := NewData(opts, tools, []skill.Info{{Name: "deploy", Description: "ship it"}})
+			data.Instructions = []Instruction{{Display: "AGENTS.md", Content: "run make lint"}}
+			got, err := Render(src, data)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", platform, lang, err)
+			}
+			if !strings.Contains(got, "### hello") || !strings.Contains(got, "**deploy**") || !strings.Contains(got, "### AGENTS.md\n\nrun make lint") || !strings.Contains(got, "openlink") {
+				t.Errorf("%s/%s: missing tools or skills:\n%s", platform, lang, got)
+			}
+			if wantCodeBlock := platform != "" && platform != "claude"; wantCodeBlock != strings.Contains(got, "```xml") {
+				t.Errorf("%s/%s: unexpected code block instruction", platform, lang)
+			}
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package question
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	TypeText    = "text"
+	TypeSingle  = "single"
+	TypeMulti   = "multi"
+	TypeConfirm = "confirm"
+)
+
+var (
+	ErrNotFound = errors.New("question not found")
+	ErrTimeout  = errors.New("question timed out")
+)
+
+// Question 是一个等待用户回答的提问，通过 GET /questions 暴露给扩展或 CLI
+type Question struct {
+	ID        string    `json:"id"`
+	Question  string    `json:"question"`
+	Type      string    `json:"type"`
+	Options   []string  `json:"options,omitempty"`
+	Default   []string  `json:"default,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Answer 是规范化后的回答：text/single/confirm 只有一个值，multi 可有多个
+type Answer struct {
+	Values    []string `json:"values"`
+	Defaulted bool     `json:"defaulted,omitempty"`
+}
+
+func (a Answer) String() string {
+	return strings.Join(a.Values, ", ")
+}
+
+type pending struct {
+	q  Question
+	ch chan Answer
+}
+
+// Broker 挂起 question 工具调用，直到用户通过 HTTP 接口回答或超时
+type Broker struct {
+	mu      sync.Mutex
+	pending map[string]*pending
+}
+
+func NewBroker() *Broker {
+	return &Broker{pending: make(map[string]*pending)}
+}
+
+// Ask 登记问题并阻塞等待回答。超时后若有默认答案则返回默认答案，否则返回 ErrTimeout。
+func (b *Broker) Ask(ctx context.Context, q Question, timeout time.Duration) (Answer, error) {
+	if q.Type == "" {
+		q.Type = TypeText
+		if len(q.Options) > 0 {
+			q.Type = TypeSingle
+		}
+	}
+	if err := checkQuestion(q); err != nil {
+		return Answer{}, err
+	}
+	if len(q.Default) > 0 {
+		values, err := normalize(q, q.Default)
+		if err != nil {
+			return Answer{}, fmt.Errorf("invalid default answer: %w", err)
+		}
+		q.Default = values
+	}
+
+	q.ID = newID()
+	q.CreatedAt = time.Now()
+	q.ExpiresAt = q.CreatedAt.Add(timeout)
+	p := &pending{q: q, ch: make(chan Answer, 1)}
+
+	b.mu.Lock()
+	b.pending[q.ID] = p
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.pending, q.ID)
+		b.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case ans := <-p.ch:
+		return ans, nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	if len(q.Default) > 0 {
+		return Answer{Values: q.Default, Defaulted: true}, nil
+	}
+	if ctx.Err() != nil {
+		return Answer{}, ctx.Err()
+	}
+	return Answer{}, ErrTimeout
+}
+
+// Pending 返回所有等待回答的问题，按创建时间排序
+func (b *Broker) Pending() []Question {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	list := make([]Question, 0, len(b.pending))
+	for _, p := range b.pending {
+		list = append(list, p.q)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// Answer 校验并投递回答。value 可以是字符串、字符串数组或布尔值（confirm）。
+func (b *Broker) Answer(id string, value interface{}) (Answer, error) {
+	b.mu.Lock()
+	p, ok := b.pending[id]
+	if !ok {
+		b.mu.Unlock()
+		return Answer{}, ErrNotFound
+	}
+	values, err := normalize(p.q, toStrings(value))
+	if err != nil {
+		b.mu.Unlock()
+		return Answer{}, err
+	}
+	delete(b.pending, id)
+	b.mu.Unlock()
+
+	ans := Answer{Values: values}
+	p.ch <- ans
+	return ans, nil
+}
+
+func checkQuestion(q Question) error {
+	switch q.Type {
+	case TypeText, TypeConfirm:
+	case TypeSingle, TypeMulti:
+		if len(q.Options) == 0 {
+			return fmt.Errorf("question type %q requires options", q.Type)
+		}
+	default:
+		return fmt.Errorf("unknown question type: %q", q.Type)
+	}
+	return nil
+}
+
+func normalize(q Question, values []string) ([]string, error) {
+	switch q.Type {
+	case TypeText:
+		if len(values) != 1 {
+			return nil, errors.New("text answer requires exactly one value")
+		}
+		return values, nil
+	case TypeConfirm:
+		if len(values) != 1 {
+			return nil, errors.New("confirm answer requires exactly one value")
+		}
+		switch strings.ToLower(strings.TrimSpace(values[0])) {
+		case "yes", "y", "true", "是", "确认":
+			return []string{"yes"}, nil
+		case "no", "n", "false", "否", "取消":
+			return []string{"no"}, nil
+		}
+		return nil, fmt.Errorf("confirm answer must be yes or no, got %q", values[0])
+	case TypeSingle:
+		if len(values) != 1 {
+			return nil, errors.New("single-choice answer requires exactly one option")
+		}
+		opt, err := matchOption(q.Options, values[0])
+		if err != nil {
+			return nil, err
+		}
+		return []string{opt}, nil
+	case TypeMulti:
+		if len(values) == 0 {
+			return nil, errors.New("multi-choice answer requires at least one option")
+		}
+		out := make([]string, 0, len(values))
+		seen := map[string]bool{}
+		for _, v := range values {
+			opt, err := matchOption(q.Options, v)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[opt] {
+				seen[opt] = true
+				out = append(out, opt)
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unknown question type: %q", q.Type)
+}
+
+// matchOption 接受选项原文或 1-based 序号
+func matchOption(options []string, v string) (string, error) {
+	v = strings.TrimSpace(v)
+	for _, opt := range options {
+		if opt == v {
+			return opt, nil
+		}
+	}
+	if n, err := strconv.Atoi(v); err == nil && n >= 1 && n <= len(options) {
+		return options[n-1], nil
+	}
+	return "", fmt.Errorf("%q is not one of the options", v)
+}
+
+func toStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case bool:
+		if v {
+			return []string{"yes"}
+		}
+		return []string{"no"}
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			out = append(out, fmt.Sprint(item))
+		}
+		return out
+	}
+	return []string{fmt.Sprint(value)}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,117 @@
+package question
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitPending polls until a question is parked in the broker.
+func waitPending(t *testing.T, b *Broker) Question {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if list := b.Pending(); len(list) > 0 {
+			return list[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("question never became pending")
+	return Question{}
+}
+
+func ask(b *Broker, q Question, timeout time.Duration) (chan Answer, chan error) {
+	ansCh, errCh := make(chan Answer, 1), make(chan error, 1)
+	go func() {
+		ans, err := b.Ask(context.Background(), q, timeout)
+		ansCh <- ans
+		errCh <- err
+	}()
+	return ansCh, errCh
+}
+
+func TestBrokerAnswerTypes(t *testing.T) {
+	cases := []struct {
+		name  string
+		q     Question
+		value interface{}
+		want  string
+	}{
+		{"free text", Question{Question: "name?"}, "openlink", "openlink"},
+		{"single by option", Question{Question: "pick", Options: []string{"A", "B"}}, "B", "B"},
+		{"single by index", Question{Question: "pick", Type: TypeSingle, Options: []string{"A", "B"}}, "1", "A"},
+		{"multi", Question{Question: "pick", Type: TypeMulti, Options: []string{"A", "B", "C"}}, []interface{}{"C", "A", "C"}, "C, A"},
+		{"confirm bool", Question{Question: "ok?", Type: TypeConfirm}, true, "yes"},
+		{"confirm text", Question{Question: "ok?", Type: TypeConfirm}, "否", "no"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewBroker()
+			ansCh, errCh := ask(b, tc.q, time.Second)
+			q := waitPending(t, b)
+			if _, err := b.Answer(q.ID, tc.value); err != nil {
+				t.Fatal(err)
+			}
+			if err := <-errCh; err != nil {
+				t.Fatal(err)
+			}
+			if got := (<-ansCh).String(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+			if len(b.Pending()) != 0 {
+				t.Error("answered question should no longer be pending")
+			}
+		})
+	}
+}
+
+func TestBrokerInvalidAnswerKeepsPending(t *testing.T) {
+	b := NewBroker()
+	ansCh, _ := ask(b, Question{Question: "pick", Options: []string{"A", "B"}}, time.Second)
+	q := waitPending(t, b)
+	if _, err := b.Answer(q.ID, "Z"); err == nil {
+		t.Fatal("expected error for unknown option")
+	}
+	if _, err := b.Answer(q.ID, "A"); err != nil {
+		t.Fatalf("retry should succeed: %v", err)
+	}
+	if got := (<-ansCh).String(); got != "A" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestBrokerTimeout(t *testing.T) {
+	t.Run("default answer used", func(t *testing.T) {
+		b := NewBroker()
+		ans, err := b.Ask(context.Background(), Question{Question: "ok?", Type: TypeConfirm, Default: []string{"y"}}, 20*time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ans.Defaulted || ans.String() != "yes" {
+			t.Errorf("got %+v", ans)
+		}
+	})
+
+	t.Run("no default returns ErrTimeout", func(t *testing.T) {
+		b := NewBroker()
+		if _, err := b.Ask(context.Background(), Question{Question: "?"}, 20*time.Millisecond); err != ErrTimeout {
+			t.Errorf("expected ErrTimeout, got %v", err)
+		}
+		if len(b.Pending()) != 0 {
+			t.Error("timed out question should be removed")
+		}
+	})
+}
+
+func TestBrokerErrors(t *testing.T) {
+	b := NewBroker()
+	if _, err := b.Answer("missing", "x"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := b.Ask(context.Background(), Question{Question: "?", Type: TypeMulti}, time.Second); err == nil {
+		t.Error("expected error for multi question without options")
+	}
+	if _, err := b.Ask(context.Background(), Question{Question: "?", Options: []string{"A"}, Default: []string{"B"}}, time.Second); err == nil {
+		t.Error("expected error for default outside options")
+	}
+}
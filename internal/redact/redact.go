@@ -0,0 +1,254 @@
+// Package redact 在工具输出离开本机（发往网页聊天界面、写入截断输出文件和日志）之前，
+// 把其中的密钥、令牌、私钥等敏感信息替换为占位符。
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+// TokenKind 是 openlink 自身 token 的检测名，不能通过配置关闭
+const TokenKind = "openlink-token"
+
+// detector 用正则匹配敏感信息；正则含分组时只替换最后一个匹配到的分组（值），其前一个分组视为配置名，
+// 不含分组时替换整个匹配。
+// check 不为 nil 时，返回 false 的候选值不脱敏，用于排除代码中的变量引用等误报。
+type detector struct {
+	kind  string
+	re    *regexp.Regexp
+	check func(key, value string) bool
+}
+
+// secretKey 匹配常见的敏感配置名
+const secretKey = `[A-Za-z0-9_.-]*(?i:secret|token|passw(?:or)?d|pwd|api[_-]?key|access[_-]?key|private[_-]?key|credentials?|auth)[A-Za-z0-9_.-]*`
+
+var builtin = []detector{
+	{kind: "private-key", re: regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----.*?(?:-----END [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----|\z)`)},
+	{kind: "aws-access-key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "github-token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{kind: "gitlab-token", re: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{kind: "slack-token", re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{kind: "api-key", re: regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}\b`)},
+	{kind: "stripe-key", re: regexp.MustCompile(`\b[sr]k_(?:live|test)_[0-9A-Za-z]{16,}\b`)},
+	{kind: "google-api-key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{kind: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{kind: "azure-key", re: regexp.MustCompile(`(?i)\b(?:AccountKey|SharedAccessKey)=([A-Za-z0-9+/=]{20,})`)},
+	{kind: "bearer-token", re: regexp.MustCompile(`(?i)\bauthorization\s*[:=]\s*["']?(?:bearer|basic|token)\s+([A-Za-z0-9._~+/=-]{12,})`)},
+	{kind: "url-password", re: regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:([^/\s@]{3,})@`), check: func(_, v string) bool {
+		return !strings.HasPrefix(v, "$") && !strings.HasPrefix(v, "{")
+	}},
+	// KEY=value / key: value / "key": "value"；值为带引号的字面量，或位于行首的未加引号的值（.env、ini、yaml）
+	{kind: "secret", re: regexp.MustCompile(`(?m)(?:^[ \t]*(?:export[ \t]+)?["']?(` + secretKey + `)["']?[ \t]*[:=][ \t]*["']?([^\s"'#]{8,})|(` + secretKey + `)["']?[ \t]*(?::=|[:=])[ \t]*["']([^"'\s]{8,})["'])`), check: looksSecret},
+	{kind: "high-entropy", re: regexp.MustCompile(`(?m)\b([A-Za-z_][A-Za-z0-9_.-]*)["']?[ \t]*[:=][ \t]*["']?([A-Za-z0-9+/_-]{20,}={0,2})`), check: highEntropy},
+}
+
+// Kinds 返回全部内置检测名
+func Kinds() []string {
+	kinds := make([]string, 0, len(builtin))
+	for _, d := range builtin {
+		kinds = append(kinds, d.kind)
+	}
+	return kinds
+}
+
+// Redactor 按内置检测和自定义正则脱敏文本。同一个值在同一进程内总是得到相同的占位符，
+// 模型可据此判断两处被隐藏的值是否相同。
+type Redactor struct {
+	detectors []detector
+	literals  []string // 必须脱敏的固定值（openlink token）
+	key       []byte
+}
+
+// New 创建 Redactor；token 为 openlink 的认证 token，无论配置如何都会被脱敏
+func New(settings *types.RedactSettings, token string) (*Redactor, error) {
+	r := &Redactor{key: make([]byte, 32)}
+	rand.Read(r.key)
+	if token != "" {
+		r.literals = append(r.literals, token)
+	}
+	skip := map[string]bool{}
+	if settings != nil {
+		for _, kind := range settings.Skip {
+			skip[kind] = true
+		}
+	}
+	for _, d := range builtin {
+		if !skip[d.kind] && !skip["*"] {
+			r.detectors = append(r.detectors, d)
+		}
+	}
+	if settings != nil {
+		for _, p := range settings.Patterns {
+			re, err := regexp.Compile(p.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redact pattern %q: %w", p.Name, err)
+			}
+			kind := p.Name
+			if kind == "" {
+				kind = "custom"
+			}
+			r.detectors = append(r.detectors, detector{kind: kind, re: re})
+		}
+	}
+	return r, nil
+}
+
+// Redact 返回脱敏后的文本和各检测名命中的次数
+func (r *Redactor) Redact(s string) (string, map[string]int) {
+	found := map[string]int{}
+	if r == nil || s == "" {
+		return s, found
+	}
+	for _, lit := range r.literals {
+		if n := strings.Count(s, lit); n > 0 {
+			s = strings.ReplaceAll(s, lit, r.placeholder(TokenKind, lit))
+			found[TokenKind] += n
+		}
+	}
+	for _, d := range r.detectors {
+		s = r.apply(d, s, found)
+	}
+	return s, found
+}
+
+func (r *Redactor) apply(d detector, s string, found map[string]int) string {
+	matches := d.re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end, key := m[0], m[1], ""
+		var groups [][2]int
+		for g := 1; g < len(m)/2; g++ {
+			if m[2*g] >= 0 {
+				groups = append(groups, [2]int{m[2*g], m[2*g+1]})
+			}
+		}
+		if n := len(groups); n > 0 {
+			start, end = groups[n-1][0], groups[n-1][1]
+			if n > 1 {
+				key = s[groups[n-2][0]:groups[n-2][1]]
+			}
+		}
+		value := s[start:end]
+		if start < last || strings.HasPrefix(value, "[REDACTED:") || (d.check != nil && !d.check(key, value)) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(r.placeholder(d.kind, value))
+		last = end
+		found[d.kind]++
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// placeholder 由进程内随机密钥对原值做 HMAC，不同的值得到不同的占位符，且无法由占位符反推原值
+func (r *Redactor) placeholder(kind, value string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(value))
+	return fmt.Sprintf("[REDACTED:%s:%s]", kind, hex.EncodeToString(mac.Sum(nil)[:4]))
+}
+
+// Summary 把命中次数格式化为 "github-token×1, secret×2"
+func Summary(found map[string]int) string {
+	kinds := make([]string, 0, len(found))
+	for kind := range found {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%s×%d", kind, found[kind]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+var placeholderValues = map[string]bool{
+	"changeme": true, "password": true, "xxxxxxxx": true, "********": true, "redacted": true,
+	"your_api_key": true, "your-api-key": true, "undefined": true,
+}
+
+// looksSecret 排除代码中的变量引用、函数调用和明显的示例值：值需要含数字，或足够杂乱
+func looksSecret(_, value string) bool {
+	v := strings.ToLower(value)
+	if placeholderValues[v] || strings.ContainsAny(value, "$(){}[]<>%") || strings.HasPrefix(v, "process.env") {
+		return false
+	}
+	if strings.ContainsAny(value, "0123456789") {
+		return true
+	}
+	return entropy(value) >= 3.5
+}
+
+// entropyKeys 是值通常为哈希或校验和的配置名，不视为密钥
+var entropyKeys = regexp.MustCompile(`(?i)^(h1|integrity|checksum|hash|digest|sha\d*|etag|commit|revision|rev|uuid|id|version)$`)
+
+// highEntropy 判断任意配置名的值是否像随机生成的密钥：同时含大小写字母和数字、不是纯十六进制（哈希）或路径、字符熵足够高
+func highEntropy(key, value string) bool {
+	if entropyKeys.MatchString(key) {
+		return false
+	}
+	// 路径（如 /tmp/Build123/out）不视为密钥
+	if looksLikePath(value) {
+		return false
+	}
+	v := strings.TrimRight(value, "=")
+	var lower, upper, digit bool
+	hexOnly := true
+	for _, c := range v {
+		switch {
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			hexOnly = false
+		}
+	}
+	return lower && upper && digit && !hexOnly && entropy(v) >= 4.0
+}
+
+// pathRe 匹配以 /、./、../ 或 ~/ 开头、由至少三段路径字符组成的路径。
+// base64 中同样可能出现 /，因此只有一个 / 或含有 +、= 的值不视为路径
+var pathRe = regexp.MustCompile(`^(?:~|\.{1,2})?/(?:[A-Za-z0-9._-]+/){2,}[A-Za-z0-9._-]*$`)
+
+func looksLikePath(value string) bool {
+	return !strings.ContainsAny(value, "+=") && pathRe.MatchString(value)
+}
+
+// entropy 返回字符串每个字符的香农熵（比特）
+func entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	n := 0
+	for _, c := range s {
+		counts[c]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -26,9 +26,9 @@ func LoadOrCreateToken() (string, error) {
 	}
 
 	path := filepath.Join(dir, "settings.json")
+	var settings types.Settings
 	data, err := os.ReadFile(path)
 	if err == nil {
-		var settings types.Settings
 		if err := json.Unmarshal(data, &settings); err == nil && settings.Token != "" {
 			return settings.Token, nil
 		}
@@ -39,10 +39,9 @@ func LoadOrCreateToken() (string, error) {
 		return "", fmt.Errorf("failed to generate token: %w", err)
 	}
 	token := hex.EncodeToString(b)
-	settings := types.Settings{
-		Token:     token,
-		CreatedAt: time.Now().Format(time.RFC3339),
-	}
+	// 保留 settings.json 中的其他配置项，只补写 token
+	settings.Token = token
+	settings.CreatedAt = time.Now().Format(time.RFC3339)
 
 	data, err = json.MarshalIndent(settings, "", "  ")
 	if err != nil {
@@ -0,0 +1,304 @@
+package security
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile 是工作目录下的忽略规则文件名，语法与 .gitignore 相同
+const IgnoreFile = ".openlinkignore"
+
+// ErrIgnored 表示路径被内置敏感路径列表或 .openlinkignore 隐藏
+var ErrIgnored = errors.New("path is hidden from tools")
+
+// builtinIgnore 是默认隐藏的敏感路径，.openlinkignore 中可用 ! 规则重新放开。
+// 除工作目录外，也按相对用户主目录的路径检查 read_file 可访问的 ~/.openlink 等目录。
+var builtinIgnore = []string{
+	".git/",
+	".env",
+	".env.*",
+	"!.env.example",
+	"!.env.sample",
+	"!.env.template",
+	"*.pem",
+	"*.key",
+	"*.p12",
+	"*.pfx",
+	"*.jks",
+	"*.keystore",
+	"*.ppk",
+	"id_rsa",
+	"id_dsa",
+	"id_ecdsa",
+	"id_ed25519",
+	".ssh/",
+	".aws/",
+	".gnupg/",
+	".kube/config",
+	".docker/config.json",
+	".netrc",
+	".npmrc",
+	".pypirc",
+	".git-credentials",
+	"/.openlink/settings.json",
+	"/.claude/.credentials.json",
+}
+
+// homeSecrets 是用户主目录下保存 OpenLink token 和 Claude 凭据的文件，只按相对主目录的路径匹配，
+// 不受 .openlinkignore 的 ! 规则影响：工作目录中的规则文件不能放开主目录下的凭据
+var homeSecrets = []string{
+	".openlink/settings.json",
+	".claude/.credentials.json",
+}
+
+// protectedDirs 是工作目录和用户主目录下定义 skill、skill 工具和插件工具的目录。其中的文件会被注册为
+// 直接执行命令的工具，不经过 exec_cmd 的危险命令检查，因此工具只能读取，只有用户可以修改
+var protectedDirs = []string{
+	".skills",
+	".openlink/skills",
+	".agent/skills",
+	".claude/skills",
+	".openlink/tools",
+}
+
+type ignoreRule struct {
+	pattern string
+	source  string // 规则来源，用于错误提示
+	negate  bool
+	locked  bool // homeSecrets 中的规则，不能被放开
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// Ignore 判断路径是否对工具隐藏：先应用内置敏感路径列表，再应用工作目录的 .openlinkignore，
+// 与 .gitignore 一样后出现的规则优先，目录被隐藏时其下所有内容都被隐藏。
+type Ignore struct {
+	root  string
+	home  string
+	rules []ignoreRule
+}
+
+// LoadIgnore 读取 rootDir 下的 .openlinkignore；文件不存在时只使用内置列表
+func LoadIgnore(rootDir string) *Ignore {
+	ig := &Ignore{root: resolveDir(rootDir)}
+	if home, err := os.UserHomeDir(); err == nil {
+		ig.home = resolveDir(home)
+	}
+	for _, p := range builtinIgnore {
+		ig.add(p, "built-in")
+	}
+	if f, err := os.Open(filepath.Join(ig.root, IgnoreFile)); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			ig.add(scanner.Text(), IgnoreFile)
+		}
+	}
+	return ig
+}
+
+func resolveDir(dir string) string {
+	if abs, err := filepath.EvalSymlinks(dir); err == nil {
+		return abs
+	}
+	abs, _ := filepath.Abs(dir)
+	return abs
+}
+
+func (ig *Ignore) add(line, source string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	rule := ignoreRule{pattern: line, source: source}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:] // \# 或 \! 开头表示字面量
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	// 含有中间或开头的 / 时相对工作目录匹配，否则匹配任意层级的文件名
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return
+	}
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = `(?:.*/)?` + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return
+	}
+	rule.re = re
+	ig.rules = append(ig.rules, rule)
+}
+
+// globToRegexp 把 gitignore 的通配符转换为正则：* 和 ? 不跨越 /，** 匹配任意层级
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString(`(?:.*/)?`)
+			i += 2
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(`.*`)
+			i++
+		case c == '*':
+			b.WriteString(`[^/]*`)
+		case c == '?':
+			b.WriteString(`[^/]`)
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// relatives 返回路径相对工作目录和用户主目录的 / 分隔路径。工作目录是主目录的上级（如 -dir /home）时
+// 两者都存在，规则需要分别匹配，否则 /.openlink/settings.json 这类锚定到主目录的规则会失效
+func (ig *Ignore) relatives(absPath string) []string {
+	var rels []string
+	for _, base := range []string{ig.root, ig.home} {
+		if base == "" {
+			continue
+		}
+		rel, err := filepath.Rel(base, absPath)
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			rels = append(rels, filepath.ToSlash(rel))
+		}
+	}
+	return rels
+}
+
+// Match 判断绝对路径是否被隐藏，返回命中的规则；相对工作目录或主目录的路径任一被隐藏即隐藏
+func (ig *Ignore) Match(absPath string, isDir bool) (*ignoreRule, bool) {
+	if rule := ig.secret(absPath); rule != nil {
+		return rule, true
+	}
+	for _, rel := range ig.relatives(absPath) {
+		if rule, ok := ig.match(rel, isDir); ok {
+			return rule, true
+		}
+	}
+	return nil, false
+}
+
+// secret 在其他规则之前检查 homeSecrets，命中时返回不可放开的内置规则
+func (ig *Ignore) secret(absPath string) *ignoreRule {
+	if ig.home == "" {
+		return nil
+	}
+	rel, err := filepath.Rel(ig.home, absPath)
+	if err != nil {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+	for _, p := range homeSecrets {
+		if rel == p {
+			return &ignoreRule{pattern: "~/" + p, source: "built-in", locked: true}
+		}
+	}
+	return nil
+}
+
+func (ig *Ignore) match(rel string, isDir bool) (*ignoreRule, bool) {
+	// 依次检查每一级父目录，父目录被隐藏时子路径一律隐藏
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		dir := i < len(parts)-1 || isDir
+		if rule := ig.last(strings.Join(parts[:i+1], "/"), dir); rule != nil && !rule.negate {
+			return rule, true
+		}
+	}
+	return nil, false
+}
+
+// last 返回最后一条匹配 rel 的规则
+func (ig *Ignore) last(rel string, isDir bool) *ignoreRule {
+	for i := len(ig.rules) - 1; i >= 0; i-- {
+		r := &ig.rules[i]
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(rel) {
+			return r
+		}
+	}
+	return nil
+}
+
+// Hidden 判断路径是否应从列表和搜索结果中省略
+func (ig *Ignore) Hidden(absPath string, isDir bool) bool {
+	_, hidden := ig.Match(absPath, isDir)
+	return hidden
+}
+
+// Check 在访问路径前调用，被隐藏时返回说明原因的错误
+func (ig *Ignore) Check(absPath string) error {
+	isDir := false
+	if info, err := os.Stat(absPath); err == nil {
+		isDir = info.IsDir()
+	}
+	rule, hidden := ig.Match(absPath, isDir)
+	if !hidden {
+		return nil
+	}
+	display := absPath
+	if rels := ig.relatives(absPath); len(rels) > 0 {
+		display = rels[0]
+	}
+	if rule.locked {
+		return fmt.Errorf("%w: %s holds credentials and is never exposed to tools", ErrIgnored, rule.pattern)
+	}
+	if rule.source == "built-in" {
+		return fmt.Errorf("%w: %s matches the built-in sensitive path rule %q; the user can add \"!%s\" to %s to allow it",
+			ErrIgnored, display, rule.pattern, strings.TrimPrefix(rule.pattern, "/"), IgnoreFile)
+	}
+	return fmt.Errorf("%w: %s matches rule %q in %s", ErrIgnored, display, rule.pattern, IgnoreFile)
+}
+
+// CheckWrite 在修改路径前调用：除 Check 外，还禁止工具修改 .openlinkignore 本身和 protectedDirs 中的目录
+func (ig *Ignore) CheckWrite(absPath string) error {
+	if err := ig.Check(absPath); err != nil {
+		return err
+	}
+	if absPath == filepath.Join(ig.root, IgnoreFile) {
+		return fmt.Errorf("%w: %s can only be edited by the user", ErrIgnored, IgnoreFile)
+	}
+	for _, rel := range ig.relatives(absPath) {
+		for _, dir := range protectedDirs {
+			if rel == dir || strings.HasPrefix(rel, dir+"/") {
+				return fmt.Errorf("%w: %s defines skills or tools that run commands and can only be changed by the user", ErrIgnored, rel)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package security
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnore(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, IgnoreFile), []byte("# comment\nsecrets/\n/build/*.log\n**/fixtures/*.json\n!.env.local\n!keep.key\n"), 0644)
+	os.MkdirAll(filepath.Join(root, "docs"), 0755)
+	os.MkdirAll(filepath.Join(root, "secrets"), 0755)
+	ig := LoadIgnore(root)
+
+	cases := []struct {
+		path   string
+		isDir  bool
+		hidden bool
+	}{
+		{".env", false, true},
+		{"sub/.env.production", false, true},
+		{".env.example", false, false},
+		{".env.local", false, false}, // .openlinkignore 中重新放开
+		{".git/config", false, true},
+		{".git", true, true},
+		{".gitignore", false, false},
+		{"deploy/id_rsa", false, true},
+		{"certs/server.pem", false, true},
+		{"keep.key", false, false},
+		{"secrets", true, true},
+		{"secrets/a.txt", false, true},
+		{"docs/secrets", false, false}, // secrets/ 只匹配目录
+		{"build/out.log", false, true},
+		{"sub/build/out.log", false, false}, // 以 / 开头的规则只匹配工作目录下的 build
+		{"a/b/fixtures/x.json", false, true},
+		{"main.go", false, false},
+	}
+	for _, tc := range cases {
+		if got := ig.Hidden(filepath.Join(root, tc.path), tc.isDir); got != tc.hidden {
+			t.Errorf("Hidden(%s) = %v, want %v", tc.path, got, tc.hidden)
+		}
+	}
+
+	settings := filepath.Join(home, ".openlink", "settings.json")
+	if err := ig.Check(settings); !errors.Is(err, ErrIgnored) {
+		t.Errorf("expected ~/.openlink/settings.json to be refused, got %v", err)
+	}
+	if err := ig.Check(filepath.Join(home, ".openlink", "skills", "x", "SKILL.md")); err != nil {
+		t.Errorf("other files under ~/.openlink should be readable: %v", err)
+	}
+	if err := ig.Check(filepath.Join(root, ".env")); err == nil || !strings.Contains(err.Error(), `"!.env"`) {
+		t.Errorf("expected built-in rule explanation, got %v", err)
+	}
+	if err := ig.Check(filepath.Join(root, "secrets")); err == nil || !strings.Contains(err.Error(), IgnoreFile) {
+		t.Errorf("expected .openlinkignore rule explanation, got %v", err)
+	}
+	if err := ig.Check(filepath.Join(root, IgnoreFile)); err != nil {
+		t.Errorf(".openlinkignore should be readable: %v", err)
+	}
+	if err := ig.CheckWrite(filepath.Join(root, IgnoreFile)); !errors.Is(err, ErrIgnored) {
+		t.Errorf(".openlinkignore must not be writable by tools, got %v", err)
+	}
+}
+
+func TestIgnoreRootAboveHome(t *testing.T) {
+	root := t.TempDir()
+	home := filepath.Join(root, "u")
+	os.MkdirAll(home, 0755)
+	t.Setenv("HOME", home)
+	ig := LoadIgnore(root)
+
+	for _, p := range []string{
+		filepath.Join(home, ".openlink", "settings.json"),
+		filepath.Join(home, ".claude", ".credentials.json"),
+	} {
+		if err := ig.Check(p); !errors.Is(err, ErrIgnored) {
+			t.Errorf("expected %s to be refused with the workspace above home, got %v", p, err)
+		}
+	}
+	if err := ig.Check(filepath.Join(home, ".openlink", "skills", "x", "SKILL.md")); err != nil {
+		t.Errorf("other files under ~/.openlink should be readable: %v", err)
+	}
+	if !ig.Hidden(filepath.Join(home, "project", ".env"), false) {
+		t.Error("expected .env under home to be hidden")
+	}
+}
+
+func TestHomeSecretsCannotBeNegated(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	// 工作目录就是主目录，或在主目录之上时，.openlinkignore 中的 ! 规则都不能放开主目录下的凭据
+	for _, root := range []string{home, filepath.Dir(home)} {
+		rules := "!/.openlink/settings.json\n!/.claude/.credentials.json\n!**/.openlink/settings.json\n!**/.credentials.json\n"
+		os.WriteFile(filepath.Join(root, IgnoreFile), []byte(rules), 0644)
+		ig := LoadIgnore(root)
+		for _, p := range []string{
+			filepath.Join(home, ".openlink", "settings.json"),
+			filepath.Join(home, ".claude", ".credentials.json"),
+		} {
+			err := ig.Check(p)
+			if !errors.Is(err, ErrIgnored) {
+				t.Errorf("root %s: expected %s to stay hidden, got %v", root, p, err)
+			} else if strings.Contains(err.Error(), "!") {
+				t.Errorf("error should not suggest a negation rule: %v", err)
+			}
+		}
+	}
+}
+
+func TestCheckWriteProtectedDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := t.TempDir()
+	ig := LoadIgnore(root)
+
+	for _, p := range []string{
+		filepath.Join(root, ".skills"),
+		filepath.Join(root, ".skills", "x", "tools.json"),
+		filepath.Join(root, ".claude", "skills", "x", "SKILL.md"),
+		filepath.Join(root, ".openlink", "tools", "deploy.json"),
+		filepath.Join(home, ".openlink", "skills", "x", "SKILL.md"),
+	} {
+		if err := ig.CheckWrite(p); !errors.Is(err, ErrIgnored) {
+			t.Errorf("expected writes to %s to be refused, got %v", p, err)
+		}
+		if err := ig.Check(p); err != nil {
+			t.Errorf("%s should stay readable: %v", p, err)
+		}
+	}
+	for _, p := range []string{
+		filepath.Join(root, ".skillsrc"),
+		filepath.Join(root, "sub", ".skills", "x", "tools.json"),
+		filepath.Join(root, ".openlink", "rules", "a.md"),
+	} {
+		if err := ig.CheckWrite(p); err != nil {
+			t.Errorf("expected %s to be writable, got %v", p, err)
+		}
+	}
+}
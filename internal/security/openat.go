@@ -0,0 +1,211 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// errEscape 表示路径在打开时（包括解析符号链接后）离开了根目录
+var errEscape = errors.New("path escapes root directory")
+
+// maxSymlinks 是解析一个路径时最多跟随的符号链接数，与 Linux 内核的限制一致
+const maxSymlinks = 40
+
+// splitPath 把相对路径拆分为组件，忽略空组件和 .
+func splitPath(rel string) []string {
+	var parts []string
+	for _, p := range strings.Split(filepath.ToSlash(rel), "/") {
+		if p != "" && p != "." {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// resolveLinks 逐级解析绝对路径中的符号链接。与 EvalSymlinks 不同，不存在的组件原样保留，
+// 指向不存在目标的符号链接（悬空链接）也会被解析到目标，新建文件时同样能发现父目录或文件本身是指向别处的链接。
+func resolveLinks(path string) (string, error) {
+	vol := filepath.VolumeName(path)
+	base := vol + string(filepath.Separator)
+	parts := splitPath(path[len(vol):])
+	var resolved []string
+	links := 0
+	for len(parts) > 0 {
+		name := parts[0]
+		parts = parts[1:]
+		if name == ".." {
+			if len(resolved) > 0 {
+				resolved = resolved[:len(resolved)-1]
+			}
+			continue
+		}
+		cur := filepath.Join(base, filepath.Join(append(resolved, name)...))
+		info, err := os.Lstat(cur)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, syscall.ENOTDIR) {
+				return "", err
+			}
+			// 不存在的组件之后不可能再有符号链接
+			resolved = append(resolved, name)
+			resolved = append(resolved, parts...)
+			break
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = append(resolved, name)
+			continue
+		}
+		if links++; links > maxSymlinks {
+			return "", &os.PathError{Op: "resolve", Path: path, Err: syscall.ELOOP}
+		}
+		target, err := os.Readlink(cur)
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			vol = filepath.VolumeName(target)
+			base = vol + string(filepath.Separator)
+			resolved = nil
+			target = target[len(vol):]
+		}
+		parts = append(splitPath(target), parts...)
+	}
+	return filepath.Join(base, filepath.Join(resolved...)), nil
+}
+
+// Open 以只读方式打开 Resolve 返回的路径
+func (r *Resolver) Open(abs string) (*os.File, error) {
+	return r.OpenFile(abs, os.O_RDONLY, 0)
+}
+
+// OpenFile 在 abs 所在的根目录内打开文件。Resolve 检查路径和真正打开文件之间，路径中的组件可能被并发替换为
+// 指向根目录外的符号链接（例如同时运行的 exec_cmd），因此打开时基于根目录的 fd 重新解析：Linux 上使用
+// openat2(RESOLVE_BENEATH|RESOLVE_NO_MAGICLINKS)，其他系统逐级 openat 并检查每个符号链接。
+func (r *Resolver) OpenFile(abs string, flag int, perm os.FileMode) (*os.File, error) {
+	root, rel, err := r.beneath(abs)
+	if err != nil {
+		return nil, err
+	}
+	f, err := openBeneath(root, rel, flag, perm)
+	if errors.Is(err, errEscape) {
+		return nil, fmt.Errorf("%w: %s resolves outside %s", ErrOutsideRoots, r.Display(abs), r.Display(root))
+	}
+	return f, err
+}
+
+// ReadFile 读取 Resolve 返回的文件
+func (r *Resolver) ReadFile(abs string) ([]byte, error) {
+	f, err := r.Open(abs)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// WriteFile 写入 Resolve 返回的文件，文件不存在时创建
+func (r *Resolver) WriteFile(abs string, data []byte, perm os.FileMode) error {
+	f, err := r.OpenFile(abs, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Remove 删除 Resolve 返回的文件
+func (r *Resolver) Remove(abs string) error {
+	root, rel, err := r.beneath(abs)
+	if err != nil {
+		return err
+	}
+	err = removeBeneath(root, rel)
+	if errors.Is(err, errEscape) {
+		return fmt.Errorf("%w: %s resolves outside %s", ErrOutsideRoots, r.Display(abs), r.Display(root))
+	}
+	return err
+}
+
+// Rename 把 oldAbs 重命名为 newAbs，两者必须在同一个根目录内；目标已存在时被原子替换
+func (r *Resolver) Rename(oldAbs, newAbs string) error {
+	root, oldRel, err := r.beneath(oldAbs)
+	if err != nil {
+		return err
+	}
+	newRoot, newRel, err := r.beneath(newAbs)
+	if err != nil {
+		return err
+	}
+	if newRoot != root {
+		return fmt.Errorf("%w: %s and %s are in different directories", ErrOutsideRoots, r.Display(oldAbs), r.Display(newAbs))
+	}
+	err = renameBeneath(root, oldRel, newRel)
+	if errors.Is(err, errEscape) {
+		return fmt.Errorf("%w: %s resolves outside %s", ErrOutsideRoots, r.Display(newAbs), r.Display(root))
+	}
+	return err
+}
+
+// ReadDir 列出 Resolve 返回的目录
+func (r *Resolver) ReadDir(abs string) ([]os.DirEntry, error) {
+	f, err := r.Open(abs)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.ReadDir(-1)
+}
+
+// MkdirAll 在 abs 所在的根目录内逐级创建目录，每一级都基于根目录的 fd 创建
+func (r *Resolver) MkdirAll(abs string, perm os.FileMode) error {
+	root, rel, err := r.beneath(abs)
+	if err != nil {
+		return err
+	}
+	parts := splitPath(rel)
+	for i := range parts {
+		dir := filepath.Join(parts[:i+1]...)
+		err := mkdirBeneath(root, dir, perm)
+		if err == nil || errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if errors.Is(err, errEscape) {
+			return fmt.Errorf("%w: %s resolves outside %s", ErrOutsideRoots, r.Display(abs), r.Display(root))
+		}
+		return err
+	}
+	// 已存在的组件可能是文件或指向根目录外的链接，最后确认整条路径是根目录内的目录
+	f, err := r.Open(abs)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &os.PathError{Op: "mkdir", Path: r.Display(abs), Err: syscall.ENOTDIR}
+	}
+	return nil
+}
+
+// beneath 返回包含 abs 的根目录和 abs 相对它的路径
+func (r *Resolver) beneath(abs string) (string, string, error) {
+	root, ok := r.rootOf(abs)
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s is not inside the workspace or an allowed directory", ErrOutsideRoots, r.Display(abs))
+	}
+	rel, err := filepath.Rel(root.Path, abs)
+	if err != nil {
+		return "", "", err
+	}
+	return root.Path, rel, nil
+}
@@ -0,0 +1,10 @@
+//go:build unix && !linux
+
+package security
+
+import "os"
+
+// openBeneath 在没有 openat2 的系统上逐级 openat 解析 rel
+func openBeneath(root, rel string, flag int, perm os.FileMode) (*os.File, error) {
+	return openWalk(root, rel, flag, perm)
+}
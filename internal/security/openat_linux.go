@@ -0,0 +1,72 @@
+package security
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sys/unix"
+)
+
+// noOpenat2 在内核不支持 openat2（Linux 5.6 之前，或被 seccomp 拦截）时置位，之后直接使用 openWalk
+var noOpenat2 atomic.Bool
+
+var probeOnce sync.Once
+
+// probeOpenat2 首次使用时以 O_PATH 打开根目录本身，探测 openat2 是否可用。旧内核返回 ENOSYS；
+// 旧版 Docker 等 seccomp 配置拦截未知系统调用时返回 EPERM。EPERM 只在探测时视为不支持，
+// 正常打开文件时它表示真实的权限错误（如不可变文件）
+func probeOpenat2(rootfd int) {
+	probeOnce.Do(func() {
+		fd, err := unix.Openat2(rootfd, ".", &unix.OpenHow{Flags: unix.O_PATH | unix.O_DIRECTORY | unix.O_CLOEXEC, Resolve: unix.RESOLVE_BENEATH})
+		if err == nil {
+			unix.Close(fd)
+			return
+		}
+		if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EPERM) {
+			noOpenat2.Store(true)
+		}
+	})
+}
+
+// openBeneath 使用 openat2(RESOLVE_BENEATH|RESOLVE_NO_MAGICLINKS) 打开 root 下的 rel：
+// 由内核保证解析过程（包括符号链接和 ..）不离开 root，也不跟随 /proc 下的魔术链接。
+// 内核不支持 openat2 或路径中含有绝对路径符号链接时改用 openWalk。
+func openBeneath(root, rel string, flag int, perm os.FileMode) (*os.File, error) {
+	if noOpenat2.Load() {
+		return openWalk(root, rel, flag, perm)
+	}
+	rootfd, err := unix.Open(root, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: root, Err: err}
+	}
+	defer unix.Close(rootfd)
+	if probeOpenat2(rootfd); noOpenat2.Load() {
+		return openWalk(root, rel, flag, perm)
+	}
+	how := &unix.OpenHow{
+		Flags:   uint64(flag | unix.O_CLOEXEC),
+		Mode:    uint64(perm.Perm()),
+		Resolve: unix.RESOLVE_BENEATH | unix.RESOLVE_NO_MAGICLINKS,
+	}
+	for {
+		fd, err := unix.Openat2(rootfd, rel, how)
+		switch {
+		case err == nil:
+			return os.NewFile(uintptr(fd), filepath.Join(root, rel)), nil
+		case errors.Is(err, unix.EINTR), errors.Is(err, unix.EAGAIN):
+			// EAGAIN 表示解析期间有并发的重命名，重试即可
+			continue
+		case errors.Is(err, unix.ENOSYS):
+			noOpenat2.Store(true)
+			return openWalk(root, rel, flag, perm)
+		case errors.Is(err, unix.EXDEV):
+			// RESOLVE_BENEATH 也拒绝指向根目录内的绝对路径符号链接，交给 openWalk 逐级判断
+			return openWalk(root, rel, flag, perm)
+		default:
+			return nil, &os.PathError{Op: "open", Path: filepath.Join(root, rel), Err: err}
+		}
+	}
+}
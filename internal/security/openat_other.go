@@ -0,0 +1,79 @@
+//go:build !unix
+
+package security
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// openBeneath 在没有 openat 的系统（Windows）上逐级解析符号链接后按路径打开，
+// 打开后再确认路径仍指向同一个文件，缩小检查和打开之间的竞争窗口
+func openBeneath(root, rel string, flag int, perm os.FileMode) (*os.File, error) {
+	path, err := resolveBeneath(root, rel)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	opened, err := f.Stat()
+	if err == nil {
+		var now string
+		if now, err = resolveBeneath(root, rel); err == nil && now == path {
+			var info os.FileInfo
+			if info, err = os.Lstat(path); err == nil && os.SameFile(opened, info) {
+				return f, nil
+			}
+		}
+	}
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	return nil, errEscape
+}
+
+// mkdirBeneath 在 root 内创建目录 rel
+func mkdirBeneath(root, rel string, perm os.FileMode) error {
+	path, err := resolveBeneath(root, rel)
+	if err != nil {
+		return err
+	}
+	return os.Mkdir(path, perm)
+}
+
+// removeBeneath 删除 root 内的文件 rel；rel 本身是符号链接时只删除链接
+func removeBeneath(root, rel string) error {
+	dir, err := resolveBeneath(root, filepath.Dir(rel))
+	if err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(dir, filepath.Base(rel)))
+}
+
+// renameBeneath 把 root 内的 oldRel 重命名为 newRel
+func renameBeneath(root, oldRel, newRel string) error {
+	oldDir, err := resolveBeneath(root, filepath.Dir(oldRel))
+	if err != nil {
+		return err
+	}
+	newDir, err := resolveBeneath(root, filepath.Dir(newRel))
+	if err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(oldDir, filepath.Base(oldRel)), filepath.Join(newDir, filepath.Base(newRel)))
+}
+
+// resolveBeneath 解析 root 下的 rel 中的全部符号链接，结果不在 root 内时返回 errEscape
+func resolveBeneath(root, rel string) (string, error) {
+	path, err := resolveLinks(filepath.Join(root, rel))
+	if err != nil {
+		return "", err
+	}
+	if !within(root, path) {
+		return "", errEscape
+	}
+	return path, nil
+}
@@ -0,0 +1,174 @@
+//go:build unix
+
+package security
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+var openers = map[string]func(root, rel string, flag int, perm os.FileMode) (*os.File, error){
+	"openBeneath": openBeneath,
+	"openWalk":    openWalk,
+}
+
+func TestOpenBeneath(t *testing.T) {
+	root := resolveDir(t.TempDir())
+	outside := resolveDir(t.TempDir())
+	os.MkdirAll(filepath.Join(root, "sub"), 0755)
+	os.WriteFile(filepath.Join(root, "sub", "a.txt"), []byte("inside"), 0644)
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("outside"), 0644)
+	os.Symlink("sub/a.txt", filepath.Join(root, "rel-link"))
+	os.Symlink(filepath.Join(root, "sub"), filepath.Join(root, "abs-link"))
+	os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "out-link"))
+	os.Symlink("../"+filepath.Base(outside), filepath.Join(root, "dotdot-link"))
+
+	for name, open := range openers {
+		t.Run(name, func(t *testing.T) {
+			for _, rel := range []string{"sub/a.txt", "rel-link", "abs-link/a.txt", "sub/../sub/a.txt"} {
+				f, err := open(root, rel, os.O_RDONLY, 0)
+				if err != nil {
+					t.Errorf("%s: %v", rel, err)
+					continue
+				}
+				data, _ := io.ReadAll(f)
+				f.Close()
+				if string(data) != "inside" {
+					t.Errorf("%s: got %q", rel, data)
+				}
+			}
+			for _, rel := range []string{"out-link", "dotdot-link/secret.txt", "../" + filepath.Base(outside) + "/secret.txt"} {
+				if f, err := open(root, rel, os.O_RDONLY, 0); !errors.Is(err, errEscape) {
+					if f != nil {
+						f.Close()
+					}
+					t.Errorf("%s: expected escape, got %v", rel, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := resolveDir(t.TempDir())
+	outside := resolveDir(t.TempDir())
+	os.WriteFile(filepath.Join(root, "a.tmp"), []byte("new"), 0644)
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("old"), 0644)
+	os.Symlink(outside, filepath.Join(root, "out"))
+	r := NewResolver(&types.Config{RootDir: root})
+
+	if err := r.Rename(filepath.Join(root, "a.tmp"), filepath.Join(root, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(data) != "new" {
+		t.Errorf("a.txt = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.tmp")); !os.IsNotExist(err) {
+		t.Error("source should be gone after rename")
+	}
+	// 目标的父目录在检查后被替换为指向根目录外的链接时，重命名不能离开根目录
+	if err := r.Rename(filepath.Join(root, "a.txt"), filepath.Join(root, "out", "a.txt")); !errors.Is(err, ErrOutsideRoots) {
+		t.Errorf("expected rename through an escaping link to be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "a.txt")); !os.IsNotExist(err) {
+		t.Error("file must not be moved outside the root")
+	}
+}
+
+func TestDanglingSymlink(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := resolveDir(t.TempDir())
+	outside := resolveDir(t.TempDir())
+	os.Symlink(filepath.Join(outside, "new.txt"), filepath.Join(root, "dangling"))
+	os.Symlink(outside, filepath.Join(root, "dir-link"))
+	r := NewResolver(&types.Config{RootDir: root})
+
+	// 悬空链接和父目录为链接的新文件都应在 Resolve 时被识别为根目录外的路径
+	for _, p := range []string{"dangling", "dir-link/new.txt", "dir-link/a/b.txt"} {
+		if _, err := r.Resolve(p, Write); !errors.Is(err, ErrOutsideRoots) {
+			t.Errorf("Resolve(%q): expected refusal, got %v", p, err)
+		}
+	}
+
+	// 即使绕过 Resolve，打开时也不能经由链接在根目录外创建文件
+	for name, open := range openers {
+		if f, err := open(root, "dangling", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644); !errors.Is(err, errEscape) {
+			if f != nil {
+				f.Close()
+			}
+			t.Errorf("%s: expected escape, got %v", name, err)
+		}
+	}
+	if err := r.WriteFile(filepath.Join(root, "dir-link", "new.txt"), []byte("x"), 0644); !errors.Is(err, ErrOutsideRoots) {
+		t.Errorf("WriteFile: expected refusal, got %v", err)
+	}
+	if err := r.MkdirAll(filepath.Join(root, "dir-link", "a"), 0755); !errors.Is(err, ErrOutsideRoots) {
+		t.Errorf("MkdirAll: expected refusal, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(outside, "new.txt")); err == nil {
+		t.Error("file was created outside the root")
+	}
+	if _, err := os.Lstat(filepath.Join(outside, "a")); err == nil {
+		t.Error("directory was created outside the root")
+	}
+}
+
+func TestSymlinkRace(t *testing.T) {
+	root := resolveDir(t.TempDir())
+	outside := resolveDir(t.TempDir())
+	os.MkdirAll(filepath.Join(root, "real"), 0755)
+	os.WriteFile(filepath.Join(root, "real", "secret.txt"), []byte("inside"), 0644)
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("outside"), 0644)
+	link := filepath.Join(root, "dir")
+	os.Symlink("real", link)
+
+	// 模拟并发运行的命令在检查和打开之间把 dir 在根目录内外之间来回切换
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tmp := filepath.Join(root, "dir.tmp")
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			target := "real"
+			if i%2 == 0 {
+				target = outside
+			}
+			os.Remove(tmp)
+			os.Symlink(target, tmp)
+			os.Rename(tmp, link)
+		}
+	}()
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	for name, open := range openers {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 2000; i++ {
+				f, err := open(root, "dir/secret.txt", os.O_RDONLY, 0)
+				if err != nil {
+					continue
+				}
+				data, _ := io.ReadAll(f)
+				f.Close()
+				if string(data) != "inside" {
+					t.Fatalf("read %q through a swapped symlink", data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+//go:build unix
+
+package security
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// openWalk 从 root 的目录 fd 开始逐级 openat(O_NOFOLLOW) 打开 rel 的每个组件。遇到符号链接时读取目标，
+// 在已打开的目录栈上继续解析；目标为根目录外的绝对路径或 .. 超出根目录时返回 errEscape。
+// 每一级都相对已打开的目录 fd 解析，并发替换路径中的组件无法让解析离开根目录。
+func openWalk(root, rel string, flag int, perm os.FileMode) (*os.File, error) {
+	rootfd, err := unix.Open(root, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: root, Err: err}
+	}
+	stack := []int{rootfd}
+	defer func() {
+		for _, fd := range stack {
+			unix.Close(fd)
+		}
+	}()
+	pop := func(n int) {
+		for len(stack) > n {
+			unix.Close(stack[len(stack)-1])
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	path := filepath.Join(root, rel)
+	parts := splitPath(rel)
+	links := 0
+	for {
+		dirfd := stack[len(stack)-1]
+		if len(parts) == 0 {
+			// rel 指向根目录本身，或以 .. 结尾
+			fd, err := unix.Openat(dirfd, ".", flag|unix.O_CLOEXEC, uint32(perm.Perm()))
+			if err != nil {
+				return nil, &os.PathError{Op: "open", Path: path, Err: err}
+			}
+			return os.NewFile(uintptr(fd), path), nil
+		}
+		name := parts[0]
+		parts = parts[1:]
+		if name == ".." {
+			if len(stack) == 1 {
+				return nil, errEscape
+			}
+			pop(len(stack) - 1)
+			continue
+		}
+
+		var fd int
+		if len(parts) == 0 {
+			fd, err = unix.Openat(dirfd, name, flag|unix.O_NOFOLLOW|unix.O_CLOEXEC, uint32(perm.Perm()))
+		} else {
+			fd, err = unix.Openat(dirfd, name, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		}
+		if err == nil {
+			if len(parts) == 0 {
+				return os.NewFile(uintptr(fd), path), nil
+			}
+			stack = append(stack, fd)
+			continue
+		}
+		// O_NOFOLLOW 遇到符号链接时各系统返回的错误不同（ELOOP、EMLINK、ENOTDIR、EEXIST），以 readlinkat 是否成功为准
+		target, lerr := readlinkat(dirfd, name)
+		if lerr != nil {
+			return nil, &os.PathError{Op: "open", Path: path, Err: err}
+		}
+		if links++; links > maxSymlinks {
+			return nil, &os.PathError{Op: "open", Path: path, Err: unix.ELOOP}
+		}
+		if filepath.IsAbs(target) {
+			if !within(root, target) {
+				return nil, errEscape
+			}
+			target, _ = filepath.Rel(root, target)
+			pop(1)
+		}
+		parts = append(splitPath(target), parts...)
+	}
+}
+
+func readlinkat(dirfd int, name string) (string, error) {
+	for size := 128; ; size *= 2 {
+		buf := make([]byte, size)
+		n, err := unix.Readlinkat(dirfd, name, buf)
+		if err != nil {
+			return "", err
+		}
+		if n < size {
+			return string(buf[:n]), nil
+		}
+	}
+}
+
+// mkdirBeneath 在 root 内创建目录 rel，父目录按 openBeneath 的规则解析
+func mkdirBeneath(root, rel string, perm os.FileMode) error {
+	parent, err := openBeneath(root, filepath.Dir(rel), unix.O_RDONLY|unix.O_DIRECTORY, 0)
+	if err != nil {
+		return err
+	}
+	defer parent.Close()
+	if err := unix.Mkdirat(int(parent.Fd()), filepath.Base(rel), uint32(perm.Perm())); err != nil {
+		return &os.PathError{Op: "mkdir", Path: filepath.Join(root, rel), Err: err}
+	}
+	return nil
+}
+
+// renameBeneath 把 root 内的 oldRel 重命名为 newRel，两者的父目录都按 openBeneath 的规则解析
+func renameBeneath(root, oldRel, newRel string) error {
+	oldParent, err := openBeneath(root, filepath.Dir(oldRel), unix.O_RDONLY|unix.O_DIRECTORY, 0)
+	if err != nil {
+		return err
+	}
+	defer oldParent.Close()
+	newParent, err := openBeneath(root, filepath.Dir(newRel), unix.O_RDONLY|unix.O_DIRECTORY, 0)
+	if err != nil {
+		return err
+	}
+	defer newParent.Close()
+	if err := unix.Renameat(int(oldParent.Fd()), filepath.Base(oldRel), int(newParent.Fd()), filepath.Base(newRel)); err != nil {
+		return &os.LinkError{Op: "rename", Old: filepath.Join(root, oldRel), New: filepath.Join(root, newRel), Err: err}
+	}
+	return nil
+}
+
+// removeBeneath 删除 root 内的文件 rel，父目录按 openBeneath 的规则解析；rel 本身是符号链接时只删除链接
+func removeBeneath(root, rel string) error {
+	parent, err := openBeneath(root, filepath.Dir(rel), unix.O_RDONLY|unix.O_DIRECTORY, 0)
+	if err != nil {
+		return err
+	}
+	defer parent.Close()
+	if err := unix.Unlinkat(int(parent.Fd()), filepath.Base(rel), 0); err != nil {
+		return &os.PathError{Op: "remove", Path: filepath.Join(root, rel), Err: err}
+	}
+	return nil
+}
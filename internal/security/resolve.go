@@ -0,0 +1,162 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+// Access 是工具对路径的访问方式
+type Access int
+
+const (
+	Read Access = iota
+	Write
+)
+
+// ErrOutsideRoots 表示路径不在任何允许的根目录内，或所在根目录不允许写入
+var ErrOutsideRoots = errors.New("path outside sandbox")
+
+// DefaultReadOnlyRoots 是工作目录之外默认只读开放的目录（skills、提示词和项目指令所在位置）
+var DefaultReadOnlyRoots = []string{"~/.openlink", "~/.claude", "~/.agent"}
+
+// Root 是工具可访问的目录
+type Root struct {
+	Path     string
+	Writable bool
+}
+
+// Resolver 把工具参数中的路径统一解析为绝对路径：相对路径相对工作目录，也接受绝对路径和 ~/ 开头的路径。
+// 解析后的路径必须位于某个允许的根目录内，且不被敏感路径规则隐藏。
+type Resolver struct {
+	workspace string
+	home      string
+	roots     []Root
+	ignore    *Ignore
+}
+
+// NewResolver 按配置创建 Resolver：工作目录可读写，另加 settings.json 中 paths 配置的目录；
+// 未配置只读目录时使用 DefaultReadOnlyRoots
+func NewResolver(cfg *types.Config) *Resolver {
+	r := &Resolver{workspace: resolveDir(cfg.RootDir)}
+	if home, err := os.UserHomeDir(); err == nil {
+		r.home = resolveDir(home)
+	}
+	r.roots = append(r.roots, Root{Path: r.workspace, Writable: true})
+	readOnly, readWrite := DefaultReadOnlyRoots, []string(nil)
+	if cfg.Paths != nil {
+		if cfg.Paths.ReadOnly != nil {
+			readOnly = cfg.Paths.ReadOnly
+		}
+		readWrite = cfg.Paths.ReadWrite
+	}
+	// 可写目录在前，同一目录同时出现在两个列表中时按可写处理
+	for _, p := range readWrite {
+		r.roots = append(r.roots, Root{Path: resolveDir(r.expand(p)), Writable: true})
+	}
+	for _, p := range readOnly {
+		r.roots = append(r.roots, Root{Path: resolveDir(r.expand(p))})
+	}
+	r.ignore = LoadIgnore(r.workspace)
+	return r
+}
+
+// Workspace 返回解析符号链接后的工作目录
+func (r *Resolver) Workspace() string {
+	return r.workspace
+}
+
+// Roots 返回全部允许访问的根目录，第一个是工作目录
+func (r *Resolver) Roots() []Root {
+	return r.roots
+}
+
+// Ignore 返回工作目录的敏感路径规则，用于在列表和搜索结果中省略被隐藏的路径
+func (r *Resolver) Ignore() *Ignore {
+	return r.ignore
+}
+
+// expand 展开 ~ 并把相对路径拼接到工作目录
+func (r *Resolver) expand(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		return filepath.Join(r.home, path[1:])
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(r.workspace, path)
+}
+
+// Resolve 解析并检查路径，返回解析符号链接后的绝对路径。读写文件应使用 Open、OpenFile 等方法，
+// 不要直接按返回的路径调用 os 包。
+func (r *Resolver) Resolve(path string, access Access) (string, error) {
+	if path == "" {
+		path = "."
+	}
+	target := r.expand(filepath.FromSlash(path))
+	// 逐级解析符号链接：新建文件时父目录中的链接和指向不存在目标的悬空链接同样被解析。
+	// 这里的检查只用于给出明确的错误，真正打开文件时还会由 OpenFile 在根目录内重新解析。
+	abs, err := resolveLinks(target)
+	if err != nil {
+		return "", err
+	}
+	root, ok := r.rootOf(abs)
+	if !ok {
+		return "", fmt.Errorf("%w: %s is not inside the workspace or an allowed directory", ErrOutsideRoots, r.Display(abs))
+	}
+	if access == Write && !root.Writable {
+		return "", fmt.Errorf("%w: %s is in read-only directory %s", ErrOutsideRoots, r.Display(abs), r.Display(root.Path))
+	}
+	if access == Write {
+		err = r.ignore.CheckWrite(abs)
+	} else {
+		err = r.ignore.Check(abs)
+	}
+	if err != nil {
+		return "", err
+	}
+	return abs, nil
+}
+
+// rootOf 返回包含 abs 的根目录；可写目录优先
+func (r *Resolver) rootOf(abs string) (Root, bool) {
+	var found *Root
+	for i := range r.roots {
+		root := &r.roots[i]
+		if within(root.Path, abs) && (found == nil || root.Writable && !found.Writable) {
+			found = root
+		}
+	}
+	if found == nil {
+		return Root{}, false
+	}
+	return *found, true
+}
+
+func within(root, path string) bool {
+	return path == root || strings.HasPrefix(path, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// Display 返回在工具输出中展示的路径：工作目录内为 / 分隔的相对路径，用户主目录内为 ~/ 开头的路径，
+// 其他为 / 分隔的绝对路径。Display 的结果可以原样作为任何工具的 path 参数。
+func (r *Resolver) Display(abs string) string {
+	if within(r.workspace, abs) {
+		rel, err := filepath.Rel(r.workspace, abs)
+		if err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	if r.home != "" && within(r.home, abs) {
+		if rel, err := filepath.Rel(r.home, abs); err == nil {
+			if rel == "." {
+				return "~"
+			}
+			return "~/" + filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(abs)
+}
@@ -0,0 +1,73 @@
+package security
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+func TestResolver(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := t.TempDir()
+	extra := t.TempDir()
+	os.MkdirAll(filepath.Join(home, ".openlink", "skills"), 0755)
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0644)
+	r := NewResolver(&types.Config{RootDir: root})
+	ws := r.Workspace()
+
+	t.Run("relative, absolute and slash forms agree", func(t *testing.T) {
+		want := filepath.Join(ws, "a.txt")
+		for _, p := range []string{"a.txt", "./a.txt", filepath.Join(ws, "a.txt"), filepath.ToSlash(filepath.Join(ws, "a.txt"))} {
+			got, err := r.Resolve(p, Write)
+			if err != nil || got != want {
+				t.Errorf("Resolve(%q) = %q, %v", p, got, err)
+			}
+		}
+	})
+
+	t.Run("default read-only roots", func(t *testing.T) {
+		got, err := r.Resolve("~/.openlink/skills", Read)
+		if err != nil || got != filepath.Join(r.home, ".openlink", "skills") {
+			t.Errorf("got %q, %v", got, err)
+		}
+		if _, err := r.Resolve("~/.openlink/skills/x.md", Write); !errors.Is(err, ErrOutsideRoots) {
+			t.Errorf("expected read-only refusal, got %v", err)
+		}
+	})
+
+	t.Run("outside roots", func(t *testing.T) {
+		for _, p := range []string{"../x", extra, "~/other.txt"} {
+			if _, err := r.Resolve(p, Read); !errors.Is(err, ErrOutsideRoots) {
+				t.Errorf("Resolve(%q): expected refusal, got %v", p, err)
+			}
+		}
+	})
+
+	t.Run("configured roots", func(t *testing.T) {
+		r := NewResolver(&types.Config{RootDir: root, Paths: &types.PathSettings{ReadOnly: []string{}, ReadWrite: []string{extra}}})
+		if _, err := r.Resolve(filepath.Join(extra, "new.txt"), Write); err != nil {
+			t.Errorf("read-write root: %v", err)
+		}
+		if _, err := r.Resolve("~/.openlink/skills", Read); !errors.Is(err, ErrOutsideRoots) {
+			t.Errorf("empty read_only should disable default roots, got %v", err)
+		}
+	})
+
+	t.Run("display", func(t *testing.T) {
+		cases := map[string]string{
+			ws:                                       ".",
+			filepath.Join(ws, "src", "main.go"):      "src/main.go",
+			filepath.Join(r.home, ".openlink", "x"):  "~/.openlink/x",
+			filepath.Join(r.home, "..", "elsewhere"): filepath.ToSlash(filepath.Join(r.home, "..", "elsewhere")),
+		}
+		for abs, want := range cases {
+			if got := r.Display(abs); got != want {
+				t.Errorf("Display(%s) = %s, want %s", abs, got, want)
+			}
+		}
+	})
+}
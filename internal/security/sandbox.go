@@ -2,7 +2,6 @@ package security
 
 import (
 	"errors"
-	"os"
 	"path/filepath"
 	"strings"
 )
@@ -17,14 +16,9 @@ func SafePath(rootDir, targetPath string) (string, error) {
 			return "", err
 		}
 	}
-	joined := filepath.Join(absRoot, targetPath)
-	// EvalSymlinks 解析符号链接；文件不存在时（新建场景）fallback 到 Abs
-	absTarget, err := filepath.EvalSymlinks(joined)
+	absTarget, err := resolveLinks(filepath.Join(absRoot, targetPath))
 	if err != nil {
-		absTarget, err = filepath.Abs(joined)
-		if err != nil {
-			return "", err
-		}
+		return "", err
 	}
 	if !strings.HasPrefix(absTarget, absRoot+string(filepath.Separator)) && absTarget != absRoot {
 		return "", errors.New("path outside sandbox")
@@ -32,40 +26,6 @@ func SafePath(rootDir, targetPath string) (string, error) {
 	return absTarget, nil
 }
 
-// SafeAbsPath validates an already-absolute (or ~-prefixed) path against one or more allowed roots.
-func SafeAbsPath(targetPath string, allowedRoots ...string) (string, error) {
-	if strings.HasPrefix(targetPath, "~/") {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return "", err
-		}
-		targetPath = filepath.Join(home, targetPath[2:])
-	}
-	if !filepath.IsAbs(targetPath) {
-		return "", errors.New("not an absolute path")
-	}
-	absTarget, err := filepath.EvalSymlinks(targetPath)
-	if err != nil {
-		absTarget, err = filepath.Abs(targetPath)
-		if err != nil {
-			return "", err
-		}
-	}
-	for _, rootDir := range allowedRoots {
-		absRoot, err := filepath.EvalSymlinks(rootDir)
-		if err != nil {
-			absRoot, err = filepath.Abs(rootDir)
-			if err != nil {
-				continue
-			}
-		}
-		if strings.HasPrefix(absTarget, absRoot+string(filepath.Separator)) || absTarget == absRoot {
-			return absTarget, nil
-		}
-	}
-	return "", errors.New("path outside sandbox")
-}
-
 var DangerousCommands = []string{
 	"rm -rf", "rm -fr", "mkfs", "dd", "format",
 	"> /dev/", "curl", "wget", "nc", "netcat",
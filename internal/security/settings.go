@@ -0,0 +1,38 @@
+package security
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+// SettingsPath returns ~/.openlink/settings.json.
+func SettingsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".openlink", "settings.json"), nil
+}
+
+// LoadSettings reads ~/.openlink/settings.json. A missing file yields empty settings.
+func LoadSettings() (*types.Settings, error) {
+	path, err := SettingsPath()
+	if err != nil {
+		return nil, err
+	}
+	settings := &types.Settings{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
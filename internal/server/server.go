@@ -1,20 +1,19 @@
 package server
 
 import (
-	"context"
 	"crypto/subtle"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
-	"runtime"
-	"strings"
-	"time"
 
+	"github.com/afumu/openlink/internal/checkpoint"
 	"github.com/afumu/openlink/internal/executor"
+	"github.com/afumu/openlink/internal/prompt"
+	"github.com/afumu/openlink/internal/question"
 	"github.com/afumu/openlink/internal/security"
 	"github.com/afumu/openlink/internal/skill"
+	"github.com/afumu/openlink/internal/todo"
 	"github.com/afumu/openlink/internal/types"
 	"github.com/gin-gonic/gin"
 )
@@ -59,6 +58,16 @@ func (s *Server) setupRoutes() {
 	s.router.GET("/tools", s.handleListTools)
 	s.router.POST("/exec", s.handleExec)
 	s.router.GET("/prompt", s.handlePrompt)
+	s.router.GET("/reinject", s.handleReinjectStatus)
+	s.router.POST("/reinject", s.handleReinject)
+	s.router.POST("/reinject/policy", s.handleReinjectPolicy)
+	s.router.GET("/skills", s.handleListSkills)
+	s.router.POST("/skills/reload", s.handleReloadSkills)
+	s.router.GET("/todos", s.handleTodos)
+	s.router.GET("/checkpoints", s.handleListCheckpoints)
+	s.router.POST("/checkpoints/:id/restore", s.handleRestoreCheckpoint)
+	s.router.GET("/questions", s.handleListQuestions)
+	s.router.POST("/questions/:id/answer", s.handleAnswerQuestion)
 }
 
 func (s *Server) handleHealth(c *gin.Context) {
@@ -91,37 +100,66 @@ func (s *Server) handleConfig(c *gin.Context) {
 	})
 }
 
-func buildSystemInfo(rootDir string) string {
-	hostname, _ := os.Hostname()
-	return fmt.Sprintf("- 操作系统: %s/%s\n- 工作目录: %s\n- 主机名: %s\n- 当前时间: %s",
-		runtime.GOOS, runtime.GOARCH, rootDir, hostname,
-		time.Now().Format("2006-01-02 15:04:05"))
-}
-
+// handlePrompt 返回渲染后的初始化提示词：GET /prompt?platform=gemini|qwen|chatgpt|claude|deepseek&lang=zh|en。
+// 携带 session 时记录该会话的平台和语言，供之后重新注入使用。
 func (s *Server) handlePrompt(c *gin.Context) {
-	content, err := os.ReadFile(filepath.Join(s.config.RootDir, "prompts", "init_prompt.txt"))
+	platform, lang := c.Query("platform"), c.Query("lang")
+	content, err := s.executor.Prompt(platform, lang)
 	if err != nil {
-		if len(s.config.DefaultPrompt) == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "init_prompt.txt not found"})
-			return
-		}
-		content = s.config.DefaultPrompt
+		c.JSON(promptErrorStatus(err), gin.H{"error": err.Error()})
+		return
 	}
-	content = []byte(strings.ReplaceAll(string(content), "{{SYSTEM_INFO}}", buildSystemInfo(s.config.RootDir)))
+	if session := c.Query("session"); session != "" {
+		s.executor.BindSession(session, platform, lang, true)
+	}
+	c.String(http.StatusOK, content)
+}
 
-	skills := skill.LoadInfos(s.config.RootDir)
-	if len(skills) > 0 {
-		var sb strings.Builder
-		sb.WriteString("\n\n## 当前可用 Skills\n\n")
-		for _, sk := range skills {
-			sb.WriteString(fmt.Sprintf("- **%s**: %s\n", sk.Name, sk.Description))
-		}
-		content = append(content, []byte(sb.String())...)
+func promptErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, prompt.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, prompt.ErrInvalid):
+		return http.StatusBadRequest
 	}
+	return http.StatusInternalServerError
+}
 
-	content = append(content, []byte("\n\n初始化回复：\n你好，我是 openlink，请问有什么可以帮你？")...)
+func (s *Server) handleReinjectStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, s.executor.ReinjectStatus(c.Query("session")))
+}
+
+// handleReinject 请求重新注入：返回渲染后的提示词，并让该会话的下一次工具输出附带完整提示词
+func (s *Server) handleReinject(c *gin.Context) {
+	session := c.Query("session")
+	if platform, lang := c.Query("platform"), c.Query("lang"); platform != "" || lang != "" {
+		s.executor.BindSession(session, platform, lang, false)
+	}
+	content, err := s.executor.RequestReinject(session)
+	if err != nil {
+		c.JSON(promptErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"session": types.NormalizeSession(session), "prompt": content})
+}
 
-	c.String(http.StatusOK, string(content))
+// handleReinjectPolicy 设置会话级注入策略，请求体为空对象时恢复为全局和平台配置
+func (s *Server) handleReinjectPolicy(c *gin.Context) {
+	var policy types.ReinjectPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var override *types.ReinjectPolicy
+	if policy != (types.ReinjectPolicy{}) {
+		override = &policy
+	}
+	session := c.Query("session")
+	if err := s.executor.SetReinjectPolicy(session, override); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, s.executor.ReinjectStatus(session))
 }
 
 func (s *Server) handleListTools(c *gin.Context) {
@@ -142,11 +180,10 @@ func (s *Server) handleExec(c *gin.Context) {
 		return
 	}
 
-	log.Printf("[OpenLink] 工具调用: name=%s, args=%+v\n", req.Name, req.Args)
+	log.Printf("[OpenLink] 工具调用: name=%s, args=%s\n", req.Name, s.executor.Redact(fmt.Sprintf("%+v", req.Args)))
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.Timeout)*time.Second)
-	defer cancel()
-	resp := s.executor.Execute(ctx, &req)
+	// 各工具自行按 config.Timeout 或参数限时；这里只在扩展断开连接时取消，例如结束等待回答的 question
+	resp := s.executor.Execute(c.Request.Context(), &req)
 
 	log.Printf("[OpenLink] 执行结果: status=%s, output长度=%d\n", resp.Status, len(resp.Output))
 	if resp.Error != "" {
@@ -157,6 +194,122 @@ func (s *Server) handleExec(c *gin.Context) {
 	log.Println("[OpenLink] 响应已发送")
 }
 
+func (s *Server) handleListSkills(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"skills": skillList(s.executor.Skills().List())})
+}
+
+func (s *Server) handleReloadSkills(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"skills": skillList(s.executor.Skills().Reload())})
+}
+
+func skillList(infos []skill.Info) []gin.H {
+	list := make([]gin.H, 0, len(infos))
+	for _, info := range infos {
+		item := gin.H{
+			"name":        info.Name,
+			"description": info.Description,
+			"dir":         info.Dir,
+			"location":    info.Location,
+			"broken":      info.Broken(),
+		}
+		if info.Version != "" {
+			item["version"] = info.Version
+		}
+		if info.WhenToUse != "" {
+			item["when_to_use"] = info.WhenToUse
+		}
+		if len(info.AllowedTools) > 0 {
+			item["allowed_tools"] = info.AllowedTools
+		}
+		if len(info.Arguments) > 0 {
+			item["arguments"] = info.Arguments
+		}
+		if len(info.Tools) > 0 {
+			tools := make([]string, 0, len(info.Tools))
+			for _, spec := range info.Tools {
+				tools = append(tools, info.QualifiedName(spec))
+			}
+			item["tools"] = tools
+		}
+		if len(info.Metadata) > 0 {
+			item["metadata"] = info.Metadata
+		}
+		if len(info.Diagnostics) > 0 {
+			item["diagnostics"] = info.Diagnostics
+		}
+		list = append(list, item)
+	}
+	return list
+}
+
+func (s *Server) handleTodos(c *gin.Context) {
+	session := types.NormalizeSession(c.Query("session"))
+	items, err := s.executor.Todos().Get(session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if items == nil {
+		items = []todo.Item{}
+	}
+	c.JSON(http.StatusOK, gin.H{"session": session, "todos": items})
+}
+
+func (s *Server) handleListCheckpoints(c *gin.Context) {
+	session := types.NormalizeSession(c.Query("session"))
+	list, err := s.executor.Checkpoints().List(session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"session": session, "checkpoints": list})
+}
+
+// handleRestoreCheckpoint 撤销会话中该检查点及之后的全部修改：POST /checkpoints/:id/restore?session=
+func (s *Server) handleRestoreCheckpoint(c *gin.Context) {
+	session := types.NormalizeSession(c.Query("session"))
+	changes, err := s.executor.Checkpoints().Restore(session, c.Param("id"), security.NewResolver(s.config))
+	if changes == nil {
+		changes = []checkpoint.Change{}
+	}
+	if errors.Is(err, checkpoint.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "restored": changes})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"session": session, "restored": changes})
+}
+
+func (s *Server) handleListQuestions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"questions": s.executor.Questions().Pending()})
+}
+
+func (s *Server) handleAnswerQuestion(c *gin.Context) {
+	var req struct {
+		Answer interface{} `json:"answer"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Answer == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "answer is required"})
+		return
+	}
+	ans, err := s.executor.Questions().Answer(c.Param("id"), req.Answer)
+	if errors.Is(err, question.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"answer": ans})
+}
+
 func (s *Server) Run() error {
+	s.executor.Skills().Watch()
+	defer s.executor.Skills().Close()
+	defer s.executor.Close()
 	return s.router.Run(fmt.Sprintf("127.0.0.1:%d", s.config.Port))
 }
@@ -8,12 +8,14 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/afumu/openlink/internal/types"
 )
 
 func testServer(t *testing.T) *Server {
 	t.Helper()
+	t.Setenv("HOME", t.TempDir())
 	cfg := &types.Config{
 		RootDir: t.TempDir(),
 		Port:    8080,
@@ -186,6 +188,199 @@ func TestHandlePrompt(t *testing.T) {
 			t.Errorf("expected prompt content in response")
 		}
 	})
+
+	t.Run("platform template with variables", func(t *testing.T) {
+		os.WriteFile(filepath.Join(s.config.RootDir, "init_prompt.gemini.en.txt"), []byte("{{.Platform}}/{{.Lang}} {{range .Tools}}{{if eq .Name \"read_file\"}}has read_file{{end}}{{end}}"), 0644)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/prompt?platform=gemini&lang=en", nil)
+		req.Header.Set("Authorization", "Bearer testtoken")
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Body.String() != "gemini/en has read_file" {
+			t.Errorf("got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("invalid platform returns 400", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/prompt?platform=../x", nil)
+		req.Header.Set("Authorization", "Bearer testtoken")
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestQuestions(t *testing.T) {
+	s := testServer(t)
+
+	done := make(chan types.ToolResponse, 1)
+	go func() {
+		body, _ := json.Marshal(types.ToolRequest{
+			Name: "question",
+			Args: map[string]interface{}{"question": "Deploy?", "type": "confirm"},
+		})
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/exec", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer testtoken")
+		s.router.ServeHTTP(w, req)
+		var resp types.ToolResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		done <- resp
+	}()
+
+	var list struct {
+		Questions []struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"questions"`
+	}
+	for i := 0; i < 200 && len(list.Questions) == 0; i++ {
+		time.Sleep(5 * time.Millisecond)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/questions", nil)
+		req.Header.Set("Authorization", "Bearer testtoken")
+		s.router.ServeHTTP(w, req)
+		json.NewDecoder(w.Body).Decode(&list)
+	}
+	if len(list.Questions) != 1 || list.Questions[0].Type != "confirm" {
+		t.Fatalf("expected one pending confirm question, got %+v", list.Questions)
+	}
+
+	answer := func(id, body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/questions/"+id+"/answer", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer testtoken")
+		s.router.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := answer("unknown", `{"answer":"yes"}`); code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown id, got %d", code)
+	}
+	if code := answer(list.Questions[0].ID, `{"answer":"maybe"}`); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid answer, got %d", code)
+	}
+	if code := answer(list.Questions[0].ID, `{"answer":true}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	resp := <-done
+	if resp.Status != "success" || !bytes.Contains([]byte(resp.Output), []byte("回答: yes")) {
+		t.Errorf("got status=%s output=%q", resp.Status, resp.Output)
+	}
+}
+
+func TestHandleTodos(t *testing.T) {
+	s := testServer(t)
+
+	body, _ := json.Marshal(types.ToolRequest{
+		Name:    "todo_write",
+		Args:    map[string]interface{}{"todos": `[{"content":"write tests","status":"in_progress"}]`},
+		Session: "conv-1",
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/exec", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer testtoken")
+	s.router.ServeHTTP(w, req)
+
+	get := func(session string) []map[string]interface{} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/todos?session="+session, nil)
+		req.Header.Set("Authorization", "Bearer testtoken")
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		var resp struct {
+			Todos []map[string]interface{} `json:"todos"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Todos
+	}
+
+	todos := get("conv-1")
+	if len(todos) != 1 || todos[0]["content"] != "write tests" || todos[0]["status"] != "in_progress" {
+		t.Errorf("got %+v", todos)
+	}
+	if other := get("conv-2"); len(other) != 0 {
+		t.Errorf("expected empty list for other session, got %+v", other)
+	}
+}
+
+func TestReinject(t *testing.T) {
+	s := testServer(t)
+	os.WriteFile(filepath.Join(s.config.RootDir, "init_prompt.txt"), []byte("PROMPT {{.Platform}}"), 0644)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer testtoken")
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("GET", "/prompt?platform=qwen&session=c1", ""); w.Body.String() != "PROMPT qwen" {
+		t.Fatalf("got %q", w.Body.String())
+	}
+	if w := do("POST", "/reinject/policy?session=c1", `{"mode":"bogus"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+	w := do("POST", "/reinject/policy?session=c1", `{"mode":"calls","every":5}`)
+	var status map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&status)
+	policy, _ := status["policy"].(map[string]interface{})
+	if w.Code != http.StatusOK || status["platform"] != "qwen" || policy["every"] != float64(5) {
+		t.Errorf("got %d %v", w.Code, status)
+	}
+
+	w = do("POST", "/reinject?session=c1", "")
+	var resp map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || resp["prompt"] != "PROMPT qwen" {
+		t.Fatalf("got %d %v", w.Code, resp)
+	}
+	body, _ := json.Marshal(types.ToolRequest{Name: "list_dir", Args: map[string]interface{}{"path": "."}, Session: "c1"})
+	w = do("POST", "/exec", string(body))
+	var out types.ToolResponse
+	json.NewDecoder(w.Body).Decode(&out)
+	if !bytes.Contains([]byte(out.Output), []byte("PROMPT qwen")) {
+		t.Errorf("expected re-injected prompt, got %q", out.Output)
+	}
+}
+
+func TestHandleSkills(t *testing.T) {
+	s := testServer(t)
+
+	list := func(method, path string) []map[string]interface{} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer testtoken")
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: expected 200, got %d", method, path, w.Code)
+		}
+		var resp struct {
+			Skills []map[string]interface{} `json:"skills"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Skills
+	}
+
+	if got := list("GET", "/skills"); len(got) != 0 {
+		t.Fatalf("expected no skills, got %+v", got)
+	}
+	dir := filepath.Join(s.config.RootDir, ".skills", "deploy")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\nname: deploy\ndescription: ship it\n---\n"), 0644)
+
+	got := list("POST", "/skills/reload")
+	if len(got) != 1 || got[0]["name"] != "deploy" || got[0]["description"] != "ship it" {
+		t.Errorf("got %+v", got)
+	}
 }
 
 func TestCORSOptions(t *testing.T) {
@@ -197,3 +392,41 @@ func TestCORSOptions(t *testing.T) {
 		t.Errorf("expected 204, got %d", w.Code)
 	}
 }
+
+func TestCheckpoints(t *testing.T) {
+	s := testServer(t)
+	call := func(method, url string, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer testtoken")
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+	body, _ := json.Marshal(types.ToolRequest{Name: "write_file", Session: "s1", Args: map[string]interface{}{"path": "a.txt", "content": "hi"}})
+	if w := call("POST", "/exec", body); w.Code != http.StatusOK {
+		t.Fatalf("exec: %d %s", w.Code, w.Body.String())
+	}
+
+	w := call("GET", "/checkpoints?session=s1", nil)
+	var list struct {
+		Checkpoints []struct {
+			ID      string `json:"id"`
+			Tool    string `json:"tool"`
+			Created bool   `json:"created"`
+		} `json:"checkpoints"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Checkpoints) != 1 || list.Checkpoints[0].Tool != "write_file" || !list.Checkpoints[0].Created {
+		t.Fatalf("got %s", w.Body.String())
+	}
+
+	if w := call("POST", "/checkpoints/"+list.Checkpoints[0].ID+"/restore?session=s1", nil); w.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(s.config.RootDir, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("restore should delete the created file, got %v", err)
+	}
+	if w := call("POST", "/checkpoints/99/restore?session=s1", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,139 @@
+package skill
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+// toolAliases 将 Claude 风格的工具名映射到 openlink 工具名
+var toolAliases = map[string]string{
+	"read":      "read_file",
+	"write":     "write_file",
+	"edit":      "edit",
+	"multiedit": "multi_edit",
+	"bash":      "exec_cmd",
+	"glob":      "glob",
+	"grep":      "grep",
+	"ls":        "list_dir",
+	"webfetch":  "web_fetch",
+	"websearch": "web_search",
+	"todowrite": "todo_write",
+	"todoread":  "todo_read",
+	"skill":     "skill",
+}
+
+// parseToolRule 解析 allowed-tools 条目，如 "read_file"、"Read"、"Bash(git:*)"、"exec_cmd(go test)"
+func parseToolRule(rule string) (tool, pattern string, err error) {
+	rule = strings.TrimSpace(rule)
+	if open := strings.IndexByte(rule, '('); open >= 0 {
+		if !strings.HasSuffix(rule, ")") {
+			return "", "", fmt.Errorf("invalid allowed-tools entry %q: missing )", rule)
+		}
+		pattern = strings.TrimSpace(rule[open+1 : len(rule)-1])
+		rule = strings.TrimSpace(rule[:open])
+	}
+	if rule == "" {
+		return "", "", fmt.Errorf("invalid allowed-tools entry: empty tool name")
+	}
+	return canonicalTool(rule), pattern, nil
+}
+
+func canonicalTool(name string) string {
+	lower := strings.ToLower(name)
+	if alias, ok := toolAliases[lower]; ok {
+		return alias
+	}
+	return lower
+}
+
+// Allows 判断 skill 的 allowed-tools 是否允许本次调用。allowed-tools 为空时不限制，
+// skill 工具本身及该 skill 随附的工具始终允许。
+// exec_cmd 的模式按命令前缀匹配："git:*" 或 "git *" 允许所有以 git 开头的命令，不带通配符时要求完全相同。
+func (i Info) Allows(toolName string, args map[string]interface{}) bool {
+	if len(i.AllowedTools) == 0 {
+		return true
+	}
+	// skill 自己定义的工具始终可用；只按已定义的工具名精确匹配，
+	// 同名 MCP server 挂载的 "<server>.<tool>" 不在其中
+	for _, spec := range i.Tools {
+		if toolName == i.QualifiedName(spec) {
+			return true
+		}
+	}
+	toolName = strings.ToLower(toolName)
+	if toolName == "skill" {
+		return true
+	}
+	for _, rule := range i.AllowedTools {
+		tool, pattern, err := parseToolRule(rule)
+		if err != nil || tool != toolName {
+			continue
+		}
+		if pattern == "" || pattern == "*" {
+			return true
+		}
+		if toolName != "exec_cmd" {
+			continue
+		}
+		command, _ := args["command"].(string)
+		if matchCommand(pattern, strings.TrimSpace(command)) {
+			return true
+		}
+	}
+	return false
+}
+
+// shellMeta 是能在允许的命令之后串联、重定向或嵌入其他命令的 shell 字符
+const shellMeta = ";&|`<>\n\r"
+
+// matchCommand 按前缀匹配命令；含有 shellMeta 或 $( 的命令一律不匹配，
+// 否则 Bash(git:*) 会放行 "git status; rm -rf ~" 这类命令
+func matchCommand(pattern, command string) bool {
+	if strings.ContainsAny(command, shellMeta) || strings.Contains(command, "$(") {
+		return false
+	}
+	for _, suffix := range []string{":*", " *", "*"} {
+		if prefix, ok := strings.CutSuffix(pattern, suffix); ok {
+			prefix = strings.TrimSpace(prefix)
+			return command == prefix || strings.HasPrefix(command, prefix+" ") ||
+				(suffix == "*" && strings.HasPrefix(command, prefix))
+		}
+	}
+	return command == pattern
+}
+
+// Activations 记录每个会话当前激活的 skill。skill 工具加载 skill 时激活，
+// Executor 据此在 skill 声明了 allowed-tools 时拦截其他工具。
+type Activations struct {
+	mu     sync.Mutex
+	active map[string]Info
+}
+
+func NewActivations() *Activations {
+	return &Activations{active: make(map[string]Info)}
+}
+
+func (a *Activations) Activate(session string, info Info) {
+	a.mu.Lock()
+	a.active[types.NormalizeSession(session)] = info
+	a.mu.Unlock()
+}
+
+func (a *Activations) Release(session string) (Info, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	session = types.NormalizeSession(session)
+	info, ok := a.active[session]
+	delete(a.active, session)
+	return info, ok
+}
+
+func (a *Activations) Active(session string) (Info, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	info, ok := a.active[types.NormalizeSession(session)]
+	return info, ok
+}
@@ -0,0 +1,345 @@
+package skill
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+type Argument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Default     string `json:"default,omitempty"`
+}
+
+// 已知的 frontmatter 键，其余键原样保存在 Info.Metadata
+var knownKeys = map[string]bool{
+	"name": true, "description": true, "version": true, "when_to_use": true,
+	"allowed-tools": true, "arguments": true, "tools": true,
+}
+
+// parse 解析 SKILL.md：frontmatter 按 YAML 解析，返回元信息与正文。
+// 格式问题记录在 Info.Diagnostics 中而不是静默回退。
+func parse(path, content string) (Info, string) {
+	info := Info{Name: filepath.Base(filepath.Dir(path))}
+	content = strings.TrimPrefix(content, "\ufeff")
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+
+	if !strings.HasPrefix(normalized, "---\n") {
+		info.addDiag(SeverityWarning, "missing YAML frontmatter; skill is named after its directory")
+		return info, content
+	}
+	rest := normalized[len("---\n"):]
+	var front, body string
+	if strings.HasPrefix(rest, "---\n") || rest == "---" {
+		body = strings.TrimPrefix(strings.TrimPrefix(rest, "---"), "\n")
+	} else {
+		end := strings.Index(rest, "\n---")
+		if end < 0 {
+			info.addDiag(SeverityError, "unterminated frontmatter: closing --- not found")
+			return info, content
+		}
+		front = rest[:end]
+		body = rest[end+len("\n---"):]
+		if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+			body = body[nl+1:]
+		} else {
+			body = ""
+		}
+	}
+
+	var raw map[string]interface{}
+	if strings.TrimSpace(front) != "" {
+		if err := yaml.Unmarshal([]byte(front), &raw); err != nil {
+			info.addDiag(SeverityError, "invalid frontmatter YAML: "+firstLine(err.Error()))
+			return info, body
+		}
+	}
+
+	if v, ok := raw["name"]; ok {
+		if s, ok := scalar(v); ok && strings.TrimSpace(s) != "" {
+			info.Name = strings.TrimSpace(s)
+		} else {
+			info.addDiag(SeverityError, "name must be a non-empty string")
+		}
+	} else {
+		info.addDiag(SeverityWarning, "name not set; using directory name")
+	}
+	if !validName(info.Name) {
+		info.addDiag(SeverityError, fmt.Sprintf("invalid skill name %q", info.Name))
+	}
+	info.Description = stringField(&info, raw, "description")
+	if info.Description == "" {
+		info.addDiag(SeverityWarning, "description is empty")
+	}
+	info.Version = stringField(&info, raw, "version")
+	info.WhenToUse = stringField(&info, raw, "when_to_use")
+	info.AllowedTools = parseAllowedTools(&info, raw["allowed-tools"])
+	info.Arguments = parseArguments(&info, raw["arguments"])
+	info.Tools = parseTools(&info, raw["tools"], "frontmatter")
+
+	for k, v := range raw {
+		if !knownKeys[k] {
+			if info.Metadata == nil {
+				info.Metadata = map[string]interface{}{}
+			}
+			info.Metadata[k] = v
+		}
+	}
+	return info, body
+}
+
+func (i *Info) addDiag(severity, msg string) {
+	i.Diagnostics = append(i.Diagnostics, Diagnostic{Severity: severity, Message: msg})
+}
+
+// Broken 表示 skill 存在 error 级诊断，不会出现在提示词和 skill 工具的列表中
+func (i Info) Broken() bool {
+	for _, d := range i.Diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
+
+// scalar 将 YAML 标量（字符串、数字、布尔）转为字符串
+func scalar(v interface{}) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case nil:
+		return "", true
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(x), true
+	}
+	return "", false
+}
+
+func stringField(info *Info, raw map[string]interface{}, key string) string {
+	v, ok := raw[key]
+	if !ok {
+		return ""
+	}
+	s, ok := scalar(v)
+	if !ok {
+		info.addDiag(SeverityError, key+" must be a string")
+		return ""
+	}
+	return strings.TrimSpace(s)
+}
+
+// parseAllowedTools 接受 YAML 列表或逗号/空白分隔的字符串（如 "Read, Grep, Bash(git:*)"）
+func parseAllowedTools(info *Info, v interface{}) []string {
+	var items []string
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case string:
+		items = splitToolList(x)
+	case []interface{}:
+		for _, item := range x {
+			s, ok := scalar(item)
+			if !ok {
+				info.addDiag(SeverityError, "allowed-tools entries must be strings")
+				return nil
+			}
+			items = append(items, strings.TrimSpace(s))
+		}
+	default:
+		info.addDiag(SeverityError, "allowed-tools must be a list or a comma-separated string")
+		return nil
+	}
+	var out []string
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		if _, _, err := parseToolRule(item); err != nil {
+			info.addDiag(SeverityError, err.Error())
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// splitToolList 按逗号或空白拆分，但不拆开括号内的内容
+func splitToolList(s string) []string {
+	var out []string
+	var cur strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0 && (r == ',' || r == ' ' || r == '\t' || r == '\n'):
+			if cur.Len() > 0 {
+				out = append(out, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	if cur.Len() > 0 {
+		out = append(out, cur.String())
+	}
+	return out
+}
+
+// parseArguments 接受名称列表、{name, description, required, default} 对象列表，或 name → 描述/对象 的映射
+func parseArguments(info *Info, v interface{}) []Argument {
+	var args []Argument
+	add := func(name string, spec interface{}) {
+		arg := Argument{Name: strings.TrimSpace(name)}
+		switch s := spec.(type) {
+		case nil:
+		case string:
+			arg.Description = s
+		case map[string]interface{}:
+			if n, ok := s["name"]; ok && arg.Name == "" {
+				arg.Name, _ = scalar(n)
+			}
+			arg.Description, _ = scalar(s["description"])
+			arg.Default, _ = scalar(s["default"])
+			arg.Required, _ = s["required"].(bool)
+		default:
+			info.addDiag(SeverityError, fmt.Sprintf("argument %q has an invalid definition", name))
+			return
+		}
+		if !argNameRe.MatchString(arg.Name) {
+			info.addDiag(SeverityError, fmt.Sprintf("invalid argument name %q", arg.Name))
+			return
+		}
+		args = append(args, arg)
+	}
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case string:
+		for _, name := range splitToolList(x) {
+			add(name, nil)
+		}
+	case []interface{}:
+		for _, item := range x {
+			if m, ok := item.(map[string]interface{}); ok {
+				add("", m)
+			} else if s, ok := scalar(item); ok {
+				add(s, nil)
+			} else {
+				info.addDiag(SeverityError, "arguments entries must be names or objects")
+			}
+		}
+	case map[string]interface{}:
+		names := make([]string, 0, len(x))
+		for name := range x {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			add(name, x[name])
+		}
+	default:
+		info.addDiag(SeverityError, "arguments must be a list or a mapping")
+	}
+	return args
+}
+
+var (
+	argNameRe     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_-]*)\s*\}\}`)
+)
+
+// Render 将调用参数代入正文：$ARGUMENTS 替换为原始参数文本，{{name}} 替换为声明参数的值。
+// raw 为字符串时按空白依次填充声明的参数（最后一个参数取剩余全部），为对象时按名称匹配。
+func (i Info) Render(body string, raw interface{}) (string, error) {
+	values := map[string]string{}
+	rawText := ""
+	switch r := raw.(type) {
+	case nil:
+	case string:
+		rawText = strings.TrimSpace(r)
+		fields := strings.Fields(rawText)
+		for idx, arg := range i.Arguments {
+			if idx >= len(fields) {
+				break
+			}
+			if idx == len(i.Arguments)-1 {
+				values[arg.Name] = strings.Join(fields[idx:], " ")
+			} else {
+				values[arg.Name] = fields[idx]
+			}
+		}
+	case map[string]interface{}:
+		var parts []string
+		for _, arg := range i.Arguments {
+			if v, ok := r[arg.Name]; ok {
+				values[arg.Name] = fmt.Sprint(v)
+				parts = append(parts, values[arg.Name])
+			}
+		}
+		for k := range r {
+			if !i.hasArgument(k) {
+				return "", fmt.Errorf("unknown argument %q for skill %s", k, i.Name)
+			}
+		}
+		rawText = strings.Join(parts, " ")
+	default:
+		return "", fmt.Errorf("arguments must be a string or an object")
+	}
+
+	for _, arg := range i.Arguments {
+		if _, ok := values[arg.Name]; ok {
+			continue
+		}
+		if arg.Default != "" {
+			values[arg.Name] = arg.Default
+		} else if arg.Required {
+			return "", fmt.Errorf("missing required argument %q for skill %s", arg.Name, i.Name)
+		}
+	}
+
+	out := strings.ReplaceAll(body, "$ARGUMENTS", rawText)
+	out = placeholderRe.ReplaceAllStringFunc(out, func(m string) string {
+		name := placeholderRe.FindStringSubmatch(m)[1]
+		if !i.hasArgument(name) {
+			return m
+		}
+		return values[name]
+	})
+	return out, nil
+}
+
+func (i Info) hasArgument(name string) bool {
+	for _, arg := range i.Arguments {
+		if arg.Name == name {
+			return true
+		}
+	}
+	return false
+}
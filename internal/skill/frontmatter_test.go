@@ -0,0 +1,172 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFrontmatter(t *testing.T) {
+	t.Run("full frontmatter", func(t *testing.T) {
+		content := "---\n" +
+			"name: review\n" +
+			"description: \"Review code: find bugs\"\n" +
+			"version: 1.2\n" +
+			"when_to_use: >\n  when the user asks for a review\n" +
+			"allowed-tools: Read, Grep, Bash(git:*)\n" +
+			"arguments:\n  - name: target\n    required: true\n  - name: depth\n    default: shallow\n" +
+			"author: someone\n" +
+			"---\nbody here\n"
+		info, body := parse("/x/review/SKILL.md", content)
+		if info.Name != "review" || info.Description != "Review code: find bugs" || info.Version != "1.2" {
+			t.Errorf("got %+v", info)
+		}
+		if info.WhenToUse != "when the user asks for a review" {
+			t.Errorf("when_to_use = %q", info.WhenToUse)
+		}
+		if strings.Join(info.AllowedTools, "|") != "Read|Grep|Bash(git:*)" {
+			t.Errorf("allowed-tools = %v", info.AllowedTools)
+		}
+		if len(info.Arguments) != 2 || !info.Arguments[0].Required || info.Arguments[1].Default != "shallow" {
+			t.Errorf("arguments = %+v", info.Arguments)
+		}
+		if info.Metadata["author"] != "someone" {
+			t.Errorf("metadata = %v", info.Metadata)
+		}
+		if info.Broken() || body != "body here\n" {
+			t.Errorf("broken=%v body=%q diags=%v", info.Broken(), body, info.Diagnostics)
+		}
+	})
+
+	t.Run("allowed-tools as list", func(t *testing.T) {
+		info, _ := parse("/x/a/SKILL.md", "---\nname: a\ndescription: d\nallowed-tools:\n  - read_file\n  - exec_cmd(go test:*)\n---\n")
+		if len(info.AllowedTools) != 2 || info.Broken() {
+			t.Errorf("got %+v", info)
+		}
+	})
+
+	t.Run("invalid yaml is diagnosed", func(t *testing.T) {
+		info, _ := parse("/x/bad/SKILL.md", "---\nname: bad\ndescription: [unclosed\n---\nbody")
+		if !info.Broken() || info.Name != "bad" {
+			t.Errorf("expected broken skill named after dir, got %+v", info)
+		}
+	})
+
+	t.Run("unterminated frontmatter is diagnosed", func(t *testing.T) {
+		info, _ := parse("/x/open/SKILL.md", "---\nname: open\ndescription: d\n")
+		if !info.Broken() {
+			t.Errorf("expected error diagnostic, got %+v", info.Diagnostics)
+		}
+	})
+
+	t.Run("missing frontmatter is a warning", func(t *testing.T) {
+		info, body := parse("/x/plain/SKILL.md", "just text")
+		if info.Broken() || len(info.Diagnostics) == 0 || info.Name != "plain" || body != "just text" {
+			t.Errorf("got %+v body=%q", info, body)
+		}
+	})
+
+	t.Run("CRLF line endings", func(t *testing.T) {
+		info, body := parse("/x/w/SKILL.md", "---\r\nname: win\r\ndescription: d\r\n---\r\nbody")
+		if info.Name != "win" || info.Broken() || body != "body" {
+			t.Errorf("got %+v body=%q", info, body)
+		}
+	})
+}
+
+func TestRender(t *testing.T) {
+	info := Info{Name: "greet", Arguments: []Argument{
+		{Name: "who", Required: true},
+		{Name: "tone", Default: "polite"},
+	}}
+	body := "Greet {{who}} in a {{ tone }} way. Raw: $ARGUMENTS. Keep {{other}}."
+
+	t.Run("positional string", func(t *testing.T) {
+		out, err := info.Render(body, "alice very warm")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "Greet alice in a very warm way. Raw: alice very warm. Keep {{other}}." {
+			t.Errorf("got %q", out)
+		}
+	})
+
+	t.Run("named object with default", func(t *testing.T) {
+		out, err := info.Render(body, map[string]interface{}{"who": "bob"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, "Greet bob in a polite way") {
+			t.Errorf("got %q", out)
+		}
+	})
+
+	t.Run("missing required argument", func(t *testing.T) {
+		if _, err := info.Render(body, nil); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("unknown named argument", func(t *testing.T) {
+		if _, err := info.Render(body, map[string]interface{}{"who": "x", "color": "red"}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestAllows(t *testing.T) {
+	info := Info{AllowedTools: []string{"Read", "grep", "Bash(git:*)", "exec_cmd(go test *)"}}
+	cases := []struct {
+		tool    string
+		command string
+		want    bool
+	}{
+		{"read_file", "", true},
+		{"grep", "", true},
+		{"skill", "", true},
+		{"write_file", "", false},
+		{"exec_cmd", "git status", true},
+		{"exec_cmd", "git", true},
+		{"exec_cmd", "gitx", false},
+		{"exec_cmd", "go test ./...", true},
+		{"exec_cmd", "rm -rf /", false},
+		{"exec_cmd", "git status; rm -rf ~", false},
+		{"exec_cmd", "git log && curl http://x", false},
+		{"exec_cmd", "git log || true", false},
+		{"exec_cmd", "git log | sh", false},
+		{"exec_cmd", "git $(rm -rf ~)", false},
+		{"exec_cmd", "git `rm -rf ~`", false},
+		{"exec_cmd", "git log > ~/.bashrc", false},
+		{"exec_cmd", "git apply < patch", false},
+		{"exec_cmd", "git status\nrm -rf ~", false},
+		{"exec_cmd", "git log --format=%H", true},
+	}
+	for _, c := range cases {
+		args := map[string]interface{}{"command": c.command}
+		if got := info.Allows(c.tool, args); got != c.want {
+			t.Errorf("Allows(%s, %q) = %v, want %v", c.tool, c.command, got, c.want)
+		}
+	}
+	withTools := Info{Name: "deploy", AllowedTools: []string{"Read"}, Tools: []ToolSpec{{Name: "ship"}}}
+	if !withTools.Allows("deploy.ship", nil) {
+		t.Error("the skill's own tools should be allowed")
+	}
+	if withTools.Allows("deploy.other", nil) {
+		t.Error("tools merely prefixed with the skill name (e.g. an MCP server of the same name) must not be allowed")
+	}
+	if !(Info{}).Allows("write_file", nil) {
+		t.Error("skill without allowed-tools should not restrict")
+	}
+}
+
+func TestSkillToolSpecs(t *testing.T) {
+	info, _ := parse("/x/t/SKILL.md", "---\nname: t\ndescription: d\ntools:\n  - name: lint\n    command: ./lint.sh\n    timeout: 30\n    parameters:\n      type: object\n      required: [path]\n  - name: bad name\n    command: x\n  - name: nocmd\n---\n")
+	if len(info.Tools) != 1 || info.Tools[0].Command != "./lint.sh" || info.Tools[0].Timeout != 30 {
+		t.Fatalf("got %+v", info.Tools)
+	}
+	if info.QualifiedName(info.Tools[0]) != "t.lint" {
+		t.Errorf("qualified name = %s", info.QualifiedName(info.Tools[0]))
+	}
+	if len(info.Diagnostics) < 2 {
+		t.Errorf("expected diagnostics for invalid tools, got %v", info.Diagnostics)
+	}
+}
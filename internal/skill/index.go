@@ -0,0 +1,229 @@
+package skill
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const pollInterval = 2 * time.Second
+
+// Index 缓存 LoadInfos 的扫描结果，只有在 skills 目录发生变化（或显式 Reload）后才重新扫描
+type Index struct {
+	rootDir string
+
+	mu    sync.Mutex
+	infos []Info
+	valid bool
+	w     watcher
+}
+
+func NewIndex(rootDir string) *Index {
+	return &Index{rootDir: rootDir}
+}
+
+// List 返回当前 skills，缓存失效时重新扫描
+func (ix *Index) List() []Info {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if !ix.valid {
+		ix.reloadLocked()
+	}
+	return append([]Info(nil), ix.infos...)
+}
+
+// Get 按名称（大小写不敏感）查找 skill，规则与包级 Get 相同
+func (ix *Index) Get(name string) (Info, bool) {
+	if !validName(name) {
+		return Info{}, false
+	}
+	for _, info := range ix.List() {
+		if strings.EqualFold(info.Name, name) {
+			return info, true
+		}
+	}
+	return Info{}, false
+}
+
+// Reload 强制重新扫描
+func (ix *Index) Reload() []Info {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.reloadLocked()
+	return append([]Info(nil), ix.infos...)
+}
+
+// Invalidate 使缓存失效，下次访问时重新扫描
+func (ix *Index) Invalidate() {
+	ix.mu.Lock()
+	ix.valid = false
+	ix.mu.Unlock()
+}
+
+// Watch 监听 skills 目录变化并自动使缓存失效：Linux 使用 inotify，其他平台或 inotify 不可用时轮询
+func (ix *Index) Watch() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.w != nil {
+		return
+	}
+	w, err := newNotifyWatcher()
+	if err != nil {
+		log.Printf("[Skill] inotify 不可用（%v），改用轮询监听", err)
+		w = newPollWatcher(pollInterval)
+	}
+	ix.w = w
+	w.Add(watchDirs(ix.rootDir))
+	go func() {
+		for range w.Events() {
+			ix.Invalidate()
+		}
+	}()
+}
+
+// Close 停止监听
+func (ix *Index) Close() error {
+	ix.mu.Lock()
+	w := ix.w
+	ix.w = nil
+	ix.mu.Unlock()
+	if w == nil {
+		return nil
+	}
+	return w.Close()
+}
+
+func (ix *Index) reloadLocked() {
+	ix.infos = LoadInfos(ix.rootDir)
+	ix.valid = true
+	for _, info := range ix.infos {
+		log.Printf("[Skill] 加载: name=%s description=%.60s", info.Name, info.Description)
+		for _, d := range info.Diagnostics {
+			log.Printf("[Skill] %s %s: %s", d.Severity, info.Location, d.Message)
+		}
+	}
+	log.Printf("[Skill] 共加载 %d 个 skill", len(ix.infos))
+	if ix.w != nil {
+		// 新建的子目录需要重新登记
+		ix.w.Add(watchDirs(ix.rootDir))
+	}
+}
+
+// watchDirs 返回需要监听的目录：已存在的 skills 目录及其全部子目录；
+// 不存在的 skills 目录则监听其最近的已存在上级目录，以便感知目录被创建
+func watchDirs(rootDir string) []string {
+	seen := map[string]bool{}
+	var dirs []string
+	add := func(d string) {
+		if !seen[d] {
+			seen[d] = true
+			dirs = append(dirs, d)
+		}
+	}
+	for _, dir := range SkillDirs(rootDir) {
+		if _, err := os.Stat(dir); err != nil {
+			for parent := filepath.Dir(dir); parent != filepath.Dir(parent); parent = filepath.Dir(parent) {
+				if fi, err := os.Stat(parent); err == nil && fi.IsDir() {
+					add(parent)
+					break
+				}
+			}
+			continue
+		}
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				add(path)
+			}
+			return nil
+		})
+	}
+	return dirs
+}
+
+// watcher 在被监听目录发生任何变化时发送事件
+type watcher interface {
+	Add(dirs []string)
+	Events() <-chan struct{}
+	Close() error
+}
+
+// pollWatcher 定期比较目录内容指纹（路径、大小、修改时间），不读取文件内容
+type pollWatcher struct {
+	mu     sync.Mutex
+	dirs   []string
+	last   string
+	events chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newPollWatcher(interval time.Duration) *pollWatcher {
+	w := &pollWatcher{events: make(chan struct{}, 1), done: make(chan struct{})}
+	go w.loop(interval)
+	return w
+}
+
+func (w *pollWatcher) Add(dirs []string) {
+	fp := fingerprint(dirs)
+	w.mu.Lock()
+	w.dirs, w.last = dirs, fp
+	w.mu.Unlock()
+}
+
+func (w *pollWatcher) Events() <-chan struct{} { return w.events }
+
+func (w *pollWatcher) Close() error {
+	w.once.Do(func() { close(w.done) })
+	return nil
+}
+
+func (w *pollWatcher) loop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer close(w.events)
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+		w.mu.Lock()
+		dirs := w.dirs
+		w.mu.Unlock()
+		fp := fingerprint(dirs)
+		w.mu.Lock()
+		changed := fp != w.last
+		w.last = fp
+		w.mu.Unlock()
+		if changed {
+			select {
+			case w.events <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func fingerprint(dirs []string) string {
+	var sb strings.Builder
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			sb.WriteString(dir + "!\n")
+			continue
+		}
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(&sb, "%s|%d|%d\n", filepath.Join(dir, e.Name()), info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,88 @@
+package skill
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSkill(t *testing.T, root, name, desc string) {
+	t.Helper()
+	dir := filepath.Join(root, ".skills", name)
+	os.MkdirAll(dir, 0755)
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\nname: "+name+"\ndescription: "+desc+"\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("condition not met before deadline")
+}
+
+func TestIndexCaches(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeSkill(t, root, "first", "one")
+
+	ix := NewIndex(root)
+	if len(ix.List()) != 1 {
+		t.Fatalf("expected 1 skill, got %+v", ix.List())
+	}
+
+	writeSkill(t, root, "second", "two")
+	if len(ix.List()) != 1 {
+		t.Error("expected cached result until invalidated")
+	}
+	if _, ok := ix.Get("second"); ok {
+		t.Error("expected cached Get to miss new skill")
+	}
+
+	if got := ix.Reload(); len(got) != 2 {
+		t.Errorf("expected 2 skills after reload, got %+v", got)
+	}
+	if _, ok := ix.Get("../second"); ok {
+		t.Error("expected invalid name to be rejected")
+	}
+}
+
+func TestIndexWatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+
+	ix := NewIndex(root)
+	ix.Watch()
+	defer ix.Close()
+	if len(ix.List()) != 0 {
+		t.Fatal("expected no skills")
+	}
+
+	// .skills 尚不存在，依赖对上级目录的监听
+	writeSkill(t, root, "created", "v1")
+	waitFor(t, func() bool { _, ok := ix.Get("created"); return ok })
+
+	writeSkill(t, root, "created", "v2")
+	waitFor(t, func() bool { info, _ := ix.Get("created"); return info.Description == "v2" })
+}
+
+func TestPollWatcher(t *testing.T) {
+	dir := t.TempDir()
+	w := newPollWatcher(10 * time.Millisecond)
+	defer w.Close()
+	w.Add([]string{dir})
+
+	os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("x"), 0644)
+	select {
+	case <-w.Events():
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected change event")
+	}
+}
@@ -2,18 +2,23 @@ package skill
 
 import (
 	"fmt"
-	"io/fs"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
 type Info struct {
-	Name        string
-	Description string
-	Dir         string
-	Location    string // absolute path to SKILL.md
+	Name         string
+	Description  string
+	Version      string
+	WhenToUse    string
+	AllowedTools []string // 为空表示不限制
+	Arguments    []Argument
+	Tools        []ToolSpec
+	Metadata     map[string]interface{} // 未识别的 frontmatter 键
+	Diagnostics  []Diagnostic
+	Dir          string
+	Location     string // absolute path to SKILL.md
 }
 
 func SkillDirs(rootDir string) []string {
@@ -29,48 +34,48 @@ func SkillDirs(rootDir string) []string {
 	}
 }
 
+// LoadInfos 扫描全部 skills 目录，同名 skill（大小写不敏感）以优先级高的目录为准。
+// 每次调用都会遍历磁盘，常驻进程应使用 Index。
 func LoadInfos(rootDir string) []Info {
-	seen := map[string]Info{}
-	var order []string
+	var result []Info
+	for _, e := range LoadAll(rootDir) {
+		if e.ShadowedBy == "" {
+			result = append(result, e.Info)
+		}
+	}
+	return result
+}
 
-	for _, dir := range SkillDirs(rootDir) {
-		if _, err := os.Stat(dir); err != nil {
-			continue
+// Usable 过滤掉 frontmatter 存在错误的 skill
+func Usable(infos []Info) []Info {
+	out := make([]Info, 0, len(infos))
+	for _, info := range infos {
+		if !info.Broken() {
+			out = append(out, info)
 		}
-		log.Printf("[Skill] 扫描目录: %s", dir)
-		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-			if err != nil || d.IsDir() {
-				return nil
-			}
-			if !strings.EqualFold(d.Name(), "skill.md") {
-				return nil
-			}
-			data, err := os.ReadFile(path)
-			if err != nil {
-				return nil
-			}
-			info := parse(path, string(data))
-			info.Dir = filepath.Dir(path)
-			info.Location = path
-			log.Printf("[Skill] 加载: name=%s description=%.60s", info.Name, info.Description)
-			if _, exists := seen[info.Name]; !exists {
-				order = append(order, info.Name)
-			}
-			seen[info.Name] = info
-			return nil
-		})
 	}
+	return out
+}
 
-	log.Printf("[Skill] 共加载 %d 个 skill", len(order))
-	result := make([]Info, 0, len(order))
-	for _, name := range order {
-		result = append(result, seen[name])
+func validName(name string) bool {
+	return name != "" && name != "." && !strings.ContainsAny(name, "/\\") && !strings.Contains(name, "..")
+}
+
+// Load 读取 skill 的 SKILL.md，返回重新解析的元信息与去掉 frontmatter 的正文
+func Load(info Info) (Info, string, error) {
+	data, err := os.ReadFile(info.Location)
+	if err != nil {
+		return info, "", err
 	}
-	return result
+	parsed, body := parse(info.Location, string(data))
+	parsed.Dir = info.Dir
+	parsed.Location = info.Location
+	loadToolsFile(&parsed)
+	return parsed, body, nil
 }
 
 func Get(rootDir, name string) (Info, bool) {
-	if strings.ContainsAny(name, "/\\") || strings.Contains(name, "..") {
+	if !validName(name) {
 		return Info{}, false
 	}
 	for _, info := range LoadInfos(rootDir) {
@@ -82,7 +87,7 @@ func Get(rootDir, name string) (Info, bool) {
 }
 
 func FindSkill(rootDir, name string) (content, dir string, err error) {
-	if strings.ContainsAny(name, "/\\") || strings.Contains(name, "..") {
+	if !validName(name) {
 		return "", "", fmt.Errorf("invalid skill name: %q", name)
 	}
 	for _, d := range SkillDirs(rootDir) {
@@ -107,30 +112,3 @@ func FindSkill(rootDir, name string) (content, dir string, err error) {
 	}
 	return "", "", fmt.Errorf("skill %q not found", name)
 }
-
-func parse(path, content string) Info {
-	name := filepath.Base(filepath.Dir(path))
-	description := ""
-
-	if !strings.HasPrefix(content, "---") {
-		return Info{Name: name, Description: description}
-	}
-	end := strings.Index(content[3:], "---")
-	if end < 0 {
-		return Info{Name: name, Description: description}
-	}
-	front := content[3 : end+3]
-	for _, line := range strings.Split(front, "\n") {
-		line = strings.TrimSpace(line)
-		if k, v, ok := strings.Cut(line, ":"); ok {
-			v = strings.TrimSpace(v)
-			switch strings.TrimSpace(k) {
-			case "name":
-				name = v
-			case "description":
-				description = v
-			}
-		}
-	}
-	return Info{Name: name, Description: description}
-}
@@ -0,0 +1,346 @@
+package skill
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Entry 是磁盘上的一份 skill。同名 skill 可能存在于多个目录，
+// 只有优先级最高的一份生效，其余的 ShadowedBy 指向生效的那份。
+type Entry struct {
+	Info
+	Source     string // 所在的 skills 目录（SkillDirs 之一）
+	ShadowedBy string // 被覆盖时为生效副本的 SKILL.md 路径
+}
+
+// ProjectDir 和 GlobalDir 是 install 的目标目录
+func ProjectDir(rootDir string) string {
+	return filepath.Join(rootDir, ".openlink", "skills")
+}
+
+func GlobalDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".openlink", "skills")
+}
+
+// LoadAll 返回所有 skills 目录中的全部 skill（包括被覆盖的副本），按优先级排序。
+// LoadInfos 即为其中未被覆盖的部分。
+func LoadAll(rootDir string) []Entry {
+	var entries []Entry
+	winner := map[string]string{}
+	for _, dir := range SkillDirs(rootDir) {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if !strings.EqualFold(d.Name(), "skill.md") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			info, _ := parse(path, string(data))
+			info.Dir = filepath.Dir(path)
+			info.Location = path
+			loadToolsFile(&info)
+			entry := Entry{Info: info, Source: dir}
+			key := strings.ToLower(info.Name)
+			if w, ok := winner[key]; ok {
+				entry.ShadowedBy = w
+			} else {
+				winner[key] = path
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	}
+	return entries
+}
+
+// installNameRe 是 install 接受的 skill 名，skill 名直接用作安装目录名
+var installNameRe = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Install 将 src（包含 SKILL.md 的目录或 zip 文件）复制到 destRoot/<skill 名>。
+// 目标已存在时需要 force 才会覆盖。
+func Install(src, destRoot string, force bool) (Info, error) {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return Info{}, err
+	}
+	if !fi.IsDir() {
+		if !strings.EqualFold(filepath.Ext(src), ".zip") {
+			return Info{}, fmt.Errorf("%s is neither a directory nor a .zip file", src)
+		}
+		tmp, err := os.MkdirTemp("", "openlink-skill-")
+		if err != nil {
+			return Info{}, err
+		}
+		defer os.RemoveAll(tmp)
+		if err := unzip(src, tmp); err != nil {
+			return Info{}, err
+		}
+		src = tmp
+	}
+
+	skillDir, err := findSkillRoot(src)
+	if err != nil {
+		return Info{}, err
+	}
+	info, err := inspect(skillDir)
+	if err != nil {
+		return Info{}, err
+	}
+	if info.Broken() {
+		return info, fmt.Errorf("skill %s is invalid: %s", info.Name, errorText(info.Diagnostics))
+	}
+
+	if !installNameRe.MatchString(info.Name) || info.Name == "." {
+		return info, fmt.Errorf("skill name %q cannot be used as a directory name; use letters, digits, '.', '_' or '-'", info.Name)
+	}
+	dest := filepath.Join(destRoot, info.Name)
+	// 覆盖前确认目标就是 destRoot 下的一级目录，避免 RemoveAll 删除 destRoot 本身或其他目录
+	if filepath.Dir(dest) != filepath.Clean(destRoot) {
+		return info, fmt.Errorf("refusing to install skill %q outside %s", info.Name, destRoot)
+	}
+	if _, err := os.Stat(dest); err == nil {
+		if !force {
+			return info, fmt.Errorf("%s already exists (use --force to overwrite)", dest)
+		}
+		if err := os.RemoveAll(dest); err != nil {
+			return info, err
+		}
+	}
+	if err := copyDir(skillDir, dest); err != nil {
+		return info, err
+	}
+	return inspect(dest)
+}
+
+// Remove 删除当前生效的名为 name 的 skill 目录，返回被删除的目录。
+// 直接放在 skills 目录下的 SKILL.md 不会被删除，以免误删整个 skills 目录。
+func Remove(rootDir, name string) (string, error) {
+	for _, e := range LoadAll(rootDir) {
+		if e.ShadowedBy != "" || !strings.EqualFold(e.Name, name) {
+			continue
+		}
+		if filepath.Clean(e.Dir) == filepath.Clean(e.Source) {
+			return "", fmt.Errorf("skill %s lives directly in %s; remove %s by hand", e.Name, e.Source, e.Location)
+		}
+		return e.Dir, os.RemoveAll(e.Dir)
+	}
+	return "", fmt.Errorf("skill %q not found", name)
+}
+
+// Inspect 解析目录中的 SKILL.md（不要求目录位于 skills 目录中）
+func Inspect(dir string) (Info, error) {
+	root, err := findSkillRoot(dir)
+	if err != nil {
+		return Info{}, err
+	}
+	return inspect(root)
+}
+
+func inspect(dir string) (Info, error) {
+	path := filepath.Join(dir, "SKILL.md")
+	if _, err := os.Stat(path); err != nil {
+		path = filepath.Join(dir, "skill.md")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Info{}, err
+	}
+	info, _ := parse(path, string(data))
+	info.Dir = dir
+	info.Location = path
+	loadToolsFile(&info)
+	return info, nil
+}
+
+// linkRe 匹配 Markdown 链接目标
+var linkRe = regexp.MustCompile(`\]\(([^)\s]+)\)`)
+
+// Validate 在 frontmatter 诊断的基础上检查正文链接和工具命令引用的相对路径文件是否存在
+func Validate(info Info) []Diagnostic {
+	diags := append([]Diagnostic(nil), info.Diagnostics...)
+	_, body, err := Load(info)
+	if err != nil {
+		return append(diags, Diagnostic{Severity: SeverityError, Message: err.Error()})
+	}
+	missing := func(kind, ref string) {
+		diags = append(diags, Diagnostic{Severity: SeverityError, Message: fmt.Sprintf("%s references missing file %s", kind, ref)})
+	}
+	for _, m := range linkRe.FindAllStringSubmatch(body, -1) {
+		ref := strings.SplitN(m[1], "#", 2)[0]
+		if ref == "" || strings.Contains(ref, "://") || strings.HasPrefix(ref, "mailto:") || filepath.IsAbs(ref) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(info.Dir, ref)); err != nil {
+			missing("link", ref)
+		}
+	}
+	for _, spec := range info.Tools {
+		exe := ""
+		if len(spec.Argv) > 0 {
+			exe = spec.Argv[0]
+		} else if fields := strings.Fields(spec.Command); len(fields) > 0 {
+			exe = fields[0]
+		}
+		if exe == "" || filepath.IsAbs(exe) || !strings.ContainsAny(exe, `/\`) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(info.Dir, exe)); err != nil {
+			missing("tool "+spec.Name, exe)
+		}
+	}
+	return diags
+}
+
+// findSkillRoot 返回包含 SKILL.md 的目录：dir 本身，或其唯一的子目录（常见于 zip 包）
+func findSkillRoot(dir string) (string, error) {
+	if hasSkillFile(dir) {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var subdirs []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && e.Name() != "__MACOSX" {
+			subdirs = append(subdirs, filepath.Join(dir, e.Name()))
+		}
+	}
+	if len(subdirs) == 1 && hasSkillFile(subdirs[0]) {
+		return subdirs[0], nil
+	}
+	return "", fmt.Errorf("no SKILL.md found in %s", dir)
+}
+
+func hasSkillFile(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.EqualFold(e.Name(), "skill.md") {
+			return true
+		}
+	}
+	return false
+}
+
+func copyDir(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			// 不复制符号链接等特殊文件，避免把 skill 目录之外的内容带进来
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return copyFile(path, target, fi.Mode().Perm())
+	})
+}
+
+func copyFile(src, dest string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func unzip(src, dest string) error {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		target := filepath.Join(dest, f.Name)
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal path in zip: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := extractFile(f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, target string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	perm := f.Mode().Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func errorText(diags []Diagnostic) string {
+	var parts []string
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			parts = append(parts, d.Message)
+		}
+	}
+	if len(parts) == 0 {
+		return "unknown error"
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,169 @@
+package skill
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSkillFile(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadAllShadowing(t *testing.T) {
+	root := t.TempDir()
+	writeSkillFile(t, filepath.Join(root, ".skills", "dup"), "---\nname: dup\ndescription: first\n---\n")
+	writeSkillFile(t, filepath.Join(root, ".claude", "skills", "dup"), "---\nname: Dup\ndescription: second\n---\n")
+
+	entries := LoadAll(root)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].ShadowedBy != "" || entries[1].ShadowedBy != entries[0].Location {
+		t.Errorf("unexpected shadowing: %+v", entries)
+	}
+	infos := LoadInfos(root)
+	if len(infos) != 1 || infos[0].Description != "first" {
+		t.Errorf("LoadInfos should keep the highest-priority copy, got %+v", infos)
+	}
+	if info, ok := Get(root, "dup"); !ok || info.Description != "first" {
+		t.Errorf("Get disagrees with LoadInfos: %+v", info)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(t.TempDir(), "pkg")
+	writeSkillFile(t, src, "---\nname: deploy\ndescription: ship\n---\nsee [notes](notes.md)\n")
+	os.WriteFile(filepath.Join(src, "notes.md"), []byte("notes"), 0644)
+
+	t.Run("from directory", func(t *testing.T) {
+		info, err := Install(src, ProjectDir(root), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Dir != filepath.Join(ProjectDir(root), "deploy") {
+			t.Errorf("installed to %s", info.Dir)
+		}
+		if _, err := os.Stat(filepath.Join(info.Dir, "notes.md")); err != nil {
+			t.Error("sibling files should be copied")
+		}
+		if diags := Validate(info); len(diags) != 0 {
+			t.Errorf("unexpected diagnostics: %v", diags)
+		}
+	})
+
+	t.Run("refuses to overwrite without force", func(t *testing.T) {
+		if _, err := Install(src, ProjectDir(root), false); err == nil {
+			t.Error("expected error")
+		}
+		if _, err := Install(src, ProjectDir(root), true); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("from zip with top-level directory", func(t *testing.T) {
+		zipPath := filepath.Join(t.TempDir(), "s.zip")
+		f, _ := os.Create(zipPath)
+		zw := zip.NewWriter(f)
+		w, _ := zw.Create("zipped/SKILL.md")
+		w.Write([]byte("---\nname: zipped\ndescription: from zip\n---\n"))
+		zw.Close()
+		f.Close()
+		info, err := Install(zipPath, ProjectDir(root), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Name != "zipped" {
+			t.Errorf("got %+v", info)
+		}
+	})
+
+	t.Run("zip slip rejected", func(t *testing.T) {
+		zipPath := filepath.Join(t.TempDir(), "evil.zip")
+		f, _ := os.Create(zipPath)
+		zw := zip.NewWriter(f)
+		w, _ := zw.Create("../evil/SKILL.md")
+		w.Write([]byte("x"))
+		zw.Close()
+		f.Close()
+		if _, err := Install(zipPath, ProjectDir(root), false); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("invalid skill rejected", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "bad")
+		writeSkillFile(t, bad, "---\nname: [x\n---\n")
+		if _, err := Install(bad, ProjectDir(root), false); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("names that are not a single directory are rejected", func(t *testing.T) {
+		for _, name := range []string{".", `""`, "my skill", "a:b"} {
+			bad := filepath.Join(t.TempDir(), "bad")
+			writeSkillFile(t, bad, "---\nname: "+name+"\ndescription: d\n---\n")
+			if _, err := Install(bad, ProjectDir(root), true); err == nil {
+				t.Errorf("expected name %s to be rejected", name)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(ProjectDir(root), "deploy", "SKILL.md")); err != nil {
+			t.Errorf("installed skills must survive: %v", err)
+		}
+	})
+}
+
+func TestRemoveAndValidate(t *testing.T) {
+	root := t.TempDir()
+	writeSkillFile(t, filepath.Join(root, ".skills", "gone"), "---\nname: gone\ndescription: d\n---\n[missing](ref.md) [web](https://example.com)\n")
+
+	info, ok := Get(root, "gone")
+	if !ok {
+		t.Fatal("skill not found")
+	}
+	diags := Validate(info)
+	if len(diags) != 1 || diags[0].Severity != SeverityError {
+		t.Errorf("expected one missing-file error, got %v", diags)
+	}
+
+	dir, err := Remove(root, "gone")
+	if err != nil || dir != info.Dir {
+		t.Fatalf("remove: %s %v", dir, err)
+	}
+	if _, ok := Get(root, "gone"); ok {
+		t.Error("skill should be gone")
+	}
+	if _, err := Remove(root, "gone"); err == nil {
+		t.Error("expected not found")
+	}
+}
+
+// 工作目录中的 skill 优先于用户主目录中的同名 skill，与 README 中的目录顺序一致
+func TestProjectSkillsOverrideHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := t.TempDir()
+	writeSkillFile(t, filepath.Join(root, ".skills", "deploy"), "---\nname: deploy\ndescription: project\n---\n")
+	writeSkillFile(t, filepath.Join(home, ".claude", "skills", "deploy"), "---\nname: deploy\ndescription: home\n---\n")
+
+	infos := LoadInfos(root)
+	if len(infos) != 1 || infos[0].Description != "project" {
+		t.Fatalf("expected the project copy to win, got %+v", infos)
+	}
+	entries := LoadAll(root)
+	if len(entries) != 2 || entries[1].Description != "home" || entries[1].ShadowedBy != infos[0].Location {
+		t.Errorf("expected the home copy to be shadowed by the project copy, got %+v", entries)
+	}
+	if content, _, err := FindSkill(root, "deploy"); err != nil || !strings.Contains(content, "project") {
+		t.Errorf("FindSkill disagrees with LoadInfos: %q %v", content, err)
+	}
+}
@@ -0,0 +1,111 @@
+package skill
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// ToolSpec 是 skill 随附的可执行工具，来自 frontmatter 的 tools 字段或 skill 目录下的 tools.json。
+// 注册到 Registry 时名称为 "<skill>.<name>"，以 skill 目录为工作目录执行。
+type ToolSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Command     string                 `json:"command,omitempty"` // 通过 shell 执行
+	Argv        []string               `json:"argv,omitempty"`    // 直接执行
+	Timeout     int                    `json:"timeout,omitempty"` // 秒
+}
+
+const toolsFile = "tools.json"
+
+var toolNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// QualifiedName 返回注册到 Registry 的工具名
+func (i Info) QualifiedName(spec ToolSpec) string {
+	return i.Name + "." + spec.Name
+}
+
+type rawToolSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+	Schema      map[string]interface{} `json:"schema"`
+	Command     json.RawMessage        `json:"command"`
+	Timeout     int                    `json:"timeout"`
+}
+
+// parseTools 解析 tools 定义（数组，或 {"tools": [...]}），YAML 解析结果先转成 JSON 再统一处理。
+// 无效的工具只记录 warning 并跳过，不影响 skill 本身。
+func parseTools(info *Info, v interface{}, source string) []ToolSpec {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		info.addDiag(SeverityWarning, fmt.Sprintf("%s: invalid tools definition: %v", source, err))
+		return nil
+	}
+	return decodeTools(info, data, source)
+}
+
+func decodeTools(info *Info, data []byte, source string) []ToolSpec {
+	var raws []rawToolSpec
+	if err := json.Unmarshal(data, &raws); err != nil {
+		var wrapped struct {
+			Tools []rawToolSpec `json:"tools"`
+		}
+		if err2 := json.Unmarshal(data, &wrapped); err2 != nil {
+			info.addDiag(SeverityWarning, fmt.Sprintf("%s: tools must be a list: %v", source, err))
+			return nil
+		}
+		raws = wrapped.Tools
+	}
+	var specs []ToolSpec
+	for _, raw := range raws {
+		spec := ToolSpec{Name: raw.Name, Description: raw.Description, Parameters: raw.Parameters, Timeout: raw.Timeout}
+		if spec.Parameters == nil {
+			spec.Parameters = raw.Schema
+		}
+		if !toolNameRe.MatchString(spec.Name) {
+			info.addDiag(SeverityWarning, fmt.Sprintf("%s: invalid tool name %q", source, spec.Name))
+			continue
+		}
+		if len(raw.Command) > 0 && json.Unmarshal(raw.Command, &spec.Command) != nil {
+			if json.Unmarshal(raw.Command, &spec.Argv) != nil {
+				info.addDiag(SeverityWarning, fmt.Sprintf("%s: tool %s: command must be a string or a list", source, spec.Name))
+				continue
+			}
+		}
+		if spec.Command == "" && len(spec.Argv) == 0 {
+			info.addDiag(SeverityWarning, fmt.Sprintf("%s: tool %s has no command", source, spec.Name))
+			continue
+		}
+		if spec.Description == "" {
+			spec.Description = fmt.Sprintf("Tool %s provided by skill %s", spec.Name, info.Name)
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// loadToolsFile 合并 skill 目录下 tools.json 中的工具定义，同名时 frontmatter 优先
+func loadToolsFile(info *Info) {
+	path := filepath.Join(info.Dir, toolsFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	seen := map[string]bool{}
+	for _, spec := range info.Tools {
+		seen[spec.Name] = true
+	}
+	for _, spec := range decodeTools(info, data, toolsFile) {
+		if !seen[spec.Name] {
+			seen[spec.Name] = true
+			info.Tools = append(info.Tools, spec)
+		}
+	}
+}
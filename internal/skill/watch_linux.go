@@ -0,0 +1,80 @@
+//go:build linux
+
+package skill
+
+import (
+	"os"
+	"sync"
+	"syscall"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_MOVED_FROM |
+	syscall.IN_MOVED_TO | syscall.IN_CLOSE_WRITE | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF | syscall.IN_ATTRIB
+
+// notifyWatcher 基于 inotify；事件内容不做解析，任意变化都视为缓存失效
+type notifyWatcher struct {
+	fd     int
+	file   *os.File
+	mu     sync.Mutex
+	wds    map[string]int
+	events chan struct{}
+}
+
+func newNotifyWatcher() (watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	w := &notifyWatcher{
+		fd: fd,
+		// 非阻塞 fd 交给 runtime poller，Close 时可打断阻塞中的 Read
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		wds:    make(map[string]int),
+		events: make(chan struct{}, 1),
+	}
+	go w.loop()
+	return w, nil
+}
+
+func (w *notifyWatcher) Add(dirs []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	current := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		current[dir] = true
+		// 重复登记同一 inode 返回同一个 wd；目录被删除重建后会得到新的 wd
+		wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+		if err == nil {
+			w.wds[dir] = wd
+		}
+	}
+	for dir, wd := range w.wds {
+		if !current[dir] {
+			syscall.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.wds, dir)
+		}
+	}
+}
+
+func (w *notifyWatcher) Events() <-chan struct{} { return w.events }
+
+func (w *notifyWatcher) Close() error {
+	return w.file.Close()
+}
+
+func (w *notifyWatcher) loop() {
+	defer close(w.events)
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+		if n > 0 {
+			select {
+			case w.events <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package skill
+
+import "errors"
+
+func newNotifyWatcher() (watcher, error) {
+	return nil, errors.New("inotify is only available on linux")
+}
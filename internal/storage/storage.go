@@ -0,0 +1,201 @@
+// Package storage 按保留期限和总大小清理 ~/.openlink 下不断增长的数据（截断输出、运行记录等），
+// 并把文件权限收紧为仅所有者可读写。
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+// Store 是一类需要定期清理的数据目录
+type Store struct {
+	Name     string
+	Dir      string
+	MaxAge   time.Duration // 0 表示不按时间清理
+	MaxBytes int64         // 0 表示不限制总大小
+}
+
+// Report 是一次清理的结果；Files 和 Bytes 为清理后剩余的文件数和大小
+type Report struct {
+	Name    string `json:"name"`
+	Dir     string `json:"dir"`
+	Files   int    `json:"files"`
+	Bytes   int64  `json:"bytes"`
+	Removed int    `json:"removed"`
+	Freed   int64  `json:"freed"`
+	Fixed   int    `json:"fixed"` // 收紧权限的文件数
+}
+
+type entry struct {
+	path string
+	size int64
+	mod  time.Time
+}
+
+// WithPolicy 用 settings.json 中按名称配置的保留策略覆盖 stores 的默认值
+func WithPolicy(stores []Store, retention map[string]types.RetentionPolicy) []Store {
+	out := make([]Store, len(stores))
+	for i, s := range stores {
+		if p, ok := retention[s.Name]; ok {
+			switch {
+			case p.MaxAgeDays > 0:
+				s.MaxAge = time.Duration(p.MaxAgeDays) * 24 * time.Hour
+			case p.MaxAgeDays < 0:
+				s.MaxAge = 0
+			}
+			switch {
+			case p.MaxSizeMB > 0:
+				s.MaxBytes = int64(p.MaxSizeMB) << 20
+			case p.MaxSizeMB < 0:
+				s.MaxBytes = 0
+			}
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// Prune 删除超过 MaxAge 的文件，再从最旧的文件开始删除直到总大小不超过 MaxBytes，
+// 并把文件权限改为 0600、目录权限改为 0700。dryRun 为 true 时只统计不修改。目录不存在时返回空结果。
+func (s Store) Prune(now time.Time, dryRun bool) (Report, error) {
+	report := Report{Name: s.Name, Dir: s.Dir}
+	var entries []entry
+	err := filepath.WalkDir(s.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if info, err := d.Info(); err == nil && info.Mode().Perm()&0077 != 0 && !dryRun {
+				os.Chmod(path, 0700)
+			}
+			return nil
+		}
+		// 不跟随符号链接，也不删除链接指向的文件
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.Mode().Perm()&0077 != 0 {
+			report.Fixed++
+			if !dryRun {
+				os.Chmod(path, 0600)
+			}
+		}
+		entries = append(entries, entry{path: path, size: info.Size(), mod: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].mod.Before(entries[j].mod) })
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	var firstErr error
+	for _, e := range entries {
+		expired := s.MaxAge > 0 && now.Sub(e.mod) > s.MaxAge
+		oversize := s.MaxBytes > 0 && total > s.MaxBytes
+		if !expired && !oversize {
+			report.Files++
+			report.Bytes += e.size
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(e.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				if firstErr == nil {
+					firstErr = err
+				}
+				report.Files++
+				report.Bytes += e.size
+				continue
+			}
+		}
+		total -= e.size
+		report.Removed++
+		report.Freed += e.size
+	}
+	if !dryRun && report.Removed > 0 {
+		removeEmptyDirs(s.Dir)
+	}
+	return report, firstErr
+}
+
+// removeEmptyDirs 删除 root 下清理后留下的空子目录（不删除 root 本身）
+func removeEmptyDirs(root string) {
+	var dirs []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	// 由深到浅删除，父目录在子目录删除后才可能变空
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+}
+
+// PruneAll 依次清理所有 stores，出错的 store 记录日志后继续
+func PruneAll(stores []Store, dryRun bool) []Report {
+	reports := make([]Report, 0, len(stores))
+	now := time.Now()
+	for _, s := range stores {
+		report, err := s.Prune(now, dryRun)
+		if err != nil {
+			log.Printf("[Storage] 清理 %s 出错: %v\n", s.Name, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// Run 立即清理一次，之后每隔 interval 清理一次，直到 ctx 取消
+func Run(ctx context.Context, stores []Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, r := range PruneAll(stores, false) {
+			if r.Removed > 0 || r.Fixed > 0 {
+				log.Printf("[Storage] %s: 删除 %d 个文件（%s），收紧 %d 个文件权限\n", r.Name, r.Removed, FormatSize(r.Freed), r.Fixed)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FormatSize 以 B、KB、MB、GB 显示字节数
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	v := float64(n) / unit
+	for _, u := range []string{"KB", "MB", "GB"} {
+		if v < unit {
+			return fmt.Sprintf("%.1f%s", v, u)
+		}
+		v /= unit
+	}
+	return fmt.Sprintf("%.1fTB", v)
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+func writeAged(t *testing.T, path string, size int, age time.Duration, perm os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), perm); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(path, perm)
+	mod := time.Now().Add(-age)
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestPruneMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old")
+	nested := filepath.Join(dir, "sub", "old")
+	fresh := filepath.Join(dir, "fresh")
+	writeAged(t, old, 10, 10*24*time.Hour, 0600)
+	writeAged(t, nested, 10, 10*24*time.Hour, 0600)
+	writeAged(t, fresh, 10, time.Hour, 0644)
+
+	s := Store{Name: "test", Dir: dir, MaxAge: 7 * 24 * time.Hour}
+	r, err := s.Prune(time.Now(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Removed != 2 || r.Files != 1 || r.Fixed != 1 || !exists(old) {
+		t.Fatalf("dry run: %+v", r)
+	}
+
+	r, err = s.Prune(time.Now(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Removed != 2 || r.Freed != 20 || r.Files != 1 || r.Bytes != 10 {
+		t.Errorf("report: %+v", r)
+	}
+	if exists(old) || exists(nested) || !exists(fresh) {
+		t.Error("expected only expired files removed")
+	}
+	if exists(filepath.Join(dir, "sub")) {
+		t.Error("expected empty subdirectory removed")
+	}
+	if info, _ := os.Stat(fresh); info.Mode().Perm() != 0600 {
+		t.Errorf("expected 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestPruneMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+	for i, name := range []string{"a", "b", "c", "d"} {
+		writeAged(t, filepath.Join(dir, name), 100, time.Duration(4-i)*time.Hour, 0600)
+	}
+	s := Store{Name: "test", Dir: dir, MaxBytes: 250}
+	r, err := s.Prune(time.Now(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Removed != 2 || r.Bytes != 200 {
+		t.Errorf("report: %+v", r)
+	}
+	if exists(filepath.Join(dir, "a")) || exists(filepath.Join(dir, "b")) || !exists(filepath.Join(dir, "d")) {
+		t.Error("expected oldest files removed first")
+	}
+}
+
+func TestPruneSkipsSymlinksAndMissingDir(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(t.TempDir(), "target")
+	writeAged(t, target, 10, 100*24*time.Hour, 0644)
+	if err := os.Symlink(target, filepath.Join(dir, "link")); err != nil {
+		t.Skip(err)
+	}
+	s := Store{Name: "test", Dir: dir, MaxAge: time.Hour}
+	if r, err := s.Prune(time.Now(), false); err != nil || r.Removed != 0 {
+		t.Errorf("got %+v, %v", r, err)
+	}
+	if !exists(target) {
+		t.Error("symlink target must not be removed")
+	}
+
+	s.Dir = filepath.Join(dir, "missing")
+	if r, err := s.Prune(time.Now(), false); err != nil || r.Files != 0 {
+		t.Errorf("missing dir: %+v, %v", r, err)
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	stores := []Store{
+		{Name: "a", MaxAge: time.Hour, MaxBytes: 10},
+		{Name: "b", MaxAge: time.Hour, MaxBytes: 10},
+	}
+	got := WithPolicy(stores, map[string]types.RetentionPolicy{
+		"a": {MaxAgeDays: 2, MaxSizeMB: -1},
+	})
+	if got[0].MaxAge != 48*time.Hour || got[0].MaxBytes != 0 {
+		t.Errorf("a: %+v", got[0])
+	}
+	if got[1] != stores[1] {
+		t.Errorf("b should keep defaults: %+v", got[1])
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{0: "0B", 1023: "1023B", 1536: "1.5KB", 5 << 20: "5.0MB"} {
+		if got := FormatSize(n); got != want {
+			t.Errorf("FormatSize(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
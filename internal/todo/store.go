@@ -0,0 +1,232 @@
+package todo
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+)
+
+type Item struct {
+	ID       string `json:"id"`
+	Content  string `json:"content"`
+	Status   string `json:"status"`
+	Priority string `json:"priority"`
+}
+
+// Store 按会话保存待办列表，每个会话一个 JSON 文件，避免多个对话共享工作区时互相覆盖
+type Store struct {
+	dir   string
+	mu    sync.Mutex
+	lists map[string][]Item
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir, lists: make(map[string][]Item)}
+}
+
+// DefaultDir 返回 ~/.openlink/todos/<工作区哈希>
+func DefaultDir(rootDir string) string {
+	home, _ := os.UserHomeDir()
+	abs, err := filepath.Abs(rootDir)
+	if err != nil {
+		abs = rootDir
+	}
+	sum := sha1.Sum([]byte(abs))
+	return filepath.Join(home, ".openlink", "todos", hex.EncodeToString(sum[:])[:12])
+}
+
+func (s *Store) Get(session string) ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items, err := s.load(session)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Item(nil), items...), nil
+}
+
+// Replace 用 items 整体替换会话的列表；缺少 id 的条目按顺序自动编号
+func (s *Store) Replace(session string, items []Item) ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := make([]Item, 0, len(items))
+	for _, it := range items {
+		next = append(next, withDefaults(it))
+	}
+	assignIDs(next)
+	if err := Validate(next); err != nil {
+		return nil, err
+	}
+	return next, s.save(session, next)
+}
+
+// Merge 按 id 更新已有条目（只覆盖非空字段），未知 id 的条目追加到末尾
+func (s *Store) Merge(session string, updates []Item) ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, err := s.load(session)
+	if err != nil {
+		return nil, err
+	}
+	next := append([]Item(nil), current...)
+	index := make(map[string]int, len(next))
+	for i, it := range next {
+		index[it.ID] = i
+	}
+	var added []Item
+	for _, u := range updates {
+		if i, ok := index[u.ID]; ok && u.ID != "" {
+			if u.Content != "" {
+				next[i].Content = u.Content
+			}
+			if u.Status != "" {
+				next[i].Status = u.Status
+			}
+			if u.Priority != "" {
+				next[i].Priority = u.Priority
+			}
+			continue
+		}
+		added = append(added, withDefaults(u))
+	}
+	next = append(next, added...)
+	assignIDs(next)
+	if err := Validate(next); err != nil {
+		return nil, err
+	}
+	return next, s.save(session, next)
+}
+
+// Validate 校验条目是否符合 schema：id 唯一、content 非空、status/priority 取值合法
+func Validate(items []Item) error {
+	seen := make(map[string]bool, len(items))
+	for i, it := range items {
+		if it.ID == "" {
+			return fmt.Errorf("todo #%d: id is required", i+1)
+		}
+		if seen[it.ID] {
+			return fmt.Errorf("todo #%d: duplicate id %q", i+1, it.ID)
+		}
+		seen[it.ID] = true
+		if strings.TrimSpace(it.Content) == "" {
+			return fmt.Errorf("todo %q: content is required", it.ID)
+		}
+		switch it.Status {
+		case StatusPending, StatusInProgress, StatusCompleted:
+		default:
+			return fmt.Errorf("todo %q: invalid status %q (pending/in_progress/completed)", it.ID, it.Status)
+		}
+		switch it.Priority {
+		case PriorityHigh, PriorityMedium, PriorityLow:
+		default:
+			return fmt.Errorf("todo %q: invalid priority %q (high/medium/low)", it.ID, it.Priority)
+		}
+	}
+	return nil
+}
+
+func withDefaults(it Item) Item {
+	if it.Status == "" {
+		it.Status = StatusPending
+	}
+	if it.Priority == "" {
+		it.Priority = PriorityMedium
+	}
+	return it
+}
+
+// assignIDs 为缺少 id 的条目分配当前最大数字 id 之后的编号
+func assignIDs(items []Item) {
+	max := 0
+	for _, it := range items {
+		if n, err := strconv.Atoi(it.ID); err == nil && n > max {
+			max = n
+		}
+	}
+	for i := range items {
+		if items[i].ID == "" {
+			max++
+			items[i].ID = strconv.Itoa(max)
+		}
+	}
+}
+
+func (s *Store) path(session string) string {
+	return filepath.Join(s.dir, types.NormalizeSession(session)+".json")
+}
+
+func (s *Store) load(session string) ([]Item, error) {
+	session = types.NormalizeSession(session)
+	if items, ok := s.lists[session]; ok {
+		return items, nil
+	}
+	data, err := os.ReadFile(s.path(session))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("corrupt todo file: %w", err)
+	}
+	s.lists[session] = items
+	return items, nil
+}
+
+func (s *Store) save(session string, items []Item) error {
+	session = types.NormalizeSession(session)
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path(session), data, 0600); err != nil {
+		return err
+	}
+	s.lists[session] = items
+	return nil
+}
+
+// Render 将列表渲染为带状态标记的文本，供工具结果展示
+func Render(items []Item) string {
+	if len(items) == 0 {
+		return "暂无任务"
+	}
+	var sb strings.Builder
+	done := 0
+	for _, it := range items {
+		mark := "[ ]"
+		switch it.Status {
+		case StatusInProgress:
+			mark = "[~]"
+		case StatusCompleted:
+			mark = "[x]"
+			done++
+		}
+		fmt.Fprintf(&sb, "%s %s. %s (%s)\n", mark, it.ID, it.Content, it.Priority)
+	}
+	fmt.Fprintf(&sb, "进度: %d/%d 已完成", done, len(items))
+	return sb.String()
+}
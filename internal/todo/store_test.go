@@ -0,0 +1,61 @@
+package todo
+
+import (
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("replace assigns ids and defaults", func(t *testing.T) {
+		s := NewStore(dir)
+		items, err := s.Replace("a", []Item{{Content: "one"}, {ID: "7", Content: "seven", Priority: PriorityHigh}, {Content: "eight"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if items[0].ID != "8" || items[2].ID != "9" || items[0].Status != StatusPending || items[0].Priority != PriorityMedium {
+			t.Errorf("got %+v", items)
+		}
+	})
+
+	t.Run("persists across stores", func(t *testing.T) {
+		items, err := NewStore(dir).Get("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 3 {
+			t.Errorf("expected 3 items, got %+v", items)
+		}
+	})
+
+	t.Run("merge updates fields and appends", func(t *testing.T) {
+		s := NewStore(dir)
+		items, err := s.Merge("a", []Item{{ID: "7", Status: StatusCompleted}, {Content: "new"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 4 || items[1].Status != StatusCompleted || items[1].Content != "seven" || items[3].ID != "10" {
+			t.Errorf("got %+v", items)
+		}
+	})
+
+	t.Run("invalid merge leaves list untouched", func(t *testing.T) {
+		s := NewStore(dir)
+		if _, err := s.Merge("a", []Item{{ID: "7", Status: "done"}}); err == nil {
+			t.Fatal("expected validation error")
+		}
+		items, _ := s.Get("a")
+		if items[1].Status != StatusCompleted {
+			t.Errorf("list modified by failed merge: %+v", items)
+		}
+	})
+
+	t.Run("validate rejects duplicates and empty content", func(t *testing.T) {
+		if err := Validate([]Item{{ID: "1", Content: "x", Status: StatusPending, Priority: PriorityLow}, {ID: "1", Content: "y", Status: StatusPending, Priority: PriorityLow}}); err == nil {
+			t.Error("expected duplicate id error")
+		}
+		if err := Validate([]Item{{ID: "1", Status: StatusPending, Priority: PriorityLow}}); err == nil {
+			t.Error("expected empty content error")
+		}
+	})
+}
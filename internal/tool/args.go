@@ -0,0 +1,60 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 扩展通过 XML <parameter> 传参时所有值都是字符串，JSON 调用则是原生类型，
+// 以下辅助函数统一两种形式。
+
+func argInt(args map[string]interface{}, key string) (int, bool) {
+	switch v := args[key].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func argBool(args map[string]interface{}, key string) (bool, bool) {
+	switch v := args[key].(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		return b, err == nil
+	}
+	return false, false
+}
+
+// argStrings accepts a JSON array, a JSON-encoded array string or a single string.
+func argStrings(args map[string]interface{}, key string) []string {
+	switch v := args[key].(type) {
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			out = append(out, fmt.Sprint(item))
+		}
+		return out
+	case []string:
+		return v
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return nil
+		}
+		var list []interface{}
+		if strings.HasPrefix(s, "[") && json.Unmarshal([]byte(s), &list) == nil {
+			return argStrings(map[string]interface{}{key: list}, key)
+		}
+		return []string{s}
+	}
+	return nil
+}
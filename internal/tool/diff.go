@@ -0,0 +1,41 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/afumu/openlink/internal/diff"
+	"github.com/afumu/openlink/internal/types"
+)
+
+// fileDiff 比较文件修改前后的内容，返回修改摘要加带行号的统一差异，以及供扩展渲染的结构化差异；
+// 差异按工具的截断配置裁剪，并在 ctx 提供脱敏函数时脱敏
+func fileDiff(ctx *Context, name, path, before, after string, created bool, notes ...string) (string, *types.FileDiff) {
+	d := diff.Compute(path, before, after)
+	d.Created = created
+	if !created && len(d.Hunks) == 0 && len(d.Notes) == 0 {
+		return fmt.Sprintf("%s 内容未变化", path), d
+	}
+	limit := LimitFor(ctx.Config, name)
+	diff.Cap(d, limit.MaxLines, limit.MaxBytes)
+	// 统一差异中每行带有行号前缀，按行首匹配的检测无法识别，因此渲染前逐行脱敏
+	if ctx.Redact != nil {
+		for i := range d.Hunks {
+			for j := range d.Hunks[i].Lines {
+				d.Hunks[i].Lines[j].Text = ctx.Redact(d.Hunks[i].Lines[j].Text)
+			}
+		}
+	}
+	summary := fmt.Sprintf("已修改 %s（+%d -%d%s）", path, d.Added, d.Removed, joinNotes(notes))
+	if created {
+		summary = fmt.Sprintf("已创建 %s（+%d%s）", path, d.Added, joinNotes(notes))
+	}
+	return summary + "\n" + diff.Unified(d), d
+}
+
+func joinNotes(notes []string) string {
+	if len(notes) == 0 {
+		return ""
+	}
+	return "，" + strings.Join(notes, "，")
+}
@@ -1,11 +1,9 @@
 package tool
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
-	"log"
-	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,14 +19,19 @@ func NewEditTool(config *types.Config) *EditTool {
 	return &EditTool{config: config}
 }
 
-func (t *EditTool) Name() string        { return "edit" }
-func (t *EditTool) Description() string { return "Replace a string in a file (exact match)" }
+func (t *EditTool) Name() string { return "edit" }
+func (t *EditTool) Description() string {
+	return "Replace a string in a file; fails listing all matches when old_string is ambiguous"
+}
 func (t *EditTool) Parameters() interface{} {
 	return map[string]string{
 		"path":        "string (required) - file path",
 		"old_string":  "string (required) - text to replace",
 		"new_string":  "string (required) - replacement text",
 		"replace_all": "bool (optional) - replace all occurrences (default false)",
+		"occurrence":  "number (optional) - which match to replace (1-based) when old_string matches several places",
+		"start_line":  "number (optional) - only match old_string within lines start_line..end_line",
+		"end_line":    "number (optional) - last line of the range (default end of file)",
 	}
 }
 
@@ -42,244 +45,409 @@ func (t *EditTool) Validate(args map[string]interface{}) error {
 	if _, ok := args["new_string"].(string); !ok {
 		return errors.New("new_string is required")
 	}
-	return nil
+	_, err := parseEditSpec(args)
+	return err
 }
 
 func (t *EditTool) Execute(ctx *Context) *Result {
 	result := &Result{StartTime: time.Now()}
 	path, _ := ctx.Args["path"].(string)
-	oldStr, _ := ctx.Args["old_string"].(string)
-	newStr, _ := ctx.Args["new_string"].(string)
-	replaceAll, _ := ctx.Args["replace_all"].(bool)
+	spec, _ := parseEditSpec(ctx.Args)
 
-	safePath, err := security.SafePath(ctx.Config.RootDir, path)
+	resolver := security.NewResolver(ctx.Config)
+	safePath, err := resolver.Resolve(path, security.Write)
 	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
 		return result
 	}
 
-	content, err := os.ReadFile(safePath)
+	content, err := resolver.ReadFile(safePath)
 	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
 		return result
 	}
 
-	log.Printf("[edit] old_string: %q\n", oldStr)
-	log.Printf("[edit] new_string: %q\n", newStr)
-	replaced, err := replaceInContent(string(content), oldStr, newStr, replaceAll)
+	outcome, err := applyEdit(string(content), spec)
 	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
 		return result
 	}
 
-	if err := os.WriteFile(safePath, []byte(replaced), 0644); err != nil {
+	// 替换后内容不变（如 old_string 与 new_string 相同）时不写入也不保存检查点
+	if outcome.Content == string(content) {
+		result.Status = "success"
+		result.Output, result.Diff = fileDiff(ctx, t.Name(), resolver.Display(safePath), outcome.Content, outcome.Content, false, outcome.describe())
+		result.EndTime = time.Now()
+		return result
+	}
+	if err := snapshot(ctx, t.Name(), safePath, resolver); err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	if err := resolver.WriteFile(safePath, []byte(outcome.Content), 0644); err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
 		return result
 	}
 
 	result.Status = "success"
-	result.Output = fmt.Sprintf("已替换 '%s' → '%s'", oldStr, newStr)
+	result.Output, result.Diff = fileDiff(ctx, t.Name(), resolver.Display(safePath), string(content), outcome.Content, false, outcome.describe())
 	result.EndTime = time.Now()
 	return result
 }
 
-func replaceInContent(content, oldStr, newStr string, replaceAll bool) (string, error) {
-	if strings.Contains(content, oldStr) {
-		if replaceAll {
-			return strings.ReplaceAll(content, oldStr, newStr), nil
+// 匹配策略，第一个有匹配的策略生效；忽略缩进匹配保留相对缩进，比按行去空白更严格，因此先尝试
+const (
+	MatchExact          = "exact"
+	MatchCRLFNormalized = "crlf-normalized"
+	MatchTrimmed        = "trimmed"
+	MatchIndentFlexible = "indent-flexible"
+)
+
+// editSpec 是一次替换：Occurrence 从 1 开始选择第几处匹配；StartLine、EndLine 限定匹配所在的行范围
+type editSpec struct {
+	Old        string
+	New        string
+	ReplaceAll bool
+	Occurrence int
+	StartLine  int
+	EndLine    int
+}
+
+// parseEditSpec 从工具参数中读取替换描述并校验行范围和 occurrence
+func parseEditSpec(args map[string]interface{}) (editSpec, error) {
+	spec := editSpec{}
+	spec.Old, _ = args["old_string"].(string)
+	spec.New, _ = args["new_string"].(string)
+	spec.ReplaceAll, _ = argBool(args, "replace_all")
+	spec.Occurrence, _ = argInt(args, "occurrence")
+	spec.StartLine, _ = argInt(args, "start_line")
+	spec.EndLine, _ = argInt(args, "end_line")
+	for name, v := range map[string]int{"occurrence": spec.Occurrence, "start_line": spec.StartLine, "end_line": spec.EndLine} {
+		if _, ok := args[name]; ok && v < 1 {
+			return spec, fmt.Errorf("%s must be a positive integer", name)
 		}
-		return strings.Replace(content, oldStr, newStr, 1), nil
 	}
+	if spec.EndLine > 0 && spec.EndLine < spec.StartLine {
+		return spec, fmt.Errorf("end_line (%d) is before start_line (%d)", spec.EndLine, spec.StartLine)
+	}
+	if spec.ReplaceAll && spec.Occurrence > 0 {
+		return spec, errors.New("occurrence cannot be combined with replace_all")
+	}
+	return spec, nil
+}
 
-	normalContent := strings.ReplaceAll(content, "\r\n", "\n")
-	normalOld := strings.ReplaceAll(oldStr, "\r\n", "\n")
-	if strings.Contains(normalContent, normalOld) {
-		normalNew := strings.ReplaceAll(newStr, "\r\n", "\n")
-		var result string
-		if replaceAll {
-			result = strings.ReplaceAll(normalContent, normalOld, normalNew)
-		} else {
-			result = strings.Replace(normalContent, normalOld, normalNew, 1)
+// editMatch 是 old_string 在原文中的一处匹配：[start, end) 为字节范围，replacement 为替换后的文本
+type editMatch struct {
+	start, end         int
+	startLine, endLine int
+	replacement        string
+}
+
+// editOutcome 是替换结果：使用的匹配策略和被替换的各处匹配的起始行号
+type editOutcome struct {
+	Content  string
+	Strategy string
+	Lines    []int
+}
+
+// describe 返回附在修改摘要中的匹配说明
+func (o editOutcome) describe() string {
+	return fmt.Sprintf("匹配方式 %s，第 %s 行", o.Strategy, joinInts(o.Lines, "、"))
+}
+
+func replaceInContent(content, oldStr, newStr string, replaceAll bool) (string, error) {
+	outcome, err := applyEdit(content, editSpec{Old: oldStr, New: newStr, ReplaceAll: replaceAll})
+	return outcome.Content, err
+}
+
+// applyEdit 依次尝试精确匹配、统一换行后匹配、忽略缩进匹配和按行去空白匹配，在行范围内找到匹配后：
+// replace_all 时替换全部，指定 occurrence 时替换第 N 处，否则要求恰好一处，多处时返回包含全部行号的错误
+func applyEdit(content string, spec editSpec) (editOutcome, error) {
+	if spec.Old == "" {
+		return editOutcome{}, errors.New("old_string is empty; use write_file to create or overwrite a file")
+	}
+	strategies := []struct {
+		name string
+		find func(content, oldStr, newStr string) []editMatch
+	}{
+		{MatchExact, exactMatches},
+		{MatchCRLFNormalized, crlfMatches},
+		{MatchIndentFlexible, indentFlexibleMatches},
+		{MatchTrimmed, lineTrimMatches},
+	}
+	var outside []editMatch
+	for _, st := range strategies {
+		all := st.find(content, spec.Old, spec.New)
+		matches := inRange(all, spec.StartLine, spec.EndLine)
+		if len(matches) == 0 {
+			if len(outside) == 0 {
+				outside = all
+			}
+			continue
 		}
-		if strings.Contains(content, "\r\n") {
-			result = strings.ReplaceAll(result, "\n", "\r\n")
+		switch {
+		case spec.ReplaceAll:
+		case spec.Occurrence > 0:
+			if spec.Occurrence > len(matches) {
+				return editOutcome{}, fmt.Errorf("occurrence %d out of range: old_string matches %d time(s)%s (%s) at lines %s",
+					spec.Occurrence, len(matches), rangeText(spec), st.name, matchLines(matches))
+			}
+			matches = matches[spec.Occurrence-1 : spec.Occurrence]
+		case len(matches) > 1:
+			return editOutcome{}, fmt.Errorf("old_string is ambiguous: it matches %d times%s (%s) at lines %s; include more surrounding context, or set occurrence (1-%d), start_line/end_line or replace_all",
+				len(matches), rangeText(spec), st.name, matchLines(matches), len(matches))
 		}
-		return result, nil
+		return splice(content, matches, st.name), nil
+	}
+	if len(outside) > 0 {
+		return editOutcome{}, fmt.Errorf("old_string not found%s; it matches at lines %s", rangeText(spec), matchLines(outside))
 	}
+	return editOutcome{}, fmt.Errorf("old_string not found in file")
+}
 
-	result, ok := lineTrimReplace(content, oldStr, newStr, replaceAll)
-	if ok {
-		return result, nil
+func inRange(matches []editMatch, start, end int) []editMatch {
+	if start == 0 && end == 0 {
+		return matches
+	}
+	var out []editMatch
+	for _, m := range matches {
+		if m.startLine >= start && (end == 0 || m.endLine <= end) {
+			out = append(out, m)
+		}
 	}
+	return out
+}
 
-	result, ok = indentFlexibleReplace(content, oldStr, newStr, replaceAll)
-	if ok {
-		return result, nil
+func rangeText(spec editSpec) string {
+	switch {
+	case spec.StartLine > 0 && spec.EndLine > 0:
+		return fmt.Sprintf(" within lines %d-%d", spec.StartLine, spec.EndLine)
+	case spec.StartLine > 0:
+		return fmt.Sprintf(" from line %d", spec.StartLine)
+	case spec.EndLine > 0:
+		return fmt.Sprintf(" up to line %d", spec.EndLine)
 	}
+	return ""
+}
 
-	return "", fmt.Errorf("old_string not found in file")
+func matchLines(matches []editMatch) string {
+	lines := make([]int, len(matches))
+	for i, m := range matches {
+		lines[i] = m.startLine
+	}
+	return joinInts(lines, ", ")
 }
 
-func lineTrimReplace(content, oldStr, newStr string, replaceAll bool) (string, bool) {
-	contentLines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
-	searchLines := strings.Split(strings.ReplaceAll(oldStr, "\r\n", "\n"), "\n")
-	replaceLines := strings.Split(strings.ReplaceAll(newStr, "\r\n", "\n"), "\n")
+func joinInts(nums []int, sep string) string {
+	parts := make([]string, len(nums))
+	for i, n := range nums {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, sep)
+}
 
-	if len(searchLines) == 0 {
-		return "", false
+// splice 把互不重叠、按位置排序的匹配替换为各自的替换文本
+func splice(content string, matches []editMatch, strategy string) editOutcome {
+	var sb strings.Builder
+	outcome := editOutcome{Strategy: strategy}
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(content[last:m.start])
+		sb.WriteString(m.replacement)
+		last = m.end
+		outcome.Lines = append(outcome.Lines, m.startLine)
 	}
+	sb.WriteString(content[last:])
+	outcome.Content = sb.String()
+	return outcome
+}
 
-	replaced := false
-	var result []string
-	i := 0
-	for i < len(contentLines) {
-		if !replaceAll && replaced {
-			result = append(result, contentLines[i])
-			i++
-			continue
-		}
-		if i+len(searchLines) <= len(contentLines) {
-			match := true
-			for j, sl := range searchLines {
-				if strings.TrimSpace(contentLines[i+j]) != strings.TrimSpace(sl) {
-					match = false
-					break
-				}
-			}
-			if match {
-				indent := leadingWhitespace(contentLines[i])
-				for _, rl := range replaceLines {
-					if rl == "" {
-						result = append(result, "")
-					} else {
-						result = append(result, indent+strings.TrimLeft(rl, " \t"))
-					}
-				}
-				i += len(searchLines)
-				replaced = true
-				continue
-			}
-		}
-		result = append(result, contentLines[i])
-		i++
+// newMatch 根据字节范围计算匹配的起止行号
+func newMatch(content string, start, end int, replacement string) editMatch {
+	m := editMatch{start: start, end: end, replacement: replacement}
+	m.startLine = 1 + strings.Count(content[:start], "\n")
+	last := end
+	if last > start && content[last-1] == '\n' {
+		last--
 	}
+	m.endLine = 1 + strings.Count(content[:last], "\n")
+	return m
+}
 
-	if !replaced {
-		return "", false
+// exactMatches 返回 oldStr 在原文中全部不重叠的出现位置
+func exactMatches(content, oldStr, newStr string) []editMatch {
+	var matches []editMatch
+	for from := 0; ; {
+		i := strings.Index(content[from:], oldStr)
+		if i < 0 {
+			return matches
+		}
+		start := from + i
+		matches = append(matches, newMatch(content, start, start+len(oldStr), newStr))
+		from = start + len(oldStr)
 	}
+}
 
-	out := strings.Join(result, "\n")
+// crlfMatches 把 oldStr、newStr 的换行统一为文件使用的换行符后精确匹配
+func crlfMatches(content, oldStr, newStr string) []editMatch {
+	eol := "\n"
 	if strings.Contains(content, "\r\n") {
-		out = strings.ReplaceAll(out, "\n", "\r\n")
+		eol = "\r\n"
 	}
-	return out, true
+	convert := func(s string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", eol)
+	}
+	if convert(oldStr) == oldStr {
+		return nil
+	}
+	return exactMatches(content, convert(oldStr), convert(newStr))
 }
 
-func leadingWhitespace(s string) string {
-	scanner := bufio.NewScanner(strings.NewReader(s))
-	scanner.Scan()
-	line := scanner.Text()
-	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+// contentLine 是原文中的一行：text 不含行尾的换行符和 \r，[start, end) 为 text 在原文中的字节范围
+type contentLine struct {
+	text       string
+	start, end int
 }
 
-// indentFlexibleReplace strips common indentation before comparing lines.
-func indentFlexibleReplace(content, oldStr, newStr string, replaceAll bool) (string, bool) {
-	normalize := func(s string) string {
-		return strings.ReplaceAll(s, "\r\n", "\n")
+func splitContentLines(content string) []contentLine {
+	var lines []contentLine
+	start := 0
+	for {
+		i := strings.IndexByte(content[start:], '\n')
+		end := len(content)
+		if i >= 0 {
+			end = start + i
+		}
+		text := strings.TrimSuffix(content[start:end], "\r")
+		lines = append(lines, contentLine{text: text, start: start, end: start + len(text)})
+		if i < 0 {
+			return lines
+		}
+		start = end + 1
 	}
-	contentLines := strings.Split(normalize(content), "\n")
-	searchLines := strings.Split(normalize(oldStr), "\n")
-	replaceLines := strings.Split(normalize(newStr), "\n")
+}
 
-	if len(searchLines) == 0 {
-		return "", false
+// lineMatches 逐行比较 oldStr 的每一行，same 判断一块原文是否匹配；匹配时 render 生成替换文本的各行
+func lineMatches(content, oldStr, newStr string, same func(block, search []string) bool, render func(block, replace []string) []string) []editMatch {
+	lines := splitContentLines(content)
+	search := strings.Split(strings.ReplaceAll(oldStr, "\r\n", "\n"), "\n")
+	replace := strings.Split(strings.ReplaceAll(newStr, "\r\n", "\n"), "\n")
+	eol := "\n"
+	if strings.Contains(content, "\r\n") {
+		eol = "\r\n"
 	}
-
-	minIndent := func(lines []string) int {
-		min := -1
-		for _, l := range lines {
-			if strings.TrimSpace(l) == "" {
-				continue
-			}
-			n := len(l) - len(strings.TrimLeft(l, " \t"))
-			if min < 0 || n < min {
-				min = n
-			}
+	var matches []editMatch
+	for i := 0; i+len(search) <= len(lines); {
+		block := make([]string, len(search))
+		for j := range search {
+			block[j] = lines[i+j].text
 		}
-		if min < 0 {
-			return 0
+		if !same(block, search) {
+			i++
+			continue
 		}
-		return min
+		replacement := strings.Join(render(block, replace), eol)
+		matches = append(matches, newMatch(content, lines[i].start, lines[i+len(search)-1].end, replacement))
+		i += len(search)
 	}
+	return matches
+}
 
-	searchIndent := minIndent(searchLines)
-	stripIndent := func(lines []string, n int) []string {
-		out := make([]string, len(lines))
-		for i, l := range lines {
-			if len(l) >= n {
-				out[i] = l[n:]
-			} else {
-				out[i] = strings.TrimLeft(l, " \t")
+// lineTrimMatches 去掉每行首尾空白后比较，替换文本统一使用匹配块首行的缩进
+func lineTrimMatches(content, oldStr, newStr string) []editMatch {
+	same := func(block, search []string) bool {
+		for j, sl := range search {
+			if strings.TrimSpace(block[j]) != strings.TrimSpace(sl) {
+				return false
+			}
+		}
+		return true
+	}
+	render := func(block, replace []string) []string {
+		indent := leadingWhitespace(block[0])
+		out := make([]string, len(replace))
+		for i, rl := range replace {
+			if rl != "" {
+				out[i] = indent + strings.TrimLeft(rl, " \t")
 			}
 		}
 		return out
 	}
-	strippedSearch := stripIndent(searchLines, searchIndent)
+	return lineMatches(content, oldStr, newStr, same, render)
+}
 
-	replaced := false
-	var result []string
-	i := 0
-	for i < len(contentLines) {
-		if !replaceAll && replaced {
-			result = append(result, contentLines[i])
-			i++
-			continue
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// indentFlexibleMatches 去掉各自的公共缩进后比较，替换文本保留相对缩进并改用匹配块的缩进
+func indentFlexibleMatches(content, oldStr, newStr string) []editMatch {
+	search := strings.Split(strings.ReplaceAll(oldStr, "\r\n", "\n"), "\n")
+	strippedSearch := stripIndent(search, minIndent(search))
+	same := func(block, _ []string) bool {
+		stripped := stripIndent(block, minIndent(block))
+		for j := range strippedSearch {
+			if stripped[j] != strippedSearch[j] {
+				return false
+			}
 		}
-		if i+len(searchLines) <= len(contentLines) {
-			block := contentLines[i : i+len(searchLines)]
-			blockIndent := minIndent(block)
-			strippedBlock := stripIndent(block, blockIndent)
-			match := true
-			for j := range strippedSearch {
-				if strippedBlock[j] != strippedSearch[j] {
-					match = false
-					break
-				}
+		return true
+	}
+	render := func(block, replace []string) []string {
+		indent := ""
+		n := minIndent(block)
+		for _, l := range block {
+			if strings.TrimSpace(l) != "" && len(leadingWhitespace(l)) == n {
+				indent = l[:n]
+				break
 			}
-			if match {
-				indent := strings.Repeat(" ", blockIndent)
-				replaceIndent := minIndent(replaceLines)
-				for _, rl := range replaceLines {
-					if strings.TrimSpace(rl) == "" {
-						result = append(result, "")
-					} else {
-						stripped := rl
-						if len(rl) >= replaceIndent {
-							stripped = rl[replaceIndent:]
-						}
-						result = append(result, indent+stripped)
-					}
-				}
-				i += len(searchLines)
-				replaced = true
+		}
+		replaceIndent := minIndent(replace)
+		out := make([]string, len(replace))
+		for i, rl := range replace {
+			if strings.TrimSpace(rl) == "" {
 				continue
 			}
+			if len(rl) >= replaceIndent {
+				rl = rl[replaceIndent:]
+			}
+			out[i] = indent + rl
 		}
-		result = append(result, contentLines[i])
-		i++
+		return out
 	}
+	return lineMatches(content, oldStr, newStr, same, render)
+}
 
-	if !replaced {
-		return "", false
+// minIndent 返回非空行的最小缩进宽度
+func minIndent(lines []string) int {
+	min := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if n := len(leadingWhitespace(l)); min < 0 || n < min {
+			min = n
+		}
 	}
+	if min < 0 {
+		return 0
+	}
+	return min
+}
 
-	out := strings.Join(result, "\n")
-	if strings.Contains(content, "\r\n") {
-		out = strings.ReplaceAll(out, "\n", "\r\n")
+func stripIndent(lines []string, n int) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		if len(l) >= n {
+			out[i] = l[n:]
+		} else {
+			out[i] = strings.TrimLeft(l, " \t")
+		}
 	}
-	return out, true
+	return out
 }
@@ -1,6 +1,7 @@
 package tool
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -43,3 +44,82 @@ func TestReplaceInContent(t *testing.T) {
 		}
 	})
 }
+
+func TestApplyEdit(t *testing.T) {
+	content := "x := 1\nfoo()\ny := 2\nfoo()\nz := 3\nfoo()\n"
+
+	t.Run("ambiguous match lists every line", func(t *testing.T) {
+		_, err := applyEdit(content, editSpec{Old: "foo()", New: "bar()"})
+		if err == nil || !strings.Contains(err.Error(), "matches 3 times (exact) at lines 2, 4, 6") {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("occurrence picks one match", func(t *testing.T) {
+		got, err := applyEdit(content, editSpec{Old: "foo()", New: "bar()", Occurrence: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Content != "x := 1\nfoo()\ny := 2\nbar()\nz := 3\nfoo()\n" || got.Strategy != MatchExact || len(got.Lines) != 1 || got.Lines[0] != 4 {
+			t.Errorf("got %+v", got)
+		}
+		if _, err := applyEdit(content, editSpec{Old: "foo()", New: "bar()", Occurrence: 4}); err == nil {
+			t.Error("expected out of range error")
+		}
+	})
+
+	t.Run("line range narrows matches", func(t *testing.T) {
+		got, err := applyEdit(content, editSpec{Old: "foo()", New: "bar()", StartLine: 5, EndLine: 6})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Content != "x := 1\nfoo()\ny := 2\nfoo()\nz := 3\nbar()\n" || got.Lines[0] != 6 {
+			t.Errorf("got %+v", got)
+		}
+		_, err = applyEdit(content, editSpec{Old: "y := 2", New: "", StartLine: 4})
+		if err == nil || !strings.Contains(err.Error(), "from line 4; it matches at lines 3") {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("replace all reports every line", func(t *testing.T) {
+		got, err := applyEdit(content, editSpec{Old: "foo()", New: "bar()", ReplaceAll: true})
+		if err != nil || strings.Contains(got.Content, "foo") || len(got.Lines) != 3 {
+			t.Errorf("got %+v, err %v", got, err)
+		}
+	})
+
+	tests := []struct {
+		name, content, old, new, want, strategy string
+	}{
+		{"exact", "a\nb\n", "b", "c", "a\nc\n", MatchExact},
+		{"crlf", "a\r\nb\r\nc\r\n", "a\nb", "x\ny", "x\r\ny\r\nc\r\n", MatchCRLFNormalized},
+		{"trimmed", "\tif ok {\n\t\trun()\n\t}\n", "run()  ", "stop()", "\tif ok {\n\t\tstop()\n\t}\n", MatchTrimmed},
+		{"indent flexible", "func f() {\n\tif ok {\n\t\trun()\n\t}\n}\n", "if ok {\n\trun()\n}", "if ok {\n\trun()\n\tstop()\n}", "func f() {\n\tif ok {\n\t\trun()\n\t\tstop()\n\t}\n}\n", MatchIndentFlexible},
+		{"trimmed mixed indent", "\tif ok {\n\t\trun()\n\t}\n", "if ok {\n    run()\n}", "if ok {\n    stop()\n}", "\tif ok {\n\tstop()\n\t}\n", MatchTrimmed},
+	}
+	for _, tt := range tests {
+		t.Run("strategy "+tt.name, func(t *testing.T) {
+			got, err := applyEdit(tt.content, editSpec{Old: tt.old, New: tt.new})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Content != tt.want || got.Strategy != tt.strategy {
+				t.Errorf("got %q (%s), want %q (%s)", got.Content, got.Strategy, tt.want, tt.strategy)
+			}
+		})
+	}
+}
+
+func TestParseEditSpec(t *testing.T) {
+	bad := []map[string]interface{}{
+		{"occurrence": float64(0)},
+		{"start_line": float64(5), "end_line": float64(2)},
+		{"replace_all": true, "occurrence": float64(1)},
+	}
+	for _, args := range bad {
+		if _, err := parseEditSpec(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
@@ -86,7 +86,7 @@ func (t *ExecCmdTool) Execute(ctx *Context) *Result {
 		return result
 	}
 
-	outputStr, _ := Truncate(string(output))
+	outputStr := TruncateFor(ctx, t.Name(), string(output))
 
 	if err != nil {
 		result.Status = "error"
@@ -1,11 +1,13 @@
 package tool
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/afumu/openlink/internal/checkpoint"
 	"github.com/afumu/openlink/internal/types"
 )
 
@@ -71,6 +73,35 @@ func TestWriteReadFile(t *testing.T) {
 	})
 }
 
+func TestWriteFileDiff(t *testing.T) {
+	cfg := testConfig(t)
+	w := NewWriteFileTool(cfg)
+
+	res := w.Execute(testCtx(cfg, map[string]interface{}{"path": "notes.txt", "content": "a\nb\n"}))
+	if res.Output != "已创建 notes.txt（+2）\n--- /dev/null\n+++ b/notes.txt\n@@ -0,0 +1,2 @@\n+1  a\n+2  b" || !res.Diff.Created {
+		t.Errorf("create: %q %+v", res.Output, res.Diff)
+	}
+	res = w.Execute(testCtx(cfg, map[string]interface{}{"path": "notes.txt", "content": "c\n", "mode": "append"}))
+	if !strings.HasPrefix(res.Output, "已修改 notes.txt（+1 -0）") || !strings.Contains(res.Output, "+3  c") {
+		t.Errorf("append: %q", res.Output)
+	}
+	res = w.Execute(testCtx(cfg, map[string]interface{}{"path": "notes.txt", "content": "a\nb\nc\n"}))
+	if res.Output != "notes.txt 内容未变化" || len(res.Diff.Hunks) != 0 {
+		t.Errorf("unchanged: %q", res.Output)
+	}
+	res = w.Execute(testCtx(cfg, map[string]interface{}{"path": "notes.txt", "content": "a\r\nb\r\nc"}))
+	if res.Output != "已修改 notes.txt（+0 -0）\n--- a/notes.txt\n+++ b/notes.txt\n\\ 换行符由 LF 改为 CRLF\n\\ 已删除文件末尾的换行" {
+		t.Errorf("line endings: %q", res.Output)
+	}
+
+	// 差异按截断配置裁剪
+	cfg.Output = &types.OutputSettings{Tools: map[string]types.OutputLimit{"write_file": {MaxLines: 5}}}
+	res = w.Execute(testCtx(cfg, map[string]interface{}{"path": "long.txt", "content": strings.Repeat("x\n", 50)}))
+	if !res.Diff.Truncated || len(res.Diff.Hunks[0].Lines) != 5 || !strings.Contains(res.Output, "差异过长，已截断") {
+		t.Errorf("capped: %q", res.Output)
+	}
+}
+
 func TestGlobTool(t *testing.T) {
 	cfg := testConfig(t)
 	os.WriteFile(filepath.Join(cfg.RootDir, "a.go"), []byte(""), 0644)
@@ -152,6 +183,33 @@ func TestEditTool(t *testing.T) {
 		}
 	})
 
+	t.Run("returns a diff of the re-indented replacement", func(t *testing.T) {
+		os.WriteFile(filepath.Join(cfg.RootDir, "main.go"), []byte("func main() {\n\tif ok {\n\t\trun()\n\t}\n}\n"), 0644)
+		res := NewEditTool(cfg).Execute(testCtx(cfg, map[string]interface{}{
+			"path": "main.go", "old_string": "if ok {\n    run()\n}", "new_string": "if ok {\n    run()\n    stop()\n}",
+		}))
+		if res.Status != "success" {
+			t.Fatalf("edit failed: %s", res.Error)
+		}
+		if !strings.HasPrefix(res.Output, "已修改 main.go（+2 -1，匹配方式 trimmed，第 2 行）\n--- a/main.go\n+++ b/main.go\n@@ -1,5 +1,6 @@") {
+			t.Errorf("got %q", res.Output)
+		}
+		d := res.Diff
+		if d == nil || d.Path != "main.go" || d.Added != 2 || d.Removed != 1 || len(d.Hunks) != 1 {
+			t.Fatalf("got %+v", d)
+		}
+		var added []string
+		for _, l := range d.Hunks[0].Lines {
+			if l.Op == "+" {
+				added = append(added, fmt.Sprintf("%d:%s", l.New, l.Text))
+			}
+		}
+		// 按行去空白匹配时替换文本统一使用首行缩进，差异中能看出 run() 的缩进被改变
+		if strings.Join(added, "|") != "3:\trun()|4:\tstop()" {
+			t.Errorf("added lines = %q", added)
+		}
+	})
+
 	t.Run("old_string not found returns error", func(t *testing.T) {
 		os.WriteFile(filepath.Join(cfg.RootDir, "nope.txt"), []byte("abc"), 0644)
 		e := NewEditTool(cfg)
@@ -163,3 +221,162 @@ func TestEditTool(t *testing.T) {
 		}
 	})
 }
+
+func TestIgnoredPaths(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := testConfig(t)
+	root := cfg.RootDir
+	os.WriteFile(filepath.Join(root, ".env"), []byte("TOKEN=needle\n"), 0644)
+	os.WriteFile(filepath.Join(root, "private.txt"), []byte("needle\n"), 0644)
+	os.WriteFile(filepath.Join(root, "public.txt"), []byte("needle\n"), 0644)
+	os.MkdirAll(filepath.Join(root, ".git"), 0755)
+	os.WriteFile(filepath.Join(root, ".git", "config"), []byte("needle\n"), 0644)
+	os.WriteFile(filepath.Join(root, ".openlinkignore"), []byte("private.txt\n"), 0644)
+
+	refused := []struct {
+		tool Tool
+		args map[string]interface{}
+	}{
+		{NewReadFileTool(cfg), map[string]interface{}{"path": ".env"}},
+		{NewReadFileTool(cfg), map[string]interface{}{"path": "private.txt"}},
+		{NewReadFileTool(cfg), map[string]interface{}{"path": filepath.Join(os.Getenv("HOME"), ".openlink", "settings.json")}},
+		{NewWriteFileTool(cfg), map[string]interface{}{"path": ".git/config", "content": "x"}},
+		{NewWriteFileTool(cfg), map[string]interface{}{"path": ".openlinkignore", "content": ""}},
+		{NewEditTool(cfg), map[string]interface{}{"path": ".env", "old_string": "needle", "new_string": "x"}},
+		{NewListDirTool(cfg), map[string]interface{}{"path": ".git"}},
+		{NewGlobTool(cfg), map[string]interface{}{"pattern": "*", "path": ".git"}},
+		{NewGrepTool(cfg), map[string]interface{}{"pattern": "needle", "path": ".git"}},
+	}
+	for _, tc := range refused {
+		res := tc.tool.Execute(testCtx(cfg, tc.args))
+		if res.Status != "error" || !strings.Contains(res.Error, "hidden from tools") {
+			t.Errorf("%s %v: expected refusal, got %s %q", tc.tool.Name(), tc.args, res.Status, res.Error)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, ".git", "config")); string(data) != "needle\n" {
+		t.Error(".git/config must not be modified")
+	}
+
+	listings := []struct {
+		tool Tool
+		args map[string]interface{}
+	}{
+		{NewListDirTool(cfg), map[string]interface{}{"path": "."}},
+		{NewGlobTool(cfg), map[string]interface{}{"pattern": "**/*"}},
+		{NewGrepTool(cfg), map[string]interface{}{"pattern": "needle"}},
+	}
+	for _, tc := range listings {
+		res := tc.tool.Execute(testCtx(cfg, tc.args))
+		if res.Status != "success" || !strings.Contains(res.Output, "public.txt") {
+			t.Errorf("%s: expected public.txt listed, got %q", tc.tool.Name(), res.Output)
+		}
+		for _, hidden := range []string{".env", "private.txt", ".git"} {
+			if strings.Contains(res.Output, hidden) {
+				t.Errorf("%s: %s should be hidden, got %q", tc.tool.Name(), hidden, res.Output)
+			}
+		}
+	}
+}
+
+func TestPathsAcrossTools(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := testConfig(t)
+	abs := filepath.Join(cfg.RootDir, "pkg", "util.go")
+
+	res := NewWriteFileTool(cfg).Execute(testCtx(cfg, map[string]interface{}{"path": filepath.ToSlash(abs), "content": "package pkg\n"}))
+	if res.Status != "success" {
+		t.Fatalf("write with absolute path: %s", res.Error)
+	}
+	if _, err := os.Stat(abs); err != nil {
+		t.Fatal("absolute path should not be joined onto the workspace")
+	}
+
+	res = NewGlobTool(cfg).Execute(testCtx(cfg, map[string]interface{}{"pattern": "**/*.go"}))
+	if res.Output != "pkg/util.go" {
+		t.Fatalf("glob should print workspace-relative paths, got %q", res.Output)
+	}
+	res = NewGrepTool(cfg).Execute(testCtx(cfg, map[string]interface{}{"pattern": "package"}))
+	if res.Output != "pkg/util.go:1:package pkg" {
+		t.Errorf("grep should print workspace-relative paths, got %q", res.Output)
+	}
+	res = NewReadFileTool(cfg).Execute(testCtx(cfg, map[string]interface{}{"path": "pkg/util.go"}))
+	if res.Status != "success" || !strings.Contains(res.Output, "package pkg") {
+		t.Errorf("glob output should be readable as-is: %s %s", res.Status, res.Error)
+	}
+	res = NewEditTool(cfg).Execute(testCtx(cfg, map[string]interface{}{"path": "~/x.go", "old_string": "a", "new_string": "b"}))
+	if res.Status != "error" || !strings.Contains(res.Error, "outside sandbox") {
+		t.Errorf("expected ~ outside the roots to be refused, got %s %s", res.Status, res.Error)
+	}
+}
+
+func TestSymlinkEscape(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := testConfig(t)
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("needle\n"), 0644)
+	if err := os.Symlink(filepath.Join(outside, "new.txt"), filepath.Join(cfg.RootDir, "dangling")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	os.Symlink(outside, filepath.Join(cfg.RootDir, "out"))
+	os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(cfg.RootDir, "secret-link.txt"))
+
+	for _, path := range []string{"dangling", "out/new.txt", "out/dir/new.txt"} {
+		res := NewWriteFileTool(cfg).Execute(testCtx(cfg, map[string]interface{}{"path": path, "content": "x"}))
+		if res.Status != "error" || !strings.Contains(res.Error, "outside sandbox") {
+			t.Errorf("write %s: expected refusal, got %s %s", path, res.Status, res.Error)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 1 {
+		t.Errorf("files were created outside the workspace: %v", entries)
+	}
+	// grep 遍历工作目录时不能经由文件符号链接读到工作目录外的内容
+	res := NewGrepTool(cfg).Execute(testCtx(cfg, map[string]interface{}{"pattern": "needle"}))
+	if strings.Contains(res.Output, "needle") {
+		t.Errorf("grep followed a symlink outside the workspace: %q", res.Output)
+	}
+}
+
+func TestUndoTool(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := testConfig(t)
+	store := checkpoint.NewStore(t.TempDir())
+	ctx := func(args map[string]interface{}) *Context {
+		return &Context{Args: args, Config: cfg, Session: "s", Checkpoints: store}
+	}
+	path := filepath.Join(cfg.RootDir, "main.go")
+	os.WriteFile(path, []byte("package main\n"), 0644)
+
+	NewEditTool(cfg).Execute(ctx(map[string]interface{}{"path": "main.go", "old_string": "main", "new_string": "app"}))
+	NewWriteFileTool(cfg).Execute(ctx(map[string]interface{}{"path": "sub/new.go", "content": "package sub\n"}))
+
+	undo := NewUndoTool(store)
+	res := undo.Execute(ctx(map[string]interface{}{"count": float64(2)}))
+	if res.Status != "success" || !strings.Contains(res.Output, "已撤销 2 处修改") || !strings.Contains(res.Output, "删除新建的 sub/new.go") {
+		t.Fatalf("undo: %s %s %s", res.Status, res.Output, res.Error)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "package main\n" {
+		t.Errorf("main.go = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.RootDir, "sub", "new.go")); !os.IsNotExist(err) {
+		t.Errorf("new file should be deleted, got %v", err)
+	}
+	res = undo.Execute(ctx(map[string]interface{}{}))
+	if res.Status != "success" || res.Output != "没有可撤销的修改" {
+		t.Errorf("nothing left to undo: %s %s", res.Output, res.Error)
+	}
+
+	// 内容不变的写入和替换不保存检查点，undo 撤销的仍是上一次真正的修改
+	NewWriteFileTool(cfg).Execute(ctx(map[string]interface{}{"path": "main.go", "content": "package app\n"}))
+	NewWriteFileTool(cfg).Execute(ctx(map[string]interface{}{"path": "main.go", "content": "package app\n"}))
+	res = NewEditTool(cfg).Execute(ctx(map[string]interface{}{"path": "main.go", "old_string": "app", "new_string": "app"}))
+	if res.Status != "success" || res.Output != "main.go 内容未变化" {
+		t.Errorf("no-op edit: %s %q %s", res.Status, res.Output, res.Error)
+	}
+	if list, _ := store.List("s"); len(list) != 3 {
+		t.Errorf("expected no checkpoints for no-op changes, got %d", len(list))
+	}
+	undo.Execute(ctx(map[string]interface{}{}))
+	if data, _ := os.ReadFile(path); string(data) != "package main\n" {
+		t.Errorf("undo should revert the real change, main.go = %q", data)
+	}
+}
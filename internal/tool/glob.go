@@ -45,7 +45,8 @@ func (t *GlobTool) Execute(ctx *Context) *Result {
 		searchPath = "."
 	}
 
-	safePath, err := security.SafePath(ctx.Config.RootDir, searchPath)
+	resolver := security.NewResolver(ctx.Config)
+	safePath, err := resolver.Resolve(searchPath, security.Read)
 	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
@@ -62,7 +63,16 @@ func (t *GlobTool) Execute(ctx *Context) *Result {
 	isRecursive := strings.Contains(pattern, "**")
 
 	filepath.WalkDir(safePath, func(p string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
+		if err != nil {
+			return nil
+		}
+		if p != safePath && resolver.Ignore().Hidden(p, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
 			return nil
 		}
 		name := d.Name()
@@ -79,7 +89,7 @@ func (t *GlobTool) Execute(ctx *Context) *Result {
 		if matched {
 			info, _ := d.Info()
 			files = append(files, fileEntry{
-				path:  filepath.ToSlash(p),
+				path:  resolver.Display(p),
 				mtime: info.ModTime(),
 			})
 		}
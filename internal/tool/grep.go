@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
@@ -54,7 +53,8 @@ func (t *GrepTool) Execute(ctx *Context) *Result {
 		searchPath = "."
 	}
 
-	safePath, err := security.SafePath(ctx.Config.RootDir, searchPath)
+	resolver := security.NewResolver(ctx.Config)
+	safePath, err := resolver.Resolve(searchPath, security.Read)
 	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
@@ -63,9 +63,9 @@ func (t *GrepTool) Execute(ctx *Context) *Result {
 
 	var output string
 	if rgPath, err := exec.LookPath("rg"); err == nil {
-		output = grepWithRg(rgPath, pattern, safePath, include)
+		output = grepWithRg(rgPath, pattern, safePath, include, resolver)
 	} else {
-		output, err = grepNative(pattern, safePath, include)
+		output, err = grepNative(pattern, safePath, include, resolver)
 		if err != nil {
 			result.Status = "error"
 			result.Error = err.Error()
@@ -79,7 +79,10 @@ func (t *GrepTool) Execute(ctx *Context) *Result {
 	return result
 }
 
-func grepWithRg(rgPath, pattern, searchPath, include string) string {
+// rgLineRe 从 rg 输出的 "路径:行号:内容" 中取出路径
+var rgLineRe = regexp.MustCompile(`^(.*?):\d+:`)
+
+func grepWithRg(rgPath, pattern, searchPath, include string, resolver *security.Resolver) string {
 	args := []string{"-n", "--no-heading"}
 	if include != "" {
 		if strings.ContainsAny(include, "/\\") {
@@ -90,11 +93,20 @@ func grepWithRg(rgPath, pattern, searchPath, include string) string {
 	args = append(args, "--", pattern, searchPath)
 	cmd := exec.Command(rgPath, args...)
 	out, _ := cmd.Output()
-	lines := strings.Split(strings.ReplaceAll(string(out), "\r\n", "\n"), "\n")
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(out), "\r\n", "\n"), "\n") {
+		if m := rgLineRe.FindStringSubmatch(line); m != nil {
+			if resolver.Ignore().Hidden(m[1], false) {
+				continue
+			}
+			line = resolver.Display(m[1]) + line[len(m[1]):]
+		}
+		lines = append(lines, line)
+	}
 	return formatGrepLines(lines, 100)
 }
 
-func grepNative(pattern, searchPath, include string) (string, error) {
+func grepNative(pattern, searchPath, include string, resolver *security.Resolver) (string, error) {
 	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return "", fmt.Errorf("invalid pattern: %w", err)
@@ -107,7 +119,16 @@ func grepNative(pattern, searchPath, include string) (string, error) {
 	var matches []match
 
 	filepath.WalkDir(searchPath, func(p string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
+		if err != nil {
+			return nil
+		}
+		if p != searchPath && resolver.Ignore().Hidden(p, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
 			return nil
 		}
 		if include != "" {
@@ -118,7 +139,7 @@ func grepNative(pattern, searchPath, include string) (string, error) {
 		info, _ := d.Info()
 		mtime := info.ModTime()
 
-		f, err := os.Open(p)
+		f, err := resolver.Open(p)
 		if err != nil {
 			return nil
 		}
@@ -131,7 +152,7 @@ func grepNative(pattern, searchPath, include string) (string, error) {
 			text := scanner.Text()
 			if re.MatchString(text) {
 				matches = append(matches, match{
-					line:  fmt.Sprintf("%s:%d:%s", filepath.ToSlash(p), lineNum, text),
+					line:  fmt.Sprintf("%s:%d:%s", resolver.Display(p), lineNum, text),
 					mtime: mtime,
 				})
 			}
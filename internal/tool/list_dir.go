@@ -2,7 +2,7 @@ package tool
 
 import (
 	"errors"
-	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -44,14 +44,15 @@ func (t *ListDirTool) Execute(ctx *Context) *Result {
 	result := &Result{StartTime: time.Now()}
 	path, _ := ctx.Args["path"].(string)
 
-	safePath, err := security.SafePath(ctx.Config.RootDir, path)
+	resolver := security.NewResolver(ctx.Config)
+	safePath, err := resolver.Resolve(path, security.Read)
 	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
 		return result
 	}
 
-	entries, err := os.ReadDir(safePath)
+	entries, err := resolver.ReadDir(safePath)
 	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
@@ -61,6 +62,9 @@ func (t *ListDirTool) Execute(ctx *Context) *Result {
 	var names []string
 	for _, e := range entries {
 		name := e.Name()
+		if resolver.Ignore().Hidden(filepath.Join(safePath, name), e.IsDir()) {
+			continue
+		}
 		if e.IsDir() {
 			name += "/"
 		}
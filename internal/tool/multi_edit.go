@@ -0,0 +1,244 @@
+package tool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/afumu/openlink/internal/security"
+	"github.com/afumu/openlink/internal/types"
+)
+
+type MultiEditTool struct {
+	config *types.Config
+}
+
+func NewMultiEditTool(config *types.Config) *MultiEditTool {
+	return &MultiEditTool{config: config}
+}
+
+func (t *MultiEditTool) Name() string { return "multi_edit" }
+func (t *MultiEditTool) Description() string {
+	return "Apply several edit replacements to one or more files in order; all edits are checked first, new contents are written to temporary files and then renamed over the originals, so a failed write leaves every file unchanged"
+}
+func (t *MultiEditTool) Parameters() interface{} {
+	return map[string]string{
+		"edits": "array (required) - edit operations [{path, old_string, new_string, replace_all, occurrence, start_line, end_line}], applied in order; later edits to the same file see the result of earlier ones",
+	}
+}
+
+func (t *MultiEditTool) Validate(args map[string]interface{}) error {
+	_, err := parseEdits(args["edits"])
+	return err
+}
+
+// pendingFile 是 multi_edit 涉及的一个文件：before 为读取时的内容，after 为依次应用替换后的内容
+type pendingFile struct {
+	abs     string
+	display string
+	before  string
+	after   string
+	notes   []string
+}
+
+func (t *MultiEditTool) Execute(ctx *Context) *Result {
+	result := &Result{StartTime: time.Now()}
+	ops, _ := parseEdits(ctx.Args["edits"])
+	resolver := security.NewResolver(ctx.Config)
+
+	// 先在内存中依次应用全部替换，任何一处失败都不写入文件
+	var files []*pendingFile
+	byPath := map[string]*pendingFile{}
+	for i, op := range ops {
+		abs, err := resolver.Resolve(op.path, security.Write)
+		if err != nil {
+			return multiEditError(result, i, op.path, err)
+		}
+		f := byPath[abs]
+		if f == nil {
+			content, err := resolver.ReadFile(abs)
+			if err != nil {
+				return multiEditError(result, i, op.path, err)
+			}
+			f = &pendingFile{abs: abs, display: resolver.Display(abs), before: string(content), after: string(content)}
+			byPath[abs] = f
+			files = append(files, f)
+		}
+		outcome, err := applyEdit(f.after, op.spec)
+		if err != nil {
+			return multiEditError(result, i, op.path, err)
+		}
+		f.after = outcome.Content
+		f.notes = append(f.notes, fmt.Sprintf("#%d %s", i+1, outcome.describe()))
+	}
+
+	var changed []*pendingFile
+	for _, f := range files {
+		if f.after != f.before {
+			changed = append(changed, f)
+		}
+	}
+	paths := make([]string, len(changed))
+	for i, f := range changed {
+		paths[i] = f.abs
+	}
+	ids, err := snapshotGroup(ctx, t.Name(), paths, resolver)
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	if restored, err := writeAll(changed, resolver); err != nil {
+		// 文件已全部恢复时本次调用没有留下修改，丢弃检查点，避免下一次 undo 撤销的是这次空操作
+		if restored && ctx.Checkpoints != nil {
+			if derr := ctx.Checkpoints.Discard(ctx.Session, ids); derr != nil {
+				log.Printf("[multi_edit] 丢弃检查点失败: %v\n", derr)
+			}
+		}
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+
+	parts := []string{fmt.Sprintf("已应用 %d 处替换，修改 %d 个文件", len(ops), len(changed))}
+	for _, f := range files {
+		out, d := fileDiff(ctx, t.Name(), f.display, f.before, f.after, false, f.notes...)
+		parts = append(parts, out)
+		if len(d.Hunks) > 0 || len(d.Notes) > 0 {
+			result.Diffs = append(result.Diffs, d)
+		}
+	}
+	result.Status = "success"
+	result.Output = strings.Join(parts, "\n\n")
+	result.EndTime = time.Now()
+	return result
+}
+
+// writeAll 先把每个文件的新内容写入同一目录下的临时文件，全部写入成功后再依次重命名覆盖原文件。
+// 写入阶段失败（磁盘已满、权限不足等）时原文件都未被改动；重命名本身是原子的，只有重命名中途失败时
+// 才需要把已替换的文件写回原内容。restored 表示失败时文件是否都保持或恢复为原内容
+func writeAll(files []*pendingFile, resolver *security.Resolver) (restored bool, err error) {
+	temps := make([]string, 0, len(files))
+	removeTemps := func(from int) {
+		for _, tmp := range temps[from:] {
+			resolver.Remove(tmp)
+		}
+	}
+	for _, f := range files {
+		tmp, err := writeTemp(f, resolver)
+		if err != nil {
+			removeTemps(0)
+			return true, fmt.Errorf("write %s: %w; no files were modified", f.display, err)
+		}
+		temps = append(temps, tmp)
+	}
+	for i, f := range files {
+		err := resolver.Rename(temps[i], f.abs)
+		if err == nil {
+			continue
+		}
+		removeTemps(i)
+		err = fmt.Errorf("replace %s: %w", f.display, err)
+		var failed []string
+		for _, w := range files[:i] {
+			if rerr := resolver.WriteFile(w.abs, []byte(w.before), 0644); rerr != nil {
+				failed = append(failed, fmt.Sprintf("%s (%v)", w.display, rerr))
+			}
+		}
+		if len(failed) > 0 {
+			return false, fmt.Errorf("%w; rollback failed for %s, use undo to restore them", err, strings.Join(failed, ", "))
+		}
+		return true, fmt.Errorf("%w; all files were restored", err)
+	}
+	return false, nil
+}
+
+// writeTemp 把文件的新内容写入同目录下的临时文件，并保留原文件的权限
+func writeTemp(f *pendingFile, resolver *security.Resolver) (string, error) {
+	orig, err := resolver.Open(f.abs)
+	if err != nil {
+		return "", err
+	}
+	info, err := orig.Stat()
+	orig.Close()
+	if err != nil {
+		return "", err
+	}
+	tmp := filepath.Join(filepath.Dir(f.abs), fmt.Sprintf(".%s.%d.tmp", filepath.Base(f.abs), time.Now().UnixNano()))
+	out, err := resolver.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+	_, err = out.WriteString(f.after)
+	if err == nil {
+		// 创建时的权限受 umask 影响，显式设置为与原文件一致
+		err = out.Chmod(info.Mode().Perm())
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		resolver.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
+
+func multiEditError(result *Result, i int, path string, err error) *Result {
+	result.Status = "error"
+	result.Error = fmt.Sprintf("edit #%d (%s): %v; no files were modified", i+1, path, err)
+	return result
+}
+
+// editOp 是 multi_edit 中的一次替换
+type editOp struct {
+	path string
+	spec editSpec
+}
+
+// parseEdits 接受 JSON 数组或 JSON 字符串（XML 参数）形式的替换列表，逐项校验参数
+func parseEdits(v interface{}) ([]editOp, error) {
+	var data []byte
+	switch v := v.(type) {
+	case nil:
+		return nil, errors.New("edits is required")
+	case string:
+		data = []byte(v)
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	var items []map[string]interface{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("edits must be an array of {path, old_string, new_string, replace_all}: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, errors.New("edits is empty")
+	}
+	ops := make([]editOp, 0, len(items))
+	for i, item := range items {
+		path, _ := item["path"].(string)
+		if path == "" {
+			return nil, fmt.Errorf("edit #%d: path is required", i+1)
+		}
+		if _, ok := item["old_string"].(string); !ok {
+			return nil, fmt.Errorf("edit #%d: old_string is required", i+1)
+		}
+		if _, ok := item["new_string"].(string); !ok {
+			return nil, fmt.Errorf("edit #%d: new_string is required", i+1)
+		}
+		spec, err := parseEditSpec(item)
+		if err != nil {
+			return nil, fmt.Errorf("edit #%d: %w", i+1, err)
+		}
+		ops = append(ops, editOp{path: path, spec: spec})
+	}
+	return ops, nil
+}
@@ -0,0 +1,141 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/afumu/openlink/internal/checkpoint"
+)
+
+func TestMultiEditTool(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := testConfig(t)
+	store := checkpoint.NewStore(t.TempDir())
+	ctx := func(edits interface{}) *Context {
+		return &Context{Args: map[string]interface{}{"edits": edits}, Config: cfg, Session: "s", Checkpoints: store}
+	}
+	a := filepath.Join(cfg.RootDir, "a.go")
+	b := filepath.Join(cfg.RootDir, "b.go")
+	reset := func() {
+		os.WriteFile(a, []byte("package a\n\nfunc old() {}\n"), 0644)
+		os.WriteFile(b, []byte("package b\n\nvar x = old()\nvar y = old()\n"), 0644)
+	}
+	read := func(path string) string {
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+	me := NewMultiEditTool(cfg)
+
+	t.Run("applies edits in order across files", func(t *testing.T) {
+		reset()
+		res := me.Execute(ctx([]interface{}{
+			map[string]interface{}{"path": "a.go", "old_string": "func old()", "new_string": "func mid()"},
+			map[string]interface{}{"path": "a.go", "old_string": "func mid()", "new_string": "func renamed()"},
+			map[string]interface{}{"path": "b.go", "old_string": "old()", "new_string": "renamed()", "replace_all": true},
+		}))
+		if res.Status != "success" {
+			t.Fatalf("multi_edit failed: %s", res.Error)
+		}
+		if got := read(a); got != "package a\n\nfunc renamed() {}\n" {
+			t.Errorf("a.go = %q", got)
+		}
+		if got := read(b); got != "package b\n\nvar x = renamed()\nvar y = renamed()\n" {
+			t.Errorf("b.go = %q", got)
+		}
+		if !strings.HasPrefix(res.Output, "已应用 3 处替换，修改 2 个文件\n\n已修改 a.go（+1 -1，#1 匹配方式 exact，第 3 行，#2 匹配方式 exact，第 3 行）") ||
+			!strings.Contains(res.Output, "+++ b/b.go") {
+			t.Errorf("got %q", res.Output)
+		}
+		if len(res.Diffs) != 2 || res.Diffs[0].Path != "a.go" || res.Diffs[1].Path != "b.go" || res.Diffs[1].Added != 2 {
+			t.Errorf("diffs = %+v", res.Diffs)
+		}
+	})
+
+	t.Run("writes nothing when any edit fails", func(t *testing.T) {
+		reset()
+		res := me.Execute(ctx(`[{"path":"a.go","old_string":"old","new_string":"new"},{"path":"b.go","old_string":"old()","new_string":"new()"}]`))
+		if res.Status != "error" || !strings.Contains(res.Error, "edit #2 (b.go): old_string is ambiguous") || !strings.Contains(res.Error, "no files were modified") {
+			t.Fatalf("got %s %s", res.Status, res.Error)
+		}
+		if got := read(a); got != "package a\n\nfunc old() {}\n" {
+			t.Errorf("a.go should be untouched, got %q", got)
+		}
+	})
+
+	t.Run("replaces files through temporary files", func(t *testing.T) {
+		reset()
+		os.Chmod(a, 0755)
+		defer os.Chmod(a, 0644)
+		res := me.Execute(ctx([]interface{}{map[string]interface{}{"path": "a.go", "old_string": "old", "new_string": "renamed"}}))
+		if res.Status != "success" {
+			t.Fatalf("multi_edit failed: %s", res.Error)
+		}
+		if info, err := os.Stat(a); err != nil || info.Mode().Perm() != 0755 {
+			t.Errorf("file mode should be preserved, got %v %v", info.Mode(), err)
+		}
+		if entries, _ := os.ReadDir(cfg.RootDir); len(entries) != 2 {
+			t.Errorf("temporary files left behind: %v", entries)
+		}
+	})
+
+	t.Run("failed write leaves every file unchanged", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("root ignores directory permissions")
+		}
+		reset()
+		sub := filepath.Join(cfg.RootDir, "ro")
+		os.MkdirAll(sub, 0755)
+		c := filepath.Join(sub, "c.go")
+		os.WriteFile(c, []byte("package c\n\nvar z = old()\n"), 0644)
+		os.Chmod(sub, 0555)
+		defer os.Chmod(sub, 0755)
+		res := me.Execute(ctx([]interface{}{
+			map[string]interface{}{"path": "a.go", "old_string": "old", "new_string": "new"},
+			map[string]interface{}{"path": "ro/c.go", "old_string": "old", "new_string": "new"},
+		}))
+		if res.Status != "error" || !strings.Contains(res.Error, "no files were modified") {
+			t.Fatalf("got %s %s", res.Status, res.Error)
+		}
+		if !strings.Contains(read(a), "func old()") || !strings.Contains(read(c), "old()") {
+			t.Errorf("files should be untouched: %q %q", read(a), read(c))
+		}
+		if entries, _ := os.ReadDir(cfg.RootDir); len(entries) != 3 {
+			t.Errorf("temporary files left behind: %v", entries)
+		}
+	})
+
+	t.Run("one undo restores every file", func(t *testing.T) {
+		reset()
+		res := me.Execute(ctx([]interface{}{
+			map[string]interface{}{"path": "a.go", "old_string": "old", "new_string": "new"},
+			map[string]interface{}{"path": "b.go", "old_string": "old", "new_string": "new", "occurrence": float64(2)},
+		}))
+		if res.Status != "success" || !strings.Contains(read(b), "var y = new()") {
+			t.Fatalf("multi_edit failed: %s %s", res.Error, read(b))
+		}
+		if res := NewUndoTool(store).Execute(&Context{Args: map[string]interface{}{}, Config: cfg, Session: "s"}); res.Status != "success" {
+			t.Fatalf("undo failed: %s", res.Error)
+		}
+		if !strings.Contains(read(a), "func old()") || strings.Contains(read(b), "new()") {
+			t.Errorf("files not restored: %q %q", read(a), read(b))
+		}
+	})
+}
+
+func TestParseEdits(t *testing.T) {
+	bad := []interface{}{
+		nil,
+		"not json",
+		[]interface{}{},
+		[]interface{}{map[string]interface{}{"old_string": "a", "new_string": "b"}},
+		[]interface{}{map[string]interface{}{"path": "a.go", "new_string": "b"}},
+		[]interface{}{map[string]interface{}{"path": "a.go", "old_string": "a", "new_string": "b", "replace_all": true, "occurrence": float64(1)}},
+	}
+	for _, v := range bad {
+		if _, err := parseEdits(v); err == nil {
+			t.Errorf("expected error for %v", v)
+		}
+	}
+}
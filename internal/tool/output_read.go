@@ -0,0 +1,157 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// OutputReadTool 读取被截断的工具输出：按行分页，或用正则搜索匹配行
+type OutputReadTool struct{}
+
+func NewOutputReadTool() *OutputReadTool {
+	return &OutputReadTool{}
+}
+
+func (t *OutputReadTool) Name() string { return "output_read" }
+func (t *OutputReadTool) Description() string {
+	return "Read a truncated tool output by its output ID, page by page or by regex search"
+}
+func (t *OutputReadTool) Parameters() interface{} {
+	return map[string]string{
+		"id":      "string (required) - output ID from the truncation notice",
+		"offset":  "number (optional) - start line number, 1-based (default: 1)",
+		"limit":   "number (optional) - max lines to return (default: 2000)",
+		"pattern": "string (optional) - regex; only return matching lines with their line numbers",
+		"context": "number (optional) - lines of context around each match (default: 0)",
+	}
+}
+
+func (t *OutputReadTool) Validate(args map[string]interface{}) error {
+	id, ok := args["id"].(string)
+	if !ok || id == "" {
+		return errors.New("id is required")
+	}
+	if _, err := StoredOutputFile(id); err != nil {
+		return err
+	}
+	if p, ok := args["pattern"].(string); ok && p != "" {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+func (t *OutputReadTool) Execute(ctx *Context) *Result {
+	result := &Result{StartTime: time.Now()}
+	id, _ := ctx.Args["id"].(string)
+	pattern, _ := ctx.Args["pattern"].(string)
+
+	offset, limit := 1, MaxLines
+	if v, ok := argInt(ctx.Args, "offset"); ok && v >= 1 {
+		offset = v
+	}
+	if v, ok := argInt(ctx.Args, "limit"); ok && v >= 1 && v < MaxLines {
+		limit = v
+	}
+	context := 0
+	if v, ok := argInt(ctx.Args, "context"); ok && v > 0 {
+		context = v
+	}
+
+	path, err := StoredOutputFile(id)
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("output %s not found (it may have been cleaned up)", id)
+		}
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	var output string
+	if pattern != "" {
+		output, err = grepLines(lines, pattern, offset, limit, context)
+		if err != nil {
+			result.Status = "error"
+			result.Error = err.Error()
+			return result
+		}
+	} else {
+		output = pageLines(lines, offset, limit)
+	}
+
+	result.Status = "success"
+	result.Output = output
+	result.EndTime = time.Now()
+	return result
+}
+
+// pageLines 返回从 offset 行开始、不超过 limit 行和 MaxBytes 字节的内容，每行带行号
+func pageLines(lines []string, offset, limit int) string {
+	if offset > len(lines) {
+		return fmt.Sprintf("empty (offset %d is past the end, %d total lines)", offset, len(lines))
+	}
+	var b strings.Builder
+	next := offset
+	for i := offset - 1; i < len(lines) && next-offset < limit; i++ {
+		line := fmt.Sprintf("%d: %s\n", i+1, lines[i])
+		if b.Len()+len(line) > MaxBytes && b.Len() > 0 {
+			break
+		}
+		b.WriteString(line)
+		next = i + 2
+	}
+	output := strings.TrimSuffix(b.String(), "\n")
+	if next <= len(lines) {
+		output += fmt.Sprintf("\n[truncated, %d total lines, use offset=%d to continue]", len(lines), next)
+	}
+	return output
+}
+
+// grepLines 返回从 offset 行开始匹配 pattern 的行，context 为每个匹配前后附带的行数
+func grepLines(lines []string, pattern string, offset, limit, context int) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+	var b strings.Builder
+	matches, last := 0, -1
+	for i := offset - 1; i < len(lines); i++ {
+		if !re.MatchString(lines[i]) {
+			continue
+		}
+		if matches >= limit || b.Len() > MaxBytes {
+			b.WriteString(fmt.Sprintf("[more matches, use offset=%d to continue]\n", i+1))
+			break
+		}
+		matches++
+		from, to := max(i-context, offset-1, last+1), min(i+context, len(lines)-1)
+		if context > 0 && last >= 0 && from > last+1 {
+			b.WriteString("--\n")
+		}
+		for j := from; j <= to; j++ {
+			sep := "-"
+			if j == i || re.MatchString(lines[j]) {
+				sep = ":"
+			}
+			b.WriteString(fmt.Sprintf("%d%s %s\n", j+1, sep, lines[j]))
+		}
+		last = to
+	}
+	if matches == 0 {
+		return "no matches", nil
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
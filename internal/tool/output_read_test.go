@@ -0,0 +1,71 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestOutputRead(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var b strings.Builder
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	b.WriteString("error: boom")
+	id, _, err := StoreOutput(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl := NewOutputReadTool()
+	run := func(args map[string]interface{}) *Result {
+		if err := tl.Validate(args); err != nil {
+			return &Result{Status: "error", Error: err.Error()}
+		}
+		return tl.Execute(&Context{Args: args})
+	}
+
+	t.Run("page", func(t *testing.T) {
+		res := run(map[string]interface{}{"id": id, "offset": "3", "limit": "2"})
+		want := "3: line 3\n4: line 4\n[truncated, 11 total lines, use offset=5 to continue]"
+		if res.Status != "success" || res.Output != want {
+			t.Errorf("got %s %q", res.Status, res.Output)
+		}
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		res := run(map[string]interface{}{"id": id, "offset": float64(10)})
+		if res.Output != "10: line 10\n11: error: boom" {
+			t.Errorf("got %q", res.Output)
+		}
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		res := run(map[string]interface{}{"id": id, "pattern": "^error|line 5$", "context": "1"})
+		want := "4- line 4\n5: line 5\n6- line 6\n--\n10- line 10\n11: error: boom"
+		if res.Output != want {
+			t.Errorf("got %q", res.Output)
+		}
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		res := run(map[string]interface{}{"id": id, "pattern": "nothing"})
+		if res.Output != "no matches" {
+			t.Errorf("got %q", res.Output)
+		}
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		res := run(map[string]interface{}{"id": "../settings.json"})
+		if res.Status != "error" {
+			t.Error("expected error for path-like id")
+		}
+	})
+
+	t.Run("missing output", func(t *testing.T) {
+		res := run(map[string]interface{}{"id": "20000101-000000-abcdef"})
+		if res.Status != "error" || !strings.Contains(res.Error, "not found") {
+			t.Errorf("got %s %q", res.Status, res.Error)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// PluginManifest 描述一个外部插件工具，放在 ~/.openlink/tools/ 或 <工作目录>/.openlink/tools/ 下的 *.json 文件中。
+// 插件通过与 ProcessTool 相同的 JSON stdin/stdout 协议执行，工作目录为工作区根目录。
+type PluginManifest struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+	Schema      map[string]interface{} `json:"schema"` // parameters 的别名
+	Executable  string                 `json:"executable"`
+	Args        []string               `json:"args"`
+	Timeout     int                    `json:"timeout"` // 秒，0 表示使用全局超时
+}
+
+var pluginNameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// PluginDirs 返回插件清单目录，工作区目录优先
+func PluginDirs(rootDir string) []string {
+	home, _ := os.UserHomeDir()
+	return []string{
+		filepath.Join(rootDir, ".openlink", "tools"),
+		filepath.Join(home, ".openlink", "tools"),
+	}
+}
+
+// LoadPlugins 读取全部插件清单。同名插件以优先级高的目录为准；无效清单跳过并返回错误列表。
+func LoadPlugins(rootDir string) ([]ProcessSpec, []error) {
+	var specs []ProcessSpec
+	var errs []error
+	seen := map[string]bool{}
+	for _, dir := range PluginDirs(rootDir) {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		for _, path := range matches {
+			spec, err := loadManifest(path, rootDir)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				continue
+			}
+			if seen[spec.Name] {
+				continue
+			}
+			seen[spec.Name] = true
+			specs = append(specs, spec)
+		}
+	}
+	return specs, errs
+}
+
+func loadManifest(path, rootDir string) (ProcessSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ProcessSpec{}, err
+	}
+	var m PluginManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return ProcessSpec{}, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if !pluginNameRe.MatchString(m.Name) {
+		return ProcessSpec{}, fmt.Errorf("invalid tool name %q", m.Name)
+	}
+	if strings.TrimSpace(m.Executable) == "" {
+		return ProcessSpec{}, fmt.Errorf("executable is required")
+	}
+	if m.Timeout < 0 {
+		return ProcessSpec{}, fmt.Errorf("timeout must not be negative")
+	}
+	exe := m.Executable
+	if !filepath.IsAbs(exe) && strings.ContainsAny(exe, `/\`) {
+		// 相对路径相对于清单所在目录
+		exe = filepath.Join(filepath.Dir(path), exe)
+	}
+	params := m.Parameters
+	if params == nil {
+		params = m.Schema
+	}
+	desc := m.Description
+	if desc == "" {
+		desc = "External tool " + m.Name
+	}
+	return ProcessSpec{
+		Name:        m.Name,
+		Description: desc,
+		Parameters:  params,
+		Argv:        append([]string{exe}, m.Args...),
+		Dir:         rootDir,
+		Timeout:     time.Duration(m.Timeout) * time.Second,
+	}, nil
+}
@@ -0,0 +1,168 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ProcessSpec 描述一个由外部进程实现的工具。调用时把请求以 JSON 写入 stdin，
+// 从 stdout 读取 JSON 结果：
+//
+//	stdin:  {"tool": "...", "args": {...}, "session": "...", "workspace": "..."}
+//	stdout: {"status": "success|error", "output": "...", "error": "..."}
+//
+// stdout 不是 JSON 对象时整体作为输出；进程以非零状态退出视为失败。
+type ProcessSpec struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{} // JSON Schema
+	Command     string                 // 通过 shell 执行
+	Argv        []string               // 直接执行，Command 为空时使用
+	Dir         string                 // 工作目录
+	Timeout     time.Duration          // 为 0 时使用 Config.Timeout
+}
+
+type ProcessTool struct {
+	spec ProcessSpec
+}
+
+func NewProcessTool(spec ProcessSpec) *ProcessTool {
+	return &ProcessTool{spec: spec}
+}
+
+func (t *ProcessTool) Name() string        { return t.spec.Name }
+func (t *ProcessTool) Description() string { return t.spec.Description }
+
+func (t *ProcessTool) Parameters() interface{} {
+	if t.spec.Parameters == nil {
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return t.spec.Parameters
+}
+
+// Validate 检查 schema 中 required 列出的参数是否齐全
+func (t *ProcessTool) Validate(args map[string]interface{}) error {
+	required, _ := t.spec.Parameters["required"].([]interface{})
+	for _, r := range required {
+		name := fmt.Sprint(r)
+		if v, ok := args[name]; !ok || v == nil || v == "" {
+			return fmt.Errorf("%s is required", name)
+		}
+	}
+	return nil
+}
+
+type processRequest struct {
+	Tool      string                 `json:"tool"`
+	Args      map[string]interface{} `json:"args"`
+	Session   string                 `json:"session,omitempty"`
+	Workspace string                 `json:"workspace,omitempty"`
+}
+
+type processResponse struct {
+	Status string `json:"status"`
+	Output string `json:"output"`
+	Error  string `json:"error"`
+}
+
+func (t *ProcessTool) Execute(ctx *Context) *Result {
+	result := &Result{StartTime: time.Now()}
+
+	timeout := t.spec.Timeout
+	if timeout <= 0 && ctx.Config != nil {
+		timeout = time.Duration(ctx.Config.Timeout) * time.Second
+	}
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	execCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var proc *exec.Cmd
+	if t.spec.Command != "" {
+		shell, flag := getShell()
+		proc = exec.CommandContext(execCtx, shell, flag, t.spec.Command)
+	} else if len(t.spec.Argv) > 0 {
+		name := t.spec.Argv[0]
+		if !filepath.IsAbs(name) && strings.ContainsAny(name, `/\`) {
+			name = filepath.Join(t.spec.Dir, name)
+		}
+		proc = exec.CommandContext(execCtx, name, t.spec.Argv[1:]...)
+	} else {
+		result.Status = "error"
+		result.Error = fmt.Sprintf("tool %s has no command", t.spec.Name)
+		return result
+	}
+
+	workspace := ""
+	if ctx.Config != nil {
+		workspace = ctx.Config.RootDir
+	}
+	args := ctx.Args
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	input, err := json.Marshal(processRequest{Tool: t.spec.Name, Args: args, Session: ctx.Session, Workspace: workspace})
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+
+	var stdout, stderr bytes.Buffer
+	proc.Dir = t.spec.Dir
+	proc.Stdin = bytes.NewReader(input)
+	proc.Stdout = &stdout
+	proc.Stderr = &stderr
+	proc.Env = append(os.Environ(), "OPENLINK_WORKSPACE="+workspace, "OPENLINK_SESSION="+ctx.Session)
+	err = proc.Run()
+	result.EndTime = time.Now()
+
+	if execCtx.Err() == context.DeadlineExceeded {
+		result.Status = "error"
+		result.Error = fmt.Sprintf("tool %s timed out after %s", t.spec.Name, timeout)
+		return result
+	}
+
+	var resp processResponse
+	out := strings.TrimSpace(stdout.String())
+	if strings.HasPrefix(out, "{") && json.Unmarshal([]byte(out), &resp) == nil && (resp.Status != "" || resp.Output != "" || resp.Error != "") {
+		if resp.Status == "" {
+			resp.Status = "success"
+			if resp.Error != "" {
+				resp.Status = "error"
+			}
+		}
+	} else {
+		resp = processResponse{Status: "success", Output: stdout.String()}
+	}
+
+	if err != nil {
+		resp.Status = "error"
+		if resp.Error == "" {
+			resp.Error = strings.TrimSpace(stderr.String())
+		}
+		if resp.Error == "" {
+			resp.Error = err.Error()
+		}
+	}
+	if resp.Status != "success" && resp.Status != "error" {
+		resp.Status = "error"
+		resp.Error = fmt.Sprintf("tool %s returned invalid status %q", t.spec.Name, resp.Status)
+	}
+
+	result.Status = resp.Status
+	result.Output = TruncateFor(ctx, t.spec.Name, resp.Output)
+	result.Error = resp.Error
+	if result.Status == "success" && result.Output == "" {
+		result.Output = "empty"
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+func TestProcessTool(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh scripts")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "echo.sh")
+	os.WriteFile(script, []byte("#!/bin/sh\ninput=$(cat)\nprintf '{\"status\":\"success\",\"output\":\"pwd=%s in=%s\"}' \"$(basename \"$PWD\")\" \"$(echo \"$input\" | tr -d '\"')\"\n"), 0755)
+	cfg := &types.Config{RootDir: t.TempDir(), Timeout: 10}
+
+	t.Run("JSON protocol with skill dir as cwd", func(t *testing.T) {
+		tool := NewProcessTool(ProcessSpec{Name: "demo.echo", Argv: []string{"./echo.sh"}, Dir: dir})
+		res := tool.Execute(&Context{Args: map[string]interface{}{"msg": "hi"}, Config: cfg, Session: "s1"})
+		if res.Status != "success" {
+			t.Fatalf("expected success: %s", res.Error)
+		}
+		if !strings.Contains(res.Output, "pwd="+filepath.Base(dir)) || !strings.Contains(res.Output, "msg:hi") || !strings.Contains(res.Output, "tool:demo.echo") {
+			t.Errorf("got %q", res.Output)
+		}
+	})
+
+	t.Run("plain stdout is used as output", func(t *testing.T) {
+		tool := NewProcessTool(ProcessSpec{Name: "x", Command: "echo plain", Dir: dir})
+		res := tool.Execute(&Context{Config: cfg})
+		if res.Status != "success" || strings.TrimSpace(res.Output) != "plain" {
+			t.Errorf("got %s %q", res.Status, res.Output)
+		}
+	})
+
+	t.Run("non-zero exit reports stderr", func(t *testing.T) {
+		tool := NewProcessTool(ProcessSpec{Name: "x", Command: "echo boom >&2; exit 3", Dir: dir})
+		res := tool.Execute(&Context{Config: cfg})
+		if res.Status != "error" || res.Error != "boom" {
+			t.Errorf("got %s %q", res.Status, res.Error)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		tool := NewProcessTool(ProcessSpec{Name: "x", Command: "sleep 5", Dir: dir, Timeout: 100 * time.Millisecond})
+		res := tool.Execute(&Context{Config: cfg})
+		if res.Status != "error" || !strings.Contains(res.Error, "timed out") {
+			t.Errorf("got %s %q", res.Status, res.Error)
+		}
+	})
+
+	t.Run("validate uses schema required", func(t *testing.T) {
+		tool := NewProcessTool(ProcessSpec{Name: "x", Command: "true", Parameters: map[string]interface{}{
+			"type": "object", "required": []interface{}{"path"},
+		}})
+		if err := tool.Validate(map[string]interface{}{}); err == nil {
+			t.Error("expected error")
+		}
+		if err := tool.Validate(map[string]interface{}{"path": "a"}); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestLoadPlugins(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, ".openlink", "tools")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "greet.json"), []byte(`{
+		"name": "greet",
+		"description": "say hello",
+		"parameters": {"type": "object", "properties": {"who": {"type": "string"}}, "required": ["who"]},
+		"executable": "./greet.sh",
+		"timeout": 5
+	}`), 0644)
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"name": "bad name", "executable": "x"}`), 0644)
+	os.WriteFile(filepath.Join(dir, "greet.sh"), []byte("#!/bin/sh\ncat >/dev/null\necho '{\"status\":\"success\",\"output\":\"hello from '\"$(basename \"$PWD\")\"'\"}'\n"), 0755)
+
+	specs, errs := LoadPlugins(root)
+	if len(specs) != 1 || len(errs) != 1 {
+		t.Fatalf("specs=%+v errs=%v", specs, errs)
+	}
+	spec := specs[0]
+	if spec.Argv[0] != filepath.Join(dir, "greet.sh") || spec.Dir != root || spec.Timeout != 5*time.Second {
+		t.Errorf("got %+v", spec)
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	tool := NewProcessTool(spec)
+	if err := tool.Validate(map[string]interface{}{}); err == nil {
+		t.Error("expected required parameter error")
+	}
+	res := tool.Execute(&Context{Args: map[string]interface{}{"who": "x"}, Config: &types.Config{RootDir: root, Timeout: 10}})
+	if res.Status != "success" || res.Output != "hello from "+filepath.Base(root) {
+		t.Errorf("got %s %q %s", res.Status, res.Output, res.Error)
+	}
+}
@@ -1,21 +1,37 @@
 package tool
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/afumu/openlink/internal/question"
+)
+
+const (
+	defaultQuestionTimeout = 5 * time.Minute
+	maxQuestionTimeout     = 30 * time.Minute
 )
 
-type QuestionTool struct{}
+type QuestionTool struct {
+	broker *question.Broker
+}
 
-func NewQuestionTool() *QuestionTool { return &QuestionTool{} }
+// NewQuestionTool 创建 question 工具；broker 为 nil 时只格式化问题并立即返回（无人值守场景）
+func NewQuestionTool(broker *question.Broker) *QuestionTool {
+	return &QuestionTool{broker: broker}
+}
 
 func (t *QuestionTool) Name() string        { return "question" }
 func (t *QuestionTool) Description() string { return "Ask the user a question and wait for input" }
 func (t *QuestionTool) Parameters() interface{} {
 	return map[string]string{
 		"question": "string (required) - the question to ask",
-		"options":  "array (optional) - list of choices to present",
+		"type":     "string (optional) - 'text' (default), 'single', 'multi' or 'confirm' (yes/no)",
+		"options":  "array (optional) - list of choices for single/multi",
+		"default":  "string|array (optional) - answer used when the user does not reply in time",
+		"timeout":  fmt.Sprintf("number (optional) - seconds to wait for the answer (default: %d)", int(defaultQuestionTimeout.Seconds())),
 	}
 }
 
@@ -23,28 +39,82 @@ func (t *QuestionTool) Validate(args map[string]interface{}) error {
 	if q, ok := args["question"].(string); !ok || q == "" {
 		return fmt.Errorf("question is required")
 	}
+	if typ, _ := args["type"].(string); typ != "" {
+		switch typ {
+		case question.TypeText, question.TypeConfirm:
+		case question.TypeSingle, question.TypeMulti:
+			if len(argStrings(args, "options")) == 0 {
+				return fmt.Errorf("options are required for %s questions", typ)
+			}
+		default:
+			return fmt.Errorf("unknown question type: %s", typ)
+		}
+	}
 	return nil
 }
 
 func (t *QuestionTool) Execute(ctx *Context) *Result {
 	result := &Result{StartTime: time.Now()}
-	question, _ := ctx.Args["question"].(string)
-	options, _ := ctx.Args["options"].([]interface{})
+	q := question.Question{
+		Question: ctx.Args["question"].(string),
+		Options:  argStrings(ctx.Args, "options"),
+		Default:  argStrings(ctx.Args, "default"),
+	}
+	q.Type, _ = ctx.Args["type"].(string)
+
+	if t.broker == nil {
+		result.Status = "success"
+		result.Output = formatQuestion(q)
+		result.EndTime = time.Now()
+		return result
+	}
+
+	timeout := defaultQuestionTimeout
+	if n, ok := argInt(ctx.Args, "timeout"); ok && n > 0 {
+		timeout = time.Duration(n) * time.Second
+		if timeout > maxQuestionTimeout {
+			timeout = maxQuestionTimeout
+		}
+	}
+
+	askCtx := ctx.Ctx
+	if askCtx == nil {
+		askCtx = context.Background()
+	}
+	ans, err := t.broker.Ask(askCtx, q, timeout)
+	result.EndTime = time.Now()
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		if err == question.ErrTimeout {
+			result.Error = fmt.Sprintf("用户未在 %d 秒内回答", int(timeout.Seconds()))
+		}
+		return result
+	}
 
+	var sb strings.Builder
+	if ans.Defaulted {
+		fmt.Fprintf(&sb, "[用户未在 %d 秒内回答，使用默认答案]\n", int(timeout.Seconds()))
+	} else {
+		sb.WriteString("[用户回答]\n")
+	}
+	fmt.Fprintf(&sb, "问题: %s\n回答: %s", q.Question, ans.String())
+	result.Status = "success"
+	result.Output = sb.String()
+	return result
+}
+
+func formatQuestion(q question.Question) string {
 	var sb strings.Builder
 	sb.WriteString("[需要您的输入]\n\n")
-	sb.WriteString(question)
+	sb.WriteString(q.Question)
 
-	if len(options) > 0 {
+	if len(q.Options) > 0 {
 		sb.WriteString("\n\n可选项：")
-		for i, opt := range options {
+		for i, opt := range q.Options {
 			sb.WriteString(fmt.Sprintf("\n  %d. %v", i+1, opt))
 		}
 		sb.WriteString("\n\n请输入您的选择或回答：")
 	}
-
-	result.Status = "success"
-	result.Output = sb.String()
-	result.EndTime = time.Now()
-	return result
+	return sb.String()
 }
@@ -4,8 +4,6 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -61,27 +59,15 @@ func (t *ReadFileTool) Execute(ctx *Context) *Result {
 		}
 	}
 
-	var safePath string
-	var err error
-	if filepath.IsAbs(path) {
-		home, _ := os.UserHomeDir()
-		roots := []string{
-			ctx.Config.RootDir,
-			filepath.Join(home, ".claude"),
-			filepath.Join(home, ".openlink"),
-			filepath.Join(home, ".agent"),
-		}
-		safePath, err = security.SafeAbsPath(path, roots...)
-	} else {
-		safePath, err = security.SafePath(ctx.Config.RootDir, path)
-	}
+	resolver := security.NewResolver(ctx.Config)
+	safePath, err := resolver.Resolve(path, security.Read)
 	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
 		return result
 	}
 
-	f, err := os.Open(safePath)
+	f, err := resolver.Open(safePath)
 	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
@@ -28,6 +28,15 @@ func (r *Registry) Register(tool Tool) error {
 	return nil
 }
 
+// Unregister 移除工具，返回工具是否存在
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, exists := r.tools[name]
+	delete(r.tools, name)
+	return exists
+}
+
 func (r *Registry) Get(name string) (Tool, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
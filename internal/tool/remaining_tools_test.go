@@ -1,11 +1,16 @@
 package tool
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/afumu/openlink/internal/question"
+	"github.com/afumu/openlink/internal/skill"
+	"github.com/afumu/openlink/internal/todo"
 	"github.com/afumu/openlink/internal/types"
 )
 
@@ -43,7 +48,7 @@ func TestListDirTool(t *testing.T) {
 }
 
 func TestQuestionTool(t *testing.T) {
-	tool := NewQuestionTool()
+	tool := NewQuestionTool(nil)
 
 	t.Run("returns question in output", func(t *testing.T) {
 		res := tool.Execute(&Context{Args: map[string]interface{}{"question": "What is your name?"}})
@@ -67,6 +72,67 @@ func TestQuestionTool(t *testing.T) {
 			t.Error("expected error")
 		}
 	})
+
+	t.Run("validate rejects choice question without options", func(t *testing.T) {
+		if err := tool.Validate(map[string]interface{}{"question": "x", "type": "multi"}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestQuestionToolBlocking(t *testing.T) {
+	broker := question.NewBroker()
+	tool := NewQuestionTool(broker)
+
+	t.Run("waits for answer", func(t *testing.T) {
+		done := make(chan *Result, 1)
+		go func() {
+			done <- tool.Execute(&Context{Args: map[string]interface{}{
+				"question": "Pick one",
+				"options":  `["A","B"]`,
+			}})
+		}()
+		var pending []question.Question
+		for i := 0; i < 200 && len(pending) == 0; i++ {
+			time.Sleep(5 * time.Millisecond)
+			pending = broker.Pending()
+		}
+		if len(pending) != 1 || pending[0].Type != question.TypeSingle {
+			t.Fatalf("expected one pending single-choice question, got %+v", pending)
+		}
+		if _, err := broker.Answer(pending[0].ID, "B"); err != nil {
+			t.Fatal(err)
+		}
+		res := <-done
+		if res.Status != "success" || !strings.Contains(res.Output, "回答: B") {
+			t.Errorf("got status=%s output=%q", res.Status, res.Output)
+		}
+	})
+
+	t.Run("timeout falls back to default", func(t *testing.T) {
+		res := tool.Execute(&Context{Args: map[string]interface{}{
+			"question": "Continue?",
+			"type":     "confirm",
+			"default":  "no",
+			"timeout":  "1",
+		}})
+		if res.Status != "success" || !strings.Contains(res.Output, "默认答案") || !strings.Contains(res.Output, "no") {
+			t.Errorf("got status=%s output=%q", res.Status, res.Output)
+		}
+	})
+
+	t.Run("cancelled request stops waiting", func(t *testing.T) {
+		reqCtx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		start := time.Now()
+		res := tool.Execute(&Context{Ctx: reqCtx, Args: map[string]interface{}{"question": "Name?"}})
+		if res.Status != "error" || time.Since(start) > 5*time.Second {
+			t.Errorf("got status=%s after %s", res.Status, time.Since(start))
+		}
+		if len(broker.Pending()) != 0 {
+			t.Error("cancelled question should no longer be pending")
+		}
+	})
 }
 
 func TestInvalidTool(t *testing.T) {
@@ -79,23 +145,70 @@ func TestInvalidTool(t *testing.T) {
 
 func TestTodoWriteTool(t *testing.T) {
 	cfg := &types.Config{RootDir: t.TempDir(), Timeout: 10}
-	tool := NewTodoWriteTool(cfg)
+	dir := t.TempDir()
+	store := todo.NewStore(dir)
+	tool := NewTodoWriteTool(store)
+	read := NewTodoReadTool(store)
 
-	t.Run("writes todos to file", func(t *testing.T) {
-		todos := []interface{}{"task1", "task2"}
-		res := tool.Execute(testCtx(cfg, map[string]interface{}{"todos": todos}))
+	t.Run("writes todos to session file", func(t *testing.T) {
+		todos := []interface{}{
+			map[string]interface{}{"content": "task1", "status": "in_progress", "priority": "high"},
+			map[string]interface{}{"content": "task2"},
+		}
+		ctx := testCtx(cfg, map[string]interface{}{"todos": todos})
+		ctx.Session = "s1"
+		res := tool.Execute(ctx)
 		if res.Status != "success" {
 			t.Fatalf("expected success: %s", res.Error)
 		}
-		if _, err := os.Stat(filepath.Join(cfg.RootDir, ".todos.json")); err != nil {
-			t.Error("expected .todos.json to exist")
+		if _, err := os.Stat(filepath.Join(dir, "s1.json")); err != nil {
+			t.Error("expected s1.json to exist")
 		}
 	})
 
-	t.Run("validate rejects missing todos", func(t *testing.T) {
+	t.Run("merge updates by id and todo_read reads back", func(t *testing.T) {
+		ctx := testCtx(cfg, map[string]interface{}{
+			"todos": `[{"id":"1","status":"completed"},{"content":"task3","priority":"low"}]`,
+			"merge": "true",
+		})
+		ctx.Session = "s1"
+		if res := tool.Execute(ctx); res.Status != "success" {
+			t.Fatalf("merge failed: %s", res.Error)
+		}
+		readCtx := testCtx(cfg, map[string]interface{}{})
+		readCtx.Session = "s1"
+		res := read.Execute(readCtx)
+		for _, want := range []string{"[x] 1. task1", "[ ] 2. task2", "[ ] 3. task3 (low)", "1/3"} {
+			if !strings.Contains(res.Output, want) {
+				t.Errorf("expected %q in %q", want, res.Output)
+			}
+		}
+	})
+
+	t.Run("sessions are isolated", func(t *testing.T) {
+		ctx := testCtx(cfg, map[string]interface{}{})
+		ctx.Session = "s2"
+		if res := read.Execute(ctx); res.Output != "暂无任务" {
+			t.Errorf("got %q", res.Output)
+		}
+	})
+
+	t.Run("invalid status rejected", func(t *testing.T) {
+		res := tool.Execute(testCtx(cfg, map[string]interface{}{
+			"todos": []interface{}{map[string]interface{}{"content": "x", "status": "done"}},
+		}))
+		if res.Status != "error" {
+			t.Error("expected error for invalid status")
+		}
+	})
+
+	t.Run("validate rejects missing or malformed todos", func(t *testing.T) {
 		if err := tool.Validate(map[string]interface{}{}); err == nil {
 			t.Error("expected error")
 		}
+		if err := tool.Validate(map[string]interface{}{"todos": []interface{}{"task1"}}); err == nil {
+			t.Error("expected error for non-object items")
+		}
 	})
 }
 
@@ -103,7 +216,7 @@ func TestSkillTool(t *testing.T) {
 	cfg := &types.Config{RootDir: t.TempDir(), Timeout: 10}
 
 	t.Run("lists skills when no name given", func(t *testing.T) {
-		tool := NewSkillTool(cfg)
+		tool := NewSkillTool(skill.NewIndex(cfg.RootDir), nil)
 		res := tool.Execute(testCtx(cfg, map[string]interface{}{}))
 		if res.Status != "success" {
 			t.Fatalf("expected success: %s", res.Error)
@@ -111,7 +224,7 @@ func TestSkillTool(t *testing.T) {
 	})
 
 	t.Run("returns error for unknown skill", func(t *testing.T) {
-		tool := NewSkillTool(cfg)
+		tool := NewSkillTool(skill.NewIndex(cfg.RootDir), nil)
 		res := tool.Execute(testCtx(cfg, map[string]interface{}{"skill": "nonexistent"}))
 		if res.Status != "error" {
 			t.Error("expected error for unknown skill")
@@ -122,10 +235,35 @@ func TestSkillTool(t *testing.T) {
 		sub := filepath.Join(cfg.RootDir, ".skills", "mything")
 		os.MkdirAll(sub, 0755)
 		os.WriteFile(filepath.Join(sub, "SKILL.md"), []byte("---\nname: mything\ndescription: test\n---\nskill content"), 0644)
-		tool := NewSkillTool(cfg)
+		tool := NewSkillTool(skill.NewIndex(cfg.RootDir), nil)
 		res := tool.Execute(testCtx(cfg, map[string]interface{}{"skill": "mything"}))
 		if res.Status != "success" || !strings.Contains(res.Output, "skill content") {
 			t.Errorf("got status=%s output=%q", res.Status, res.Output)
 		}
 	})
+
+	t.Run("substitutes arguments", func(t *testing.T) {
+		sub := filepath.Join(cfg.RootDir, ".skills", "greet")
+		os.MkdirAll(sub, 0755)
+		os.WriteFile(filepath.Join(sub, "SKILL.md"), []byte("---\nname: greet\ndescription: greet someone\narguments: [who]\n---\nHello {{who}} ($ARGUMENTS)"), 0644)
+		tool := NewSkillTool(skill.NewIndex(cfg.RootDir), nil)
+		res := tool.Execute(testCtx(cfg, map[string]interface{}{"skill": "greet", "arguments": `{"who":"alice"}`}))
+		if res.Status != "success" || !strings.Contains(res.Output, "Hello alice (alice)") || strings.Contains(res.Output, "name: greet") {
+			t.Errorf("got status=%s output=%q", res.Status, res.Output)
+		}
+	})
+
+	t.Run("broken skill is hidden and reports diagnostics", func(t *testing.T) {
+		sub := filepath.Join(cfg.RootDir, ".skills", "broken")
+		os.MkdirAll(sub, 0755)
+		os.WriteFile(filepath.Join(sub, "SKILL.md"), []byte("---\nname: broken\ndescription: [oops\n---\n"), 0644)
+		tool := NewSkillTool(skill.NewIndex(cfg.RootDir), nil)
+		if strings.Contains(tool.Description(), "<name>broken</name>") {
+			t.Error("broken skill should not be listed")
+		}
+		res := tool.Execute(testCtx(cfg, map[string]interface{}{"skill": "broken"}))
+		if res.Status != "error" || !strings.Contains(res.Error, "invalid frontmatter") {
+			t.Errorf("got status=%s error=%q", res.Status, res.Error)
+		}
+	})
 }
@@ -1,6 +1,7 @@
 package tool
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,35 +9,44 @@ import (
 	"time"
 
 	"github.com/afumu/openlink/internal/skill"
-	"github.com/afumu/openlink/internal/types"
 )
 
 type SkillTool struct {
-	config *types.Config
+	skills *skill.Index
+	active *skill.Activations
 }
 
-func NewSkillTool(config *types.Config) *SkillTool {
-	return &SkillTool{config: config}
+// NewSkillTool 创建 skill 工具；active 记录会话当前激活的 skill，为 nil 时不追踪激活状态
+func NewSkillTool(skills *skill.Index, active *skill.Activations) *SkillTool {
+	return &SkillTool{skills: skills, active: active}
 }
 
 func (t *SkillTool) Name() string { return "skill" }
 func (t *SkillTool) Description() string {
-	infos := skill.LoadInfos(t.config.RootDir)
+	infos := skill.Usable(t.skills.List())
 	if len(infos) == 0 {
 		return "Load a specialized skill from skills directories"
 	}
 	var sb strings.Builder
 	sb.WriteString("Load a specialized skill from skills directories\n<available_skills>")
 	for _, s := range infos {
-		fmt.Fprintf(&sb, "\n  <skill><name>%s</name><description>%s</description><location>file://%s</location></skill>",
-			s.Name, s.Description, s.Location)
+		fmt.Fprintf(&sb, "\n  <skill><name>%s</name><description>%s</description>", s.Name, s.Description)
+		if s.WhenToUse != "" {
+			fmt.Fprintf(&sb, "<when_to_use>%s</when_to_use>", s.WhenToUse)
+		}
+		if len(s.Arguments) > 0 {
+			fmt.Fprintf(&sb, "<arguments>%s</arguments>", argumentNames(s.Arguments))
+		}
+		fmt.Fprintf(&sb, "<location>file://%s</location></skill>", s.Location)
 	}
 	sb.WriteString("\n</available_skills>")
 	return sb.String()
 }
 func (t *SkillTool) Parameters() interface{} {
 	return map[string]string{
-		"skill": "string (optional) - skill name to load; omit to list available skills",
+		"skill":     "string (optional) - skill name to load; omit to list available skills",
+		"arguments": "string (optional) - arguments for the skill: free text, or a JSON object keyed by argument name",
+		"release":   "boolean (optional) - deactivate the current skill and lift its allowed-tools restriction",
 	}
 }
 func (t *SkillTool) Validate(args map[string]interface{}) error { return nil }
@@ -45,8 +55,20 @@ func (t *SkillTool) Execute(ctx *Context) *Result {
 	result := &Result{StartTime: time.Now()}
 	skillName, _ := ctx.Args["skill"].(string)
 
+	if release, _ := argBool(ctx.Args, "release"); release && skillName == "" {
+		result.Status = "success"
+		result.Output = "当前没有激活的 skill"
+		if t.active != nil {
+			if info, ok := t.active.Release(ctx.Session); ok {
+				result.Output = fmt.Sprintf("已释放 skill %s，工具限制已解除", info.Name)
+			}
+		}
+		result.EndTime = time.Now()
+		return result
+	}
+
 	if skillName == "" {
-		infos := skill.LoadInfos(ctx.Config.RootDir)
+		infos := skill.Usable(t.skills.List())
 		if len(infos) == 0 {
 			result.Status = "success"
 			result.Output = "没有找到可用的 skills"
@@ -63,14 +85,31 @@ func (t *SkillTool) Execute(ctx *Context) *Result {
 		return result
 	}
 
-	info, ok := skill.Get(ctx.Config.RootDir, skillName)
+	info, ok := t.skills.Get(skillName)
 	if !ok {
 		result.Status = "error"
 		result.Error = fmt.Sprintf("skill %q not found", skillName)
 		return result
 	}
 
-	data, err := os.ReadFile(info.Location)
+	info, body, err := skill.Load(info)
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	if info.Broken() {
+		result.Status = "error"
+		result.Error = fmt.Sprintf("skill %q has invalid frontmatter: %s", skillName, diagnosticText(info.Diagnostics))
+		return result
+	}
+	rawArgs, err := skillArguments(ctx.Args["arguments"])
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	body, err = info.Render(body, rawArgs)
 	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
@@ -96,7 +135,11 @@ func (t *SkillTool) Execute(ctx *Context) *Result {
 	}
 
 	var out strings.Builder
-	fmt.Fprintf(&out, "<skill_content name=%q>\n", skillName)
+	fmt.Fprintf(&out, "<skill_content name=%q", info.Name)
+	if info.Version != "" {
+		fmt.Fprintf(&out, " version=%q", info.Version)
+	}
+	out.WriteString(">\n")
 	fmt.Fprintf(&out, "IMPORTANT: All file paths referenced in this skill must use absolute paths. The skill directory is: %s\n", info.Dir)
 	if len(siblingPaths) > 0 {
 		fmt.Fprintf(&out, "Available files in skill directory (use these absolute paths directly):\n")
@@ -104,12 +147,66 @@ func (t *SkillTool) Execute(ctx *Context) *Result {
 			fmt.Fprintf(&out, "  - %s\n", p)
 		}
 	}
+	if len(info.Tools) > 0 {
+		out.WriteString("Tools provided by this skill (call them like any other tool):\n")
+		for _, spec := range info.Tools {
+			fmt.Fprintf(&out, "  - %s: %s\n", info.QualifiedName(spec), spec.Description)
+		}
+	}
+	if len(info.AllowedTools) > 0 {
+		fmt.Fprintf(&out, "While this skill is active only these tools may be used: %s (call skill with release=true when done)\n", strings.Join(info.AllowedTools, ", "))
+	}
 	out.WriteString("\n")
-	out.Write(data)
+	out.WriteString(body)
 	out.WriteString("\n</skill_content>")
 
+	if t.active != nil {
+		t.active.Activate(ctx.Session, info)
+	}
 	result.Status = "success"
 	result.Output = out.String()
 	result.EndTime = time.Now()
 	return result
 }
+
+// skillArguments 解析 arguments 参数：对象原样使用，以 { 开头的字符串按 JSON 对象解析，其余视为自由文本
+func skillArguments(v interface{}) (interface{}, error) {
+	switch a := v.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return a, nil
+	case string:
+		if trimmed := strings.TrimSpace(a); strings.HasPrefix(trimmed, "{") {
+			var obj map[string]interface{}
+			if err := json.Unmarshal([]byte(trimmed), &obj); err != nil {
+				return nil, fmt.Errorf("invalid arguments JSON: %w", err)
+			}
+			return obj, nil
+		}
+		return a, nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+func argumentNames(args []skill.Argument) string {
+	names := make([]string, 0, len(args))
+	for _, a := range args {
+		if a.Required {
+			names = append(names, a.Name+"*")
+		} else {
+			names = append(names, a.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func diagnosticText(diags []skill.Diagnostic) string {
+	var parts []string
+	for _, d := range diags {
+		if d.Severity == skill.SeverityError {
+			parts = append(parts, d.Message)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,34 @@
+package tool
+
+import (
+	"time"
+
+	"github.com/afumu/openlink/internal/todo"
+)
+
+type TodoReadTool struct {
+	store *todo.Store
+}
+
+func NewTodoReadTool(store *todo.Store) *TodoReadTool {
+	return &TodoReadTool{store: store}
+}
+
+func (t *TodoReadTool) Name() string                               { return "todo_read" }
+func (t *TodoReadTool) Description() string                        { return "Read the current session task list" }
+func (t *TodoReadTool) Parameters() interface{}                    { return map[string]string{} }
+func (t *TodoReadTool) Validate(args map[string]interface{}) error { return nil }
+
+func (t *TodoReadTool) Execute(ctx *Context) *Result {
+	result := &Result{StartTime: time.Now()}
+	items, err := t.store.Get(ctx.Session)
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	result.Status = "success"
+	result.Output = todo.Render(items)
+	result.EndTime = time.Now()
+	return result
+}
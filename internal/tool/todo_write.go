@@ -4,26 +4,27 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 
-	"github.com/afumu/openlink/internal/types"
+	"github.com/afumu/openlink/internal/todo"
 )
 
 type TodoWriteTool struct {
-	config *types.Config
+	store *todo.Store
 }
 
-func NewTodoWriteTool(config *types.Config) *TodoWriteTool {
-	return &TodoWriteTool{config: config}
+func NewTodoWriteTool(store *todo.Store) *TodoWriteTool {
+	return &TodoWriteTool{store: store}
 }
 
-func (t *TodoWriteTool) Name() string        { return "todo_write" }
-func (t *TodoWriteTool) Description() string { return "Write task list to .todos.json" }
+func (t *TodoWriteTool) Name() string { return "todo_write" }
+func (t *TodoWriteTool) Description() string {
+	return "Write the session task list (items: id, content, status pending/in_progress/completed, priority high/medium/low)"
+}
 func (t *TodoWriteTool) Parameters() interface{} {
 	return map[string]string{
-		"todos": "array (required) - full list of todo items to save",
+		"todos": "array (required) - todo items {id, content, status, priority}; full list unless merge=true",
+		"merge": "bool (optional) - update items by id and append new ones instead of replacing the list",
 	}
 }
 
@@ -31,27 +32,50 @@ func (t *TodoWriteTool) Validate(args map[string]interface{}) error {
 	if _, ok := args["todos"]; !ok {
 		return errors.New("todos is required")
 	}
+	if _, err := parseTodos(args["todos"]); err != nil {
+		return err
+	}
 	return nil
 }
 
 func (t *TodoWriteTool) Execute(ctx *Context) *Result {
 	result := &Result{StartTime: time.Now()}
-	todos := ctx.Args["todos"]
-	data, err := json.MarshalIndent(todos, "", "  ")
-	if err != nil {
-		result.Status = "error"
-		result.Error = err.Error()
-		return result
+	items, _ := parseTodos(ctx.Args["todos"])
+	merge, _ := argBool(ctx.Args, "merge")
+
+	var saved []todo.Item
+	var err error
+	if merge {
+		saved, err = t.store.Merge(ctx.Session, items)
+	} else {
+		saved, err = t.store.Replace(ctx.Session, items)
 	}
-	p := filepath.Join(ctx.Config.RootDir, ".todos.json")
-	if err := os.WriteFile(p, data, 0644); err != nil {
+	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
 		return result
 	}
-	items, _ := todos.([]interface{})
+
 	result.Status = "success"
-	result.Output = fmt.Sprintf("已保存 %d 个任务", len(items))
+	result.Output = fmt.Sprintf("已保存 %d 个任务\n%s", len(saved), todo.Render(saved))
 	result.EndTime = time.Now()
 	return result
 }
+
+// parseTodos 接受 JSON 数组或 JSON 字符串（XML 参数）形式的待办列表
+func parseTodos(v interface{}) ([]todo.Item, error) {
+	var data []byte
+	if s, ok := v.(string); ok {
+		data = []byte(s)
+	} else {
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	var items []todo.Item
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("todos must be an array of {id, content, status, priority}: %w", err)
+	}
+	return items, nil
+}
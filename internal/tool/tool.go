@@ -1,8 +1,10 @@
 package tool
 
 import (
+	"context"
 	"time"
 
+	"github.com/afumu/openlink/internal/checkpoint"
 	"github.com/afumu/openlink/internal/types"
 )
 
@@ -15,8 +17,15 @@ type Tool interface {
 }
 
 type Context struct {
-	Args   map[string]interface{}
-	Config *types.Config
+	// Ctx 是本次调用的上下文，调用方断开或取消时结束；为 nil 时不会被取消
+	Ctx     context.Context
+	Args    map[string]interface{}
+	Config  *types.Config
+	Session string
+	// Redact 对输出做脱敏，在截断输出写入磁盘前调用；为 nil 时不脱敏
+	Redact func(string) string
+	// Checkpoints 保存修改前的文件内容，供 undo 撤销；为 nil 时不保存
+	Checkpoints *checkpoint.Store
 }
 
 type Result struct {
@@ -24,8 +33,12 @@ type Result struct {
 	Output     string
 	Error      string
 	StopStream bool
-	StartTime  time.Time
-	EndTime    time.Time
+	// Diff 是工具对文件的修改，由 fileDiff 生成
+	Diff *types.FileDiff
+	// Diffs 是一次修改多个文件的工具（multi_edit）对各文件的修改
+	Diffs     []*types.FileDiff
+	StartTime time.Time
+	EndTime   time.Time
 }
 
 type ToolInfo struct {
@@ -4,6 +4,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/afumu/openlink/internal/skill"
+	"github.com/afumu/openlink/internal/todo"
 	"github.com/afumu/openlink/internal/types"
 )
 
@@ -22,12 +24,14 @@ func TestToolMeta(t *testing.T) {
 		NewGlobTool(cfg),
 		NewGrepTool(cfg),
 		NewListDirTool(cfg),
-		NewQuestionTool(),
+		NewQuestionTool(nil),
 		NewReadFileTool(cfg),
 		NewWriteFileTool(cfg),
-		NewSkillTool(cfg),
-		NewTodoWriteTool(cfg),
+		NewSkillTool(skill.NewIndex(cfg.RootDir), nil),
+		NewTodoWriteTool(todo.NewStore(t.TempDir())),
+		NewTodoReadTool(todo.NewStore(t.TempDir())),
 		NewWebFetchTool(),
+		NewWebSearchTool(cfg),
 	}
 
 	for _, tool := range tools {
@@ -71,7 +75,7 @@ func TestValidateMethods(t *testing.T) {
 	})
 
 	t.Run("SkillTool validate always passes", func(t *testing.T) {
-		if err := NewSkillTool(cfg).Validate(map[string]interface{}{}); err != nil {
+		if err := NewSkillTool(skill.NewIndex(cfg.RootDir), nil).Validate(map[string]interface{}{}); err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
 	})
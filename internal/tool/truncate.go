@@ -1,44 +1,210 @@
 package tool
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/afumu/openlink/internal/types"
 )
 
 const MaxLines = 2000
 const MaxBytes = 50 * 1024
 
-// Truncate 检查输出是否超限，超限则写入临时文件并返回截断提示
+// 截断方式
+const (
+	TruncateHead     = "head"
+	TruncateHeadTail = "head_tail"
+)
+
+// defaultLimits 是内置的按工具截断配置：命令输出的失败摘要通常在末尾，因此保留开头和结尾
+var defaultLimits = map[string]types.OutputLimit{
+	"exec_cmd": {Mode: TruncateHeadTail},
+}
+
+// LimitFor 返回工具生效的截断配置：内置默认值 → settings 中的全局配置 → settings 中该工具的配置
+func LimitFor(cfg *types.Config, name string) types.OutputLimit {
+	limit := types.OutputLimit{MaxLines: MaxLines, MaxBytes: MaxBytes, Mode: TruncateHead}
+	limit = mergeLimit(limit, defaultLimits[name])
+	if cfg != nil && cfg.Output != nil {
+		limit = mergeLimit(limit, cfg.Output.OutputLimit)
+		limit = mergeLimit(limit, cfg.Output.Tools[name])
+	}
+	return limit
+}
+
+func mergeLimit(base, over types.OutputLimit) types.OutputLimit {
+	if over.MaxLines > 0 {
+		base.MaxLines = over.MaxLines
+	}
+	if over.MaxBytes > 0 {
+		base.MaxBytes = over.MaxBytes
+	}
+	if over.Mode != "" {
+		base.Mode = over.Mode
+	}
+	return base
+}
+
+// Truncate 按默认配置截断输出，超限则保存完整内容并返回截断提示
 func Truncate(output string) (string, bool) {
+	return TruncateWith(output, LimitFor(nil, ""))
+}
+
+// TruncateFor 按工具的截断配置处理输出；ctx 提供脱敏函数时先脱敏，保存到磁盘的完整输出也不含敏感信息
+func TruncateFor(ctx *Context, name, output string) string {
+	var cfg *types.Config
+	if ctx != nil {
+		cfg = ctx.Config
+		if ctx.Redact != nil {
+			output = ctx.Redact(output)
+		}
+	}
+	out, _ := TruncateWith(output, LimitFor(cfg, name))
+	return out
+}
+
+// TruncateWith 检查输出是否超限，超限则写入 OutputDir 并返回截断后的预览和提示
+func TruncateWith(output string, limit types.OutputLimit) (string, bool) {
 	normalized := strings.ReplaceAll(output, "\r\n", "\n")
 	lines := strings.Split(normalized, "\n")
 
-	if len(lines) <= MaxLines && len(normalized) <= MaxBytes {
+	if len(lines) <= limit.MaxLines && len(normalized) <= limit.MaxBytes {
 		return output, false
 	}
 
-	end := MaxLines
-	if end > len(lines) {
-		end = len(lines)
+	var preview string
+	if limit.Mode == TruncateHeadTail {
+		preview = headTail(normalized, limit)
+	} else {
+		preview = normalized[:headEnd(normalized, limit.MaxLines, limit.MaxBytes)]
+	}
+
+	hint := fmt.Sprintf("\n\n...输出已截断（共 %d 行，%d 字节）", len(lines), len(output))
+	if id, path, err := StoreOutput(output); err == nil {
+		hint += fmt.Sprintf("，"+storedHint+"\n%s\n输出 ID: %s，使用 output_read 工具按 ID 分页读取或用 pattern 搜索", path, id)
+	}
+	return preview + hint, true
+}
+
+// headEnd 返回开头不超过 maxLines 行、maxBytes 字节的部分的结束位置
+func headEnd(s string, maxLines, maxBytes int) int {
+	end := len(s)
+	if maxLines >= 0 {
+		pos := 0
+		for i := 0; i < maxLines; i++ {
+			nl := strings.IndexByte(s[pos:], '\n')
+			if nl < 0 {
+				pos = len(s)
+				break
+			}
+			pos += nl + 1
+		}
+		if maxLines == 0 || pos < len(s) {
+			end = max(pos-1, 0) // 不含最后的换行
+		}
+	}
+	if end > maxBytes {
+		end = maxBytes
+		for end > 0 && !utf8.RuneStart(s[end]) {
+			end--
+		}
+	}
+	return end
+}
+
+// tailStart 返回结尾不超过 maxLines 行、maxBytes 字节的部分的起始位置
+func tailStart(s string, maxLines, maxBytes int) int {
+	start := 0
+	pos := len(s)
+	for i := 0; i < maxLines; i++ {
+		nl := strings.LastIndexByte(s[:pos], '\n')
+		if nl < 0 {
+			pos = -1
+			break
+		}
+		pos = nl
+	}
+	if pos >= 0 {
+		start = pos + 1
+	}
+	if len(s)-start > maxBytes {
+		start = len(s) - maxBytes
+		for start < len(s) && !utf8.RuneStart(s[start]) {
+			start++
+		}
 	}
-	preview := strings.Join(lines[:end], "\n")
-	if len(preview) > MaxBytes {
-		preview = preview[:MaxBytes]
+	return start
+}
+
+// headTail 开头保留约三分之一的预算，结尾保留其余部分，中间以省略标记代替
+func headTail(s string, limit types.OutputLimit) string {
+	headLines, headBytes := limit.MaxLines/3, limit.MaxBytes/3
+	h := headEnd(s, headLines, headBytes)
+	t := tailStart(s, limit.MaxLines-headLines, limit.MaxBytes-headBytes)
+	if t < h {
+		t = h
 	}
+	elided := s[h:t]
+	// 开头和结尾两侧的换行不算作省略的行
+	lines := max(strings.Count(elided, "\n")-1, 0)
+	return fmt.Sprintf("%s\n\n... 省略中间 %d 行（%d 字节）...\n\n%s", s[:h], lines, len(elided), s[t:])
+}
 
+const storedHint = "完整内容保存至:"
+
+// OutputDir 返回截断输出的保存目录
+func OutputDir() string {
 	home, _ := os.UserHomeDir()
-	dir := filepath.Join(home, ".openlink", "tool-output")
-	os.MkdirAll(dir, 0755)
-	id := fmt.Sprintf("%d", time.Now().UnixNano())
-	fullPath := filepath.Join(dir, id)
-	os.WriteFile(fullPath, []byte(output), 0644)
-
-	hint := fmt.Sprintf(
-		"\n\n...输出已截断（共 %d 行），完整内容保存至:\n%s\n使用 read_file 工具加 offset 参数分段读取",
-		len(lines), fullPath,
-	)
-	return preview + hint, true
+	return filepath.Join(home, ".openlink", "tool-output")
+}
+
+// outputIDRe 限制输出 ID 的字符，避免通过 ID 访问保存目录以外的文件
+var outputIDRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// StoreOutput 把完整输出保存到 OutputDir，返回 ID 和文件路径
+func StoreOutput(output string) (string, string, error) {
+	dir := OutputDir()
+	// 输出中可能包含密钥等敏感信息，仅所有者可读写
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", err
+	}
+	buf := make([]byte, 3)
+	rand.Read(buf)
+	id := time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(buf)
+	path := filepath.Join(dir, id)
+	if err := os.WriteFile(path, []byte(output), 0600); err != nil {
+		return "", "", err
+	}
+	return id, path, nil
+}
+
+// StoredOutputFile 返回 ID 对应的保存文件路径
+func StoredOutputFile(id string) (string, error) {
+	if !outputIDRe.MatchString(id) {
+		return "", fmt.Errorf("invalid output id %q", id)
+	}
+	return filepath.Join(OutputDir(), id), nil
+}
+
+// StoredOutputPath 从 Truncate 生成的截断提示中取出完整输出的保存路径
+func StoredOutputPath(output string) (string, bool) {
+	i := strings.LastIndex(output, storedHint+"\n")
+	if i < 0 {
+		return "", false
+	}
+	rest := output[i+len(storedHint)+1:]
+	if nl := strings.IndexByte(rest, '\n'); nl >= 0 {
+		rest = rest[:nl]
+	}
+	if !strings.HasPrefix(rest, OutputDir()) {
+		return "", false
+	}
+	return rest, true
 }
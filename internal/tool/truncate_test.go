@@ -1,8 +1,13 @@
 package tool
 
 import (
+	"fmt"
+	"os"
 	"strings"
 	"testing"
+	"unicode/utf8"
+
+	"github.com/afumu/openlink/internal/types"
 )
 
 func TestTruncate(t *testing.T) {
@@ -35,3 +40,71 @@ func TestTruncate(t *testing.T) {
 		}
 	})
 }
+
+func TestTruncateHeadTail(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var b strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	b.WriteString("FAIL: last line")
+	out, truncated := TruncateWith(b.String(), types.OutputLimit{MaxLines: 30, MaxBytes: MaxBytes, Mode: TruncateHeadTail})
+	if !truncated {
+		t.Fatal("expected truncated")
+	}
+	if !strings.HasPrefix(out, "line 1\n") || !strings.Contains(out, "FAIL: last line") {
+		t.Errorf("expected head and tail kept, got:\n%s", out)
+	}
+	if !strings.Contains(out, "省略中间 71 行") {
+		t.Errorf("expected elided marker, got:\n%s", out)
+	}
+	if strings.Contains(out, "line 50\n") {
+		t.Error("middle line should be elided")
+	}
+
+	id := out[strings.LastIndex(out, "输出 ID: ")+len("输出 ID: "):]
+	id = id[:strings.IndexAny(id, "，")]
+	path, err := StoredOutputFile(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != b.String() {
+		t.Errorf("stored output mismatch: %v", err)
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	out, _ := TruncateWith(strings.Repeat("中", 100), types.OutputLimit{MaxLines: 10, MaxBytes: 100, Mode: TruncateHead})
+	preview := out[:strings.Index(out, "\n\n...输出已截断")]
+	if !utf8.ValidString(preview) || len(preview) != 99 {
+		t.Errorf("preview should end on a rune boundary, got %d bytes", len(preview))
+	}
+	out, _ = TruncateWith(strings.Repeat("中", 100), types.OutputLimit{MaxLines: 10, MaxBytes: 100, Mode: TruncateHeadTail})
+	if !utf8.ValidString(out) {
+		t.Error("head_tail preview is not valid UTF-8")
+	}
+}
+
+func TestLimitFor(t *testing.T) {
+	if got := LimitFor(nil, "exec_cmd"); got.Mode != TruncateHeadTail || got.MaxLines != MaxLines {
+		t.Errorf("exec_cmd default: %+v", got)
+	}
+	if got := LimitFor(nil, "read_file"); got.Mode != TruncateHead {
+		t.Errorf("read_file default: %+v", got)
+	}
+	cfg := &types.Config{Output: &types.OutputSettings{
+		OutputLimit: types.OutputLimit{MaxLines: 500},
+		Tools: map[string]types.OutputLimit{
+			"exec_cmd":  {Mode: TruncateHead, MaxBytes: 1000},
+			"web_fetch": {Mode: TruncateHeadTail},
+		},
+	}}
+	if got := LimitFor(cfg, "exec_cmd"); got != (types.OutputLimit{MaxLines: 500, MaxBytes: 1000, Mode: TruncateHead}) {
+		t.Errorf("exec_cmd: %+v", got)
+	}
+	if got := LimitFor(cfg, "web_fetch"); got != (types.OutputLimit{MaxLines: 500, MaxBytes: MaxBytes, Mode: TruncateHeadTail}) {
+		t.Errorf("web_fetch: %+v", got)
+	}
+}
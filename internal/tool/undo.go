@@ -0,0 +1,102 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/afumu/openlink/internal/checkpoint"
+	"github.com/afumu/openlink/internal/security"
+)
+
+type UndoTool struct {
+	store *checkpoint.Store
+}
+
+func NewUndoTool(store *checkpoint.Store) *UndoTool {
+	return &UndoTool{store: store}
+}
+
+func (t *UndoTool) Name() string { return "undo" }
+func (t *UndoTool) Description() string {
+	return "Revert the most recent file changes made by write_file/edit/multi_edit in this session (one multi_edit call counts as one change); newly created files are deleted"
+}
+func (t *UndoTool) Parameters() interface{} {
+	return map[string]string{
+		"count": "number (optional) - how many of the most recent tool calls to revert, default 1",
+	}
+}
+
+func (t *UndoTool) Validate(args map[string]interface{}) error {
+	if n, ok := argInt(args, "count"); ok && n < 1 {
+		return fmt.Errorf("count must be at least 1")
+	}
+	return nil
+}
+
+func (t *UndoTool) Execute(ctx *Context) *Result {
+	result := &Result{StartTime: time.Now()}
+	count, ok := argInt(ctx.Args, "count")
+	if !ok {
+		count = 1
+	}
+	resolver := security.NewResolver(ctx.Config)
+	changes, err := t.store.Undo(ctx.Session, count, resolver)
+	summary := RenderChanges(changes, resolver)
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		if summary != "" {
+			result.Error = summary + "\n" + result.Error
+		}
+		return result
+	}
+	result.Status = "success"
+	if len(changes) == 0 {
+		result.Output = "没有可撤销的修改"
+	} else {
+		result.Output = fmt.Sprintf("已撤销 %d 处修改\n%s", len(changes), summary)
+	}
+	result.EndTime = time.Now()
+	return result
+}
+
+// RenderChanges 把恢复结果渲染为每个文件一行的文本
+func RenderChanges(changes []checkpoint.Change, resolver *security.Resolver) string {
+	var sb strings.Builder
+	for _, c := range changes {
+		action := "恢复"
+		if c.Deleted {
+			action = "删除新建的"
+		}
+		fmt.Fprintf(&sb, "#%s %s %s %s\n", c.ID, c.Tool, action, resolver.Display(c.Path))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// snapshot 在修改 abs 之前保存其当前内容，Context 未配置检查点时什么也不做
+func snapshot(ctx *Context, tool, abs string, resolver *security.Resolver) error {
+	if ctx.Checkpoints == nil {
+		return nil
+	}
+	if _, err := ctx.Checkpoints.Save(ctx.Session, tool, abs, resolver); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// snapshotGroup 在一次修改多个文件之前保存它们当前的内容，撤销时整组一起撤销；返回检查点 ID
+func snapshotGroup(ctx *Context, tool string, paths []string, resolver *security.Resolver) ([]string, error) {
+	if ctx.Checkpoints == nil || len(paths) == 0 {
+		return nil, nil
+	}
+	cps, err := ctx.Checkpoints.SaveGroup(ctx.Session, tool, paths, resolver)
+	if err != nil {
+		return nil, fmt.Errorf("save checkpoint: %w", err)
+	}
+	ids := make([]string, len(cps))
+	for i, cp := range cps {
+		ids[i] = cp.ID
+	}
+	return ids, nil
+}
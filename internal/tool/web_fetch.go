@@ -40,6 +40,11 @@ func (t *WebFetchTool) Validate(args map[string]interface{}) error {
 	if !ok || rawURL == "" {
 		return fmt.Errorf("url is required")
 	}
+	return checkPublicURL(rawURL)
+}
+
+// checkPublicURL rejects non-http(s) URLs and hosts that resolve to private/internal addresses.
+func checkPublicURL(rawURL string) error {
 	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
 		return fmt.Errorf("only http/https URLs are supported")
 	}
@@ -99,7 +104,7 @@ func (t *WebFetchTool) Execute(ctx *Context) *Result {
 		content = stripHTML(content)
 	}
 
-	output, _ := Truncate(content)
+	output := TruncateFor(ctx, t.Name(), content)
 	result.Status = "success"
 	result.Output = output
 	result.EndTime = time.Now()
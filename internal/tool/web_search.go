@@ -0,0 +1,285 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+const (
+	defaultSearchLimit = 8
+	maxSearchLimit     = 20
+)
+
+type SearchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+}
+
+// SearchBackend 是 web_search 的可插拔搜索后端，返回按相关度排序的结果
+type SearchBackend interface {
+	Name() string
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+}
+
+// NewSearchBackend 根据配置构造搜索后端；未配置时返回 nil
+func NewSearchBackend(cfg *types.SearchConfig) (SearchBackend, error) {
+	if cfg == nil || cfg.URL == "" {
+		return nil, nil
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	switch strings.ToLower(cfg.Backend) {
+	case "", "searxng":
+		return &SearxNGBackend{BaseURL: strings.TrimRight(cfg.URL, "/"), AllowPrivate: cfg.AllowPrivate, Client: client}, nil
+	case "json":
+		if !strings.Contains(cfg.URL, "{query}") {
+			return nil, errors.New("json search backend url must contain {query}")
+		}
+		return &JSONTemplateBackend{
+			URLTemplate:  cfg.URL,
+			ResultsPath:  cfg.ResultsPath,
+			TitleField:   orDefault(cfg.TitleField, "title"),
+			URLField:     orDefault(cfg.URLField, "url"),
+			SnippetField: orDefault(cfg.SnippetField, "snippet"),
+			AllowPrivate: cfg.AllowPrivate,
+			Client:       client,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown search backend: %s", cfg.Backend)
+	}
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// SearxNGBackend 调用 SearxNG 的 JSON API: GET <base>/search?q=...&format=json
+type SearxNGBackend struct {
+	BaseURL      string
+	AllowPrivate bool
+	Client       *http.Client
+}
+
+func (b *SearxNGBackend) Name() string { return "searxng" }
+
+func (b *SearxNGBackend) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	u := b.BaseURL + "/search?" + url.Values{"q": {query}, "format": {"json"}}.Encode()
+	var body struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := getSearchJSON(ctx, b.Client, u, b.AllowPrivate, &body); err != nil {
+		return nil, err
+	}
+	var results []SearchResult
+	for _, r := range body.Results {
+		if r.URL == "" {
+			continue
+		}
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// JSONTemplateBackend 适配任意返回 JSON 的搜索接口：
+// URLTemplate 中的 {query}/{limit} 会被替换，ResultsPath 与各字段名为点分路径（如 data.items）
+type JSONTemplateBackend struct {
+	URLTemplate  string
+	ResultsPath  string
+	TitleField   string
+	URLField     string
+	SnippetField string
+	AllowPrivate bool
+	Client       *http.Client
+}
+
+func (b *JSONTemplateBackend) Name() string { return "json" }
+
+func (b *JSONTemplateBackend) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	u := strings.NewReplacer(
+		"{query}", url.QueryEscape(query),
+		"{limit}", fmt.Sprintf("%d", limit),
+	).Replace(b.URLTemplate)
+	var body interface{}
+	if err := getSearchJSON(ctx, b.Client, u, b.AllowPrivate, &body); err != nil {
+		return nil, err
+	}
+	items, ok := lookupPath(body, b.ResultsPath).([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("search response has no result array at %q", b.ResultsPath)
+	}
+	var results []SearchResult
+	for _, item := range items {
+		link := stringAt(item, b.URLField)
+		if link == "" {
+			continue
+		}
+		results = append(results, SearchResult{
+			Title:   stringAt(item, b.TitleField),
+			URL:     link,
+			Snippet: stringAt(item, b.SnippetField),
+		})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+func stringAt(v interface{}, path string) string {
+	if found := lookupPath(v, path); found != nil {
+		return fmt.Sprint(found)
+	}
+	return ""
+}
+
+// lookupPath 按点分路径取 JSON 值，空路径返回自身
+func lookupPath(v interface{}, path string) interface{} {
+	if path == "" {
+		return v
+	}
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func getSearchJSON(ctx context.Context, client *http.Client, rawURL string, allowPrivate bool, out interface{}) error {
+	if !allowPrivate {
+		if err := checkPublicURL(rawURL); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("search backend returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid search response: %w", err)
+	}
+	return nil
+}
+
+type WebSearchTool struct {
+	backend SearchBackend
+	err     error
+}
+
+func NewWebSearchTool(config *types.Config) *WebSearchTool {
+	var cfg *types.SearchConfig
+	if config != nil {
+		cfg = config.Search
+	}
+	backend, err := NewSearchBackend(cfg)
+	return &WebSearchTool{backend: backend, err: err}
+}
+
+// NewWebSearchToolWithBackend 使用指定后端构造 web_search（测试或自定义后端）
+func NewWebSearchToolWithBackend(backend SearchBackend) *WebSearchTool {
+	return &WebSearchTool{backend: backend}
+}
+
+func (t *WebSearchTool) Name() string { return "web_search" }
+func (t *WebSearchTool) Description() string {
+	return "Search the web and return ranked results (title, url, snippet)"
+}
+func (t *WebSearchTool) Parameters() interface{} {
+	return map[string]string{
+		"query": "string (required) - search query",
+		"limit": fmt.Sprintf("number (optional) - max results (default: %d, max: %d)", defaultSearchLimit, maxSearchLimit),
+	}
+}
+
+func (t *WebSearchTool) Validate(args map[string]interface{}) error {
+	if q, ok := args["query"].(string); !ok || strings.TrimSpace(q) == "" {
+		return errors.New("query is required")
+	}
+	return nil
+}
+
+func (t *WebSearchTool) Execute(ctx *Context) *Result {
+	result := &Result{StartTime: time.Now()}
+	query, _ := ctx.Args["query"].(string)
+
+	if t.err != nil {
+		result.Status = "error"
+		result.Error = t.err.Error()
+		return result
+	}
+	if t.backend == nil {
+		result.Status = "error"
+		result.Error = "no search backend configured (set \"search\" in ~/.openlink/settings.json or use -search-url)"
+		return result
+	}
+
+	limit := defaultSearchLimit
+	if v, ok := argInt(ctx.Args, "limit"); ok && v >= 1 {
+		limit = v
+		if limit > maxSearchLimit {
+			limit = maxSearchLimit
+		}
+	}
+
+	searchCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	results, err := t.backend.Search(searchCtx, strings.TrimSpace(query), limit)
+	result.EndTime = time.Now()
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = "success"
+	if len(results) == 0 {
+		result.Output = "No results found"
+		return result
+	}
+	var sb strings.Builder
+	for i, r := range results {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		fmt.Fprintf(&sb, "%d. %s\n   %s", i+1, strings.TrimSpace(r.Title), r.URL)
+		if snippet := strings.TrimSpace(r.Snippet); snippet != "" {
+			fmt.Fprintf(&sb, "\n   %s", snippet)
+		}
+	}
+	result.Output = sb.String()
+	return result
+}
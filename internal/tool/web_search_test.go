@@ -0,0 +1,143 @@
+package tool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/afumu/openlink/internal/types"
+)
+
+func TestSearxNGBackend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("format") != "json" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("q") != "golang generics" {
+			t.Errorf("unexpected query %q", r.URL.Query().Get("q"))
+		}
+		w.Write([]byte(`{"results":[
+			{"title":"Tutorial","url":"https://go.dev/doc/tutorial/generics","content":"Getting started"},
+			{"title":"No URL","url":""},
+			{"title":"Spec","url":"https://go.dev/ref/spec","content":"Type parameters"},
+			{"title":"Blog","url":"https://go.dev/blog/intro-generics","content":"An introduction"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	backend, err := NewSearchBackend(&types.SearchConfig{URL: srv.URL + "/", AllowPrivate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := NewWebSearchToolWithBackend(backend)
+
+	t.Run("returns ranked results", func(t *testing.T) {
+		res := tool.Execute(&Context{Args: map[string]interface{}{"query": "golang generics"}})
+		if res.Status != "success" {
+			t.Fatalf("expected success: %s", res.Error)
+		}
+		first := strings.Index(res.Output, "1. Tutorial")
+		second := strings.Index(res.Output, "2. Spec")
+		if first < 0 || second < first {
+			t.Errorf("unexpected ranking: %q", res.Output)
+		}
+		if !strings.Contains(res.Output, "https://go.dev/ref/spec") || !strings.Contains(res.Output, "Type parameters") {
+			t.Errorf("expected url and snippet, got %q", res.Output)
+		}
+		if strings.Contains(res.Output, "No URL") {
+			t.Errorf("results without url should be skipped: %q", res.Output)
+		}
+	})
+
+	t.Run("string limit from XML arguments", func(t *testing.T) {
+		res := tool.Execute(&Context{Args: map[string]interface{}{"query": "golang generics", "limit": "1"}})
+		if res.Status != "success" || strings.Contains(res.Output, "2. Spec") {
+			t.Errorf("expected a single result: %q %s", res.Output, res.Error)
+		}
+	})
+
+	t.Run("limit caps results", func(t *testing.T) {
+		results, err := backend.Search(context.Background(), "golang generics", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 {
+			t.Errorf("expected 1 result, got %d", len(results))
+		}
+	})
+}
+
+func TestJSONTemplateBackend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("term") != "a b" || r.URL.Query().Get("n") != "8" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"data":{"items":[{"name":"Result","link":"https://example.com/r","meta":{"desc":"snippet text"}}]}}`))
+	}))
+	defer srv.Close()
+
+	backend, err := NewSearchBackend(&types.SearchConfig{
+		Backend:      "json",
+		URL:          srv.URL + "/api?term={query}&n={limit}",
+		ResultsPath:  "data.items",
+		TitleField:   "name",
+		URLField:     "link",
+		SnippetField: "meta.desc",
+		AllowPrivate: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := NewWebSearchToolWithBackend(backend).Execute(&Context{Args: map[string]interface{}{"query": "a b"}})
+	if res.Status != "success" {
+		t.Fatalf("expected success: %s", res.Error)
+	}
+	if !strings.Contains(res.Output, "1. Result") || !strings.Contains(res.Output, "https://example.com/r") || !strings.Contains(res.Output, "snippet text") {
+		t.Errorf("got %q", res.Output)
+	}
+}
+
+func TestWebSearchBackendConfig(t *testing.T) {
+	t.Run("json backend requires {query}", func(t *testing.T) {
+		if _, err := NewSearchBackend(&types.SearchConfig{Backend: "json", URL: "https://example.com/search"}); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("unknown backend rejected", func(t *testing.T) {
+		if _, err := NewSearchBackend(&types.SearchConfig{Backend: "bing", URL: "https://example.com"}); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("unconfigured tool returns error", func(t *testing.T) {
+		tool := NewWebSearchTool(&types.Config{})
+		res := tool.Execute(&Context{Args: map[string]interface{}{"query": "x"}})
+		if res.Status != "error" {
+			t.Error("expected error without backend")
+		}
+	})
+
+	t.Run("private backend blocked unless allowed", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"results":[]}`))
+		}))
+		defer srv.Close()
+		backend, err := NewSearchBackend(&types.SearchConfig{URL: srv.URL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := backend.Search(context.Background(), "x", 5); err == nil {
+			t.Error("expected private address to be blocked")
+		}
+	})
+
+	t.Run("validate rejects missing query", func(t *testing.T) {
+		if err := NewWebSearchTool(&types.Config{}).Validate(map[string]interface{}{}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -2,6 +2,7 @@ package tool
 
 import (
 	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"time"
@@ -48,20 +49,45 @@ func (t *WriteFileTool) Execute(ctx *Context) *Result {
 	content, _ := ctx.Args["content"].(string)
 	mode, _ := ctx.Args["mode"].(string)
 
-	safePath, err := security.SafePath(ctx.Config.RootDir, path)
+	resolver := security.NewResolver(ctx.Config)
+	safePath, err := resolver.Resolve(path, security.Write)
 	if err != nil {
 		result.Status = "error"
 		result.Error = err.Error()
 		return result
 	}
+	before, err := resolver.ReadFile(safePath)
+	created := errors.Is(err, fs.ErrNotExist)
+	if err != nil && !created {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	after := content
+	if mode == "append" {
+		after = string(before) + content
+	}
+	// 内容不变时不写入也不保存检查点，避免空操作占用一次 undo
+	if !created && after == string(before) {
+		result.Status = "success"
+		result.Output, result.Diff = fileDiff(ctx, t.Name(), resolver.Display(safePath), after, after, false)
+		result.StopStream = true
+		result.EndTime = time.Now()
+		return result
+	}
+	if err := snapshot(ctx, t.Name(), safePath, resolver); err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
 
 	if mode == "append" {
-		if err := os.MkdirAll(filepath.Dir(safePath), 0755); err != nil {
+		if err := resolver.MkdirAll(filepath.Dir(safePath), 0755); err != nil {
 			result.Status = "error"
 			result.Error = err.Error()
 			return result
 		}
-		f, err := os.OpenFile(safePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		f, err := resolver.OpenFile(safePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
 			result.Status = "error"
 			result.Error = err.Error()
@@ -74,12 +100,12 @@ func (t *WriteFileTool) Execute(ctx *Context) *Result {
 			return result
 		}
 	} else {
-		if err := os.MkdirAll(filepath.Dir(safePath), 0755); err != nil {
+		if err := resolver.MkdirAll(filepath.Dir(safePath), 0755); err != nil {
 			result.Status = "error"
 			result.Error = err.Error()
 			return result
 		}
-		if err := os.WriteFile(safePath, []byte(content), 0644); err != nil {
+		if err := resolver.WriteFile(safePath, []byte(content), 0644); err != nil {
 			result.Status = "error"
 			result.Error = err.Error()
 			return result
@@ -87,7 +113,7 @@ func (t *WriteFileTool) Execute(ctx *Context) *Result {
 	}
 
 	result.Status = "success"
-	result.Output = "写入成功"
+	result.Output, result.Diff = fileDiff(ctx, t.Name(), resolver.Display(safePath), string(before), after, created)
 	result.StopStream = true
 	result.EndTime = time.Now()
 	return result
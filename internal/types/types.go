@@ -1,11 +1,22 @@
 package types
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"io/fs"
+	"strings"
+)
+
+// DefaultSession 是未携带 session 的请求所使用的会话 ID
+const DefaultSession = "default"
 
 type ToolRequest struct {
-	Name   string                 `json:"name"`
-	Args   map[string]interface{} `json:"args"`
-	Reason string                 `json:"reason,omitempty"`
+	Name    string                 `json:"name"`
+	Args    map[string]interface{} `json:"args"`
+	Reason  string                 `json:"reason,omitempty"`
+	Session string                 `json:"session,omitempty"`
+	// Platform、Lang 由扩展告知当前聊天平台和语言，用于选择重新注入的提示词模板
+	Platform string `json:"platform,omitempty"`
+	Lang     string `json:"lang,omitempty"`
 }
 
 func (r *ToolRequest) UnmarshalJSON(data []byte) error {
@@ -14,6 +25,9 @@ func (r *ToolRequest) UnmarshalJSON(data []byte) error {
 		Args      map[string]interface{} `json:"args"`
 		Arguments map[string]interface{} `json:"arguments"`
 		Reason    string                 `json:"reason,omitempty"`
+		Session   string                 `json:"session,omitempty"`
+		Platform  string                 `json:"platform,omitempty"`
+		Lang      string                 `json:"lang,omitempty"`
 	}
 	var v raw
 	if err := json.Unmarshal(data, &v); err != nil {
@@ -21,6 +35,9 @@ func (r *ToolRequest) UnmarshalJSON(data []byte) error {
 	}
 	r.Name = v.Name
 	r.Reason = v.Reason
+	r.Session = v.Session
+	r.Platform = v.Platform
+	r.Lang = v.Lang
 	if v.Args != nil {
 		r.Args = v.Args
 	} else {
@@ -29,22 +46,164 @@ func (r *ToolRequest) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// NormalizeSession 将会话 ID 限制为可安全用作文件名的字符，空值返回 DefaultSession
+func NormalizeSession(session string) string {
+	session = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(session))
+	session = strings.Trim(session, ".")
+	if len(session) > 64 {
+		session = session[:64]
+	}
+	if session == "" {
+		return DefaultSession
+	}
+	return session
+}
+
 type ToolResponse struct {
 	Status     string `json:"status"`
 	Output     string `json:"output"`
 	Error      string `json:"error,omitempty"`
 	StopStream bool   `json:"stopStream,omitempty"`
+	// Diff 是修改文件的工具（write_file、edit）对文件的改动，供扩展渲染差异卡片
+	Diff *FileDiff `json:"diff,omitempty"`
+	// Diffs 是 multi_edit 对每个被修改文件的改动
+	Diffs []*FileDiff `json:"diffs,omitempty"`
+}
+
+// FileDiff 是单个文件修改前后的行级差异
+type FileDiff struct {
+	Path      string     `json:"path"`
+	Created   bool       `json:"created,omitempty"`
+	Added     int        `json:"added"`
+	Removed   int        `json:"removed"`
+	Hunks     []DiffHunk `json:"hunks"`
+	Truncated bool       `json:"truncated,omitempty"` // 超过截断上限时只保留前面的 hunk
+	// Notes 说明按行比较看不出的变化：文件末尾换行的增删和换行符（LF/CRLF）的改变
+	Notes []string `json:"notes,omitempty"`
+}
+
+// DiffHunk 对应统一差异格式中的一个 @@ 段，行号从 1 开始
+type DiffHunk struct {
+	OldStart int        `json:"oldStart"`
+	OldLines int        `json:"oldLines"`
+	NewStart int        `json:"newStart"`
+	NewLines int        `json:"newLines"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+// DiffLine 是 hunk 中的一行：Op 为 " "（上下文）、"-"（删除）或 "+"（新增）；Old、New 为该行在修改前后的行号，不存在时为 0
+type DiffLine struct {
+	Op   string `json:"op"`
+	Old  int    `json:"old,omitempty"`
+	New  int    `json:"new,omitempty"`
+	Text string `json:"text"`
 }
 
 type Config struct {
-	RootDir       string
-	Port          int
-	Timeout       int
-	Token         string
-	DefaultPrompt []byte
+	RootDir    string
+	Port       int
+	Timeout    int
+	Token      string
+	Prompts    fs.FS // 内置提示词模板，工作区和 ~/.openlink/prompts 中没有对应模板时使用
+	Search     *SearchConfig
+	MCPServers map[string]MCPServerConfig
+	// DisableReminder 关闭工具输出末尾的身份提醒和提示词重新注入（MCP 等非网页客户端不需要）
+	DisableReminder bool
+	// Unattended 表示没有 /questions 接口回答提问（mcp、call、run 命令），question 工具只返回格式化的问题而不等待回答
+	Unattended bool
+	Reinject   *ReinjectSettings
+	Output     *OutputSettings
+	Redact     *RedactSettings
+	Paths      *PathSettings
+}
+
+// SearchConfig 描述 web_search 使用的搜索后端
+type SearchConfig struct {
+	Backend      string `json:"backend"` // "searxng"（默认）或 "json"
+	URL          string `json:"url"`     // searxng 为实例地址；json 为含 {query} 占位符的 URL 模板
+	ResultsPath  string `json:"results_path,omitempty"`
+	TitleField   string `json:"title_field,omitempty"`
+	URLField     string `json:"url_field,omitempty"`
+	SnippetField string `json:"snippet_field,omitempty"`
+	AllowPrivate bool   `json:"allow_private,omitempty"` // 允许本地/内网地址（如自建 SearxNG）
+}
+
+// MCPServerConfig 描述一个通过 stdio 启动的 MCP server，其工具以 "<名称>.<工具>" 注册
+type MCPServerConfig struct {
+	Command  string            `json:"command"`
+	Args     []string          `json:"args,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+	Cwd      string            `json:"cwd,omitempty"`
+	Disabled bool              `json:"disabled,omitempty"`
+}
+
+// ReinjectPolicy 控制工具输出末尾的身份提醒和提示词重新注入。零值字段沿用上一级配置。
+type ReinjectPolicy struct {
+	Mode        string `json:"mode,omitempty"`         // "never"、"calls"（每 every 次调用）或 "tokens"（累计输出超过 token_budget）
+	Every       int    `json:"every,omitempty"`        // mode=calls 的调用间隔
+	TokenBudget int    `json:"token_budget,omitempty"` // mode=tokens 的估算 token 数
+	Reminder    *bool  `json:"reminder,omitempty"`     // 未重新注入时是否追加身份提醒
+}
+
+// ReinjectSettings 是全局默认策略及按平台覆盖的策略；会话级策略通过 HTTP 接口设置
+type ReinjectSettings struct {
+	ReinjectPolicy
+	Platforms map[string]ReinjectPolicy `json:"platforms,omitempty"`
+}
+
+// OutputLimit 控制工具输出超限时的截断方式。零值字段使用默认值。
+type OutputLimit struct {
+	MaxLines int    `json:"max_lines,omitempty"`
+	MaxBytes int    `json:"max_bytes,omitempty"`
+	Mode     string `json:"mode,omitempty"` // "head"（保留开头）或 "head_tail"（保留开头和结尾，省略中间）
+}
+
+// OutputSettings 是默认截断配置及按工具名覆盖的配置
+type OutputSettings struct {
+	OutputLimit
+	Tools map[string]OutputLimit `json:"tools,omitempty"`
+}
+
+// RedactSettings 配置工具输出的脱敏：Skip 关闭指定的内置检测（"*" 表示全部），Patterns 追加自定义正则。
+// openlink 自身的 token 始终脱敏。
+type RedactSettings struct {
+	Skip     []string        `json:"skip,omitempty"`
+	Patterns []RedactPattern `json:"patterns,omitempty"`
+}
+
+// RedactPattern 是自定义脱敏正则；含捕获分组时只替换最后一个分组
+type RedactPattern struct {
+	Name  string `json:"name"`
+	Regex string `json:"regex"`
+}
+
+// PathSettings 配置工作目录之外工具可访问的目录，支持 ~/ 开头的路径。
+// ReadOnly 为 nil 时使用默认只读目录（~/.openlink、~/.claude、~/.agent），设为空数组则不开放。
+type PathSettings struct {
+	ReadOnly  []string `json:"read_only"`
+	ReadWrite []string `json:"read_write,omitempty"`
+}
+
+// RetentionPolicy 控制 ~/.openlink 下一类数据的最长保留时间和总大小上限。零值字段使用默认值，负数表示不限制。
+type RetentionPolicy struct {
+	MaxAgeDays int `json:"max_age_days,omitempty"`
+	MaxSizeMB  int `json:"max_size_mb,omitempty"`
 }
 
 type Settings struct {
-	Token     string `json:"token"`
-	CreatedAt string `json:"created_at"`
+	Token      string                     `json:"token"`
+	CreatedAt  string                     `json:"created_at"`
+	Search     *SearchConfig              `json:"search,omitempty"`
+	MCPServers map[string]MCPServerConfig `json:"mcp_servers,omitempty"`
+	Reinject   *ReinjectSettings          `json:"reinject,omitempty"`
+	Output     *OutputSettings            `json:"output,omitempty"`
+	Retention  map[string]RetentionPolicy `json:"retention,omitempty"`
+	Redact     *RedactSettings            `json:"redact,omitempty"`
+	Paths      *PathSettings              `json:"paths,omitempty"`
 }
@@ -1,6 +1,8 @@
 package prompts
 
-import _ "embed"
+import "embed"
 
-//go:embed init_prompt.txt
-var DefaultPrompt []byte
+// Templates 是内置的初始化提示词模板，文件名规则见 internal/prompt.Candidates
+//
+//go:embed *.txt
+var Templates embed.FS